@@ -10,6 +10,19 @@
 	let authToken = null;
 	let currentUser = null;
 
+	/**
+	 * Advertise the API's public feeds so browsers and feed readers can
+	 * discover them from the page.
+	 */
+	[['application/rss+xml', 'RSS', '/feed.rss'], ['application/atom+xml', 'Atom', '/feed.atom']].forEach(([type, title, path]) => {
+	  const link = document.createElement('link');
+	  link.rel = 'alternate';
+	  link.type = type;
+	  link.title = title;
+	  link.href = `${API_BASE_URL}${path}`;
+	  document.head.appendChild(link);
+	});
+
   /**
    * Easy selector helper function
    */
@@ -533,6 +546,28 @@
 		    statusEl.classList.add('alert', alertClass);
 		  };
 
+		  const showMediaStatus = (message, type = 'info') => {
+		    const statusEl = select('#media-status');
+		    if (!statusEl) return;
+		    statusEl.textContent = message;
+		    statusEl.classList.remove('d-none', 'alert-info', 'alert-success', 'alert-danger');
+		    const alertClass = type === 'error'
+		      ? 'alert-danger'
+		      : (type === 'success' ? 'alert-success' : 'alert-info');
+		    statusEl.classList.add('alert', alertClass);
+		  };
+
+		  const showRevisionsStatus = (message, type = 'info') => {
+		    const statusEl = select('#revisions-status');
+		    if (!statusEl) return;
+		    statusEl.textContent = message;
+		    statusEl.classList.remove('d-none', 'alert-info', 'alert-success', 'alert-danger');
+		    const alertClass = type === 'error'
+		      ? 'alert-danger'
+		      : (type === 'success' ? 'alert-success' : 'alert-info');
+		    statusEl.classList.add('alert', alertClass);
+		  };
+
 			  const showAuthStatus = (message, type = 'info') => {
 			    const statusEl = select('#auth-status');
 			    if (!statusEl) return;
@@ -545,6 +580,9 @@
 								  let allPosts = [];
 								  let activeCategoryFilter = '';
 								  let editingPostId = null;
+								  // Version of the post being edited, sent as If-Match so a save
+								  // never overwrites someone else's newer changes.
+								  let editingPostVersion = '';
 								  let currentPostsPage = 1;
 								  let totalPostsPages = 1;
 								  // Tracks whether we've successfully rendered posts at least once on this page.
@@ -568,11 +606,51 @@
 							    'cover-10': 'assets/img/blog-covers/cover-10.jpg',
 							  };
 
+							  // Keys of images uploaded through POST /media, served by the API.
+							  const MEDIA_KEY_PATTERN = /^[0-9a-f]{32}\.(jpg|png|gif|webp)$/;
+
 							  const resolvePostCoverUrl = (key) => {
 							    if (!key || typeof key !== 'string') return '';
+							    if (MEDIA_KEY_PATTERN.test(key)) return `${API_BASE_URL}/media/${key}`;
 							    return POST_COVER_IMAGES[key] || '';
 							  };
 
+							  // Lets the browser pick a variant of an uploaded cover for the
+							  // rendered size and reserve its space before it loads. The tiny
+							  // placeholder shows, blurred, behind the image in the meantime.
+							  const applyCoverImageVariants = (coverImg, coverWrapper, coverImage, sizes) => {
+							    if (!coverImage || !coverImg) return;
+							    if (coverImage.width && coverImage.height) {
+							      coverImg.width = coverImage.width;
+							      coverImg.height = coverImage.height;
+							    }
+							    const variants = Array.isArray(coverImage.variants) ? coverImage.variants : [];
+							    if (variants.length) {
+							      const candidates = variants.map((variant) => `${API_BASE_URL}${variant.url} ${variant.width}w`);
+							      if (coverImage.url && coverImage.width) {
+							        candidates.push(`${API_BASE_URL}${coverImage.url} ${coverImage.width}w`);
+							      }
+							      coverImg.srcset = candidates.join(', ');
+							      coverImg.sizes = sizes;
+							    }
+							    if (coverImage.placeholder && coverWrapper) {
+							      coverWrapper.classList.add('has-placeholder');
+							      coverWrapper.style.backgroundImage = `url("${coverImage.placeholder}")`;
+							    }
+							  };
+
+							  // Makes sure the cover select offers an uploaded image so it can be
+							  // selected, for example when editing a post that uses one.
+							  const ensureCoverOption = (coverSelect, key) => {
+							    if (!coverSelect || !key) return;
+							    const exists = Array.from(coverSelect.options).some((option) => option.value === key);
+							    if (exists) return;
+							    const option = document.createElement('option');
+							    option.value = key;
+							    option.textContent = `Uploaded image (${key.slice(0, 8)})`;
+							    coverSelect.appendChild(option);
+							  };
+
 									  // Map of user ID -> username for admin post analytics.
 										  let adminUsersById = {};
 									  const deriveDisplayNameFromIdentifier = (identifier) => {
@@ -799,6 +877,10 @@
 								        if (commentId != null) {
 								          li.dataset.commentId = String(commentId);
 								        }
+								        if (comment.version) {
+								          // Sent back as If-Match so an edit cannot overwrite a newer one.
+								          li.dataset.commentVersion = String(comment.version);
+								        }
 								        const commentUserId = comment.user_id || comment.userId || comment.UserID;
 								        if (commentUserId != null) {
 								          li.dataset.userId = String(commentUserId);
@@ -839,9 +921,16 @@
 								        header.appendChild(authorEl);
 								        header.appendChild(metaEl);
 									
-								        const contentEl = document.createElement('p');
+								        // content_html is sanitized by the server; fall back to the
+								        // raw text for responses that do not carry it.
+								        const contentEl = document.createElement('div');
 								        contentEl.className = 'blog-post-comment-content mb-0';
-								        contentEl.textContent = comment.content || '';
+								        contentEl.dataset.rawContent = comment.content || '';
+								        if (comment.content_html) {
+								          contentEl.innerHTML = comment.content_html;
+								        } else {
+								          contentEl.textContent = comment.content || '';
+								        }
 									
 								        li.appendChild(header);
 								        li.appendChild(contentEl);
@@ -1006,7 +1095,7 @@
 								
 								      li.dataset.editing = 'true';
 								
-								      const originalText = contentEl.textContent || '';
+								      const originalText = contentEl.dataset.rawContent || contentEl.textContent || '';
 								      const textarea = document.createElement('textarea');
 								      textarea.className = 'form-control form-control-sm blog-comment-edit-input mt-2';
 								      textarea.value = originalText;
@@ -1056,10 +1145,14 @@
 								        return;
 								      }
 								
+								      const headers = { 'Content-Type': 'application/json' };
+								      if (li.dataset.commentVersion) {
+								        headers['If-Match'] = `"${li.dataset.commentVersion}"`;
+								      }
 								      try {
 								        const data = await apiRequest(`/posts/${numericPostId}/comments/${commentId}`, {
 								          method: 'PUT',
-								          headers: { 'Content-Type': 'application/json' },
+								          headers,
 								          body: JSON.stringify({ content: newContent }),
 								        });
 								        const updated = data && (data.comment || data.Comment);
@@ -1077,6 +1170,10 @@
 								        }
 								      } catch (err) {
 								        console.error(err);
+								        if (err.status === 412) {
+								          // Someone else edited the comment: show their version instead.
+								          await loadPostComments(postId);
+								        }
 								        showBlogStatus(err.message || 'Could not update comment.', 'error');
 								      }
 								    }
@@ -1303,6 +1400,19 @@
 						    postReaderInitialized = true;
 						  };
 						
+						  // Post listings only include an excerpt, so the full body is loaded
+						  // on demand and cached on the list item, together with the sanitized
+						  // HTML the server renders from its Markdown.
+						  const loadPostBody = async (item) => {
+						    if (item.dataset.body !== undefined) return item.dataset.body;
+						    const post = await apiRequest(`/posts/${item.dataset.postId}`);
+						    item.dataset.bodyHtml = (post && post.content_html) || '';
+						    item.dataset.body = (post && post.content) || '';
+						    item.dataset.revisionLimit = post && post.revision_limit ? String(post.revision_limit) : '';
+						    item.dataset.version = post && post.version ? String(post.version) : '';
+						    return item.dataset.body;
+						  };
+						
 						  const openPostInReader = (item) => {
 						    ensurePostReader();
 						    if (!postReaderOverlay || !postReaderBody) return;
@@ -1312,15 +1422,27 @@
 						    document.body.classList.remove('blog-search-open');
 						  
 						    const postId = item.dataset.postId || '';
-						    const body = item.dataset.body || '';
+						    const body = item.dataset.body !== undefined ? item.dataset.body : item.dataset.excerpt || '';
 								  
 								    postReaderCurrentPostId = postId || null;
 								    resetPostReaderCommentsState();
 								  
-								    // Render Markdown (headings, lists, emphasis) for a better reading
-								    // experience in the overlay, while keeping stored content as plain
-								    // text/Markdown.
-								    postReaderBody.innerHTML = renderBasicMarkdown(body);
+								    // Prefer the server's rendering of the Markdown; the basic client-side
+								    // renderer only covers the excerpt shown while the post loads.
+								    postReaderBody.innerHTML = item.dataset.bodyHtml || renderBasicMarkdown(body);
+								    if (item.dataset.body === undefined && postId) {
+								      // Show the excerpt until the full body arrives.
+								      loadPostBody(item)
+								        .then((content) => {
+								          if (postReaderCurrentPostId === postId) {
+								            postReaderBody.innerHTML = item.dataset.bodyHtml || renderBasicMarkdown(content);
+								          }
+								        })
+								        .catch((err) => {
+								          console.error(err);
+								          showBlogStatus(err.message || 'Could not load post.', 'error');
+								        });
+								    }
 								  
 								    // Render reactions inside the expanded reader view.
 								    if (postReaderReactions) {
@@ -1424,7 +1546,7 @@
 			      postsToRender = postsToRender.filter((post) => (post.category || '') === activeCategoryFilter);
 			    }
 				    
-				    // Apply free-text search across title, description, category, and body.
+				    // Apply free-text search across title, description, category, and excerpt.
 				    const search = (currentPostsSearchQuery || '').trim().toLowerCase();
 				    if (search) {
 				      postsToRender = postsToRender.filter((post) => {
@@ -1432,11 +1554,13 @@
 				        const title = (post.title || '').toLowerCase();
 				        const description = (post.description || '').toLowerCase();
 				        const category = (post.category || '').toLowerCase();
-				        const body = (post.content || '').toLowerCase();
+				        const tags = (post.tags || []).join(' ');
+				        const body = (post.excerpt || '').toLowerCase();
 				        return (
 				          title.includes(search) ||
 				          description.includes(search) ||
 				          category.includes(search) ||
+				          tags.includes(search) ||
 				          body.includes(search)
 				        );
 				      });
@@ -1723,6 +1847,22 @@
 			    }
 			  };
 
+	  // toDateTimeLocal formats an RFC 3339 timestamp for a datetime-local
+	  // input in the browser's time zone; fromDateTimeLocal converts the input
+	  // value back to an ISO timestamp, or null when it is empty.
+	  const toDateTimeLocal = (value) => {
+	    const date = value ? new Date(value) : null;
+	    if (!date || Number.isNaN(date.getTime())) return '';
+	    const pad = (n) => String(n).padStart(2, '0');
+	    return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}T${pad(date.getHours())}:${pad(date.getMinutes())}`;
+	  };
+
+	  const fromDateTimeLocal = (value) => {
+	    const date = value ? new Date(value) : null;
+	    if (!date || Number.isNaN(date.getTime())) return null;
+	    return date.toISOString();
+	  };
+
 	  const apiRequest = async (path, options = {}) => {
 	    const url = `${API_BASE_URL}${path}`;
 	    const finalOptions = Object.assign({
@@ -1938,9 +2078,16 @@
 				        : 'list-group-item blog-post-item';
 		      item.dataset.postId = post.id;
 		      item.dataset.title = post.title || '';
+		      item.dataset.slug = post.slug || '';
 		      item.dataset.description = post.description || '';
 		      item.dataset.category = post.category || '';
-		      item.dataset.body = post.content || '';
+		      item.dataset.tags = (post.tags || []).join(', ');
+		      item.dataset.publishAt = post.publish_at || '';
+		      item.dataset.unpublishAt = post.unpublish_at || '';
+		      item.dataset.membersOnly = post.members_only ? 'true' : '';
+		      // Listings only carry an excerpt; the body is fetched from
+		      // /posts/:id when the post is opened or edited.
+		      item.dataset.excerpt = post.excerpt || '';
 		      item.dataset.status = post.status || '';
 		      item.dataset.created = post.created_at || '';
 
@@ -1957,6 +2104,9 @@
 				          const coverImg = document.createElement('img');
 				          coverImg.className = 'blog-post-cover';
 				          coverImg.src = coverUrl;
+				          coverImg.loading = 'lazy';
+				          coverImg.decoding = 'async';
+				          applyCoverImageVariants(coverImg, coverWrapper, post.cover_image, '(max-width: 575.98px) 100vw, 33vw');
 				          coverImg.alt = post.title
 				            ? `Cover for "${post.title}"`
 				            : 'Blog post cover image';
@@ -1996,6 +2146,15 @@
 				          draftBadge.className = 'badge bg-warning text-dark';
 				          draftBadge.textContent = 'Draft';
 				          metaWrapper.appendChild(draftBadge);
+				        }
+				        if ((isAdmin || isEditor) && post.status === 'scheduled') {
+				          const scheduledBadge = document.createElement('span');
+				          scheduledBadge.className = 'badge bg-info text-dark';
+				          const publishAt = post.publish_at ? new Date(post.publish_at) : null;
+				          scheduledBadge.textContent = publishAt && !Number.isNaN(publishAt.getTime())
+				            ? `Scheduled for ${publishAt.toLocaleString()}`
+				            : 'Scheduled';
+				          metaWrapper.appendChild(scheduledBadge);
 				        }
 							        if (!isGridLayout) {
 				          metaWrapper.appendChild(meta);
@@ -2014,6 +2173,17 @@
 					        descriptionEl.textContent = post.description;
 					        item.appendChild(descriptionEl);
 					      }
+					      if (Array.isArray(post.tags) && post.tags.length && !isGridLayout) {
+					        const tagsEl = document.createElement('div');
+					        tagsEl.className = 'mb-1 blog-post-tags';
+					        post.tags.forEach((tag) => {
+					          const badge = document.createElement('span');
+					          badge.className = 'badge bg-light text-secondary border me-1';
+					          badge.textContent = `#${tag}`;
+					          tagsEl.appendChild(badge);
+					        });
+					        item.appendChild(tagsEl);
+					      }
 					
 						      // We no longer render the body content snippet in the list/grid layouts.
 				      // Full post content is available via the centered reader view and
@@ -2100,12 +2270,19 @@
 		        editBtn.className = 'btn btn-sm btn-outline-primary blog-edit-post';
 		        editBtn.textContent = 'Edit';
 							
+		        const historyBtn = document.createElement('button');
+		        historyBtn.type = 'button';
+		        historyBtn.className = 'btn btn-sm btn-outline-secondary blog-history-post';
+		        historyBtn.textContent = 'History';
+
 		        const deleteBtn = document.createElement('button');
 		        deleteBtn.type = 'button';
 		        deleteBtn.className = 'btn btn-sm btn-outline-danger blog-delete-post';
 		        deleteBtn.textContent = 'Delete';
 							
 		        actions.appendChild(editBtn);
+		        // The history panel only exists on the admin page.
+		        if (select('#revisions-panel')) actions.appendChild(historyBtn);
 		        actions.appendChild(deleteBtn);
 		        item.appendChild(actions);
 		      }
@@ -2371,12 +2548,76 @@
 						    if (pagination) pagination.classList.remove('d-none');
 						  };
 						
+						  // Replace the options of the category filter and the post form's
+						  // category select with the managed categories from GET /categories,
+						  // keeping the first ("All" / "Choose") option and the current value.
+						  const loadCategories = async () => {
+						    let categories = [];
+						    try {
+						      const data = await apiRequest('/categories');
+						      categories = (data && Array.isArray(data.categories)) ? data.categories : [];
+						    } catch (err) {
+						      console.error('Failed to load categories', err);
+						      return;
+						    }
+						
+						    ['#blog-category-filter', '#post-category'].forEach((selector) => {
+						      const el = select(selector);
+						      if (!(el instanceof HTMLSelectElement)) return;
+						      const current = selector === '#blog-category-filter' ? activeCategoryFilter : el.value;
+						      while (el.options.length > 1) {
+						        el.remove(1);
+						      }
+						      categories.forEach((category) => {
+						        const option = document.createElement('option');
+						        option.value = category.name;
+						        option.textContent = category.name;
+						        el.appendChild(option);
+						      });
+						      el.value = categories.some((category) => category.name === current) ? current : '';
+						    });
+						  };
+						
+						  // Links from the feeds point at blog.html?post=<slug> and
+						  // blog.html?category=<name>.
+						  const applyLinkedCategory = () => {
+						    const category = new URLSearchParams(window.location.search).get('category');
+						    if (category) {
+						      activeCategoryFilter = category;
+						    }
+						  };
+
+						  const openLinkedPost = async () => {
+						    const slug = new URLSearchParams(window.location.search).get('post');
+						    if (!slug) return;
+						    let post = allPosts.find((p) => p && p.slug === slug);
+						    if (!post) {
+						      // The slug may be an older one that the server still resolves.
+						      try {
+						        post = await apiRequest(`/posts/by-slug/${encodeURIComponent(slug)}`);
+						      } catch (err) {
+						        showBlogStatus(err.message || 'Could not load post.', 'error');
+						        return;
+						      }
+						    }
+						    if (!post || post.id == null) return;
+
+						    let item = document.querySelector(`#posts-list [data-post-id="${post.id}"]`);
+						    if (!(item instanceof HTMLElement)) {
+						      item = document.createElement('div');
+						      item.dataset.postId = String(post.id);
+						      item.dataset.excerpt = post.excerpt || '';
+						    }
+						    openPostInReader(item);
+						  };
+
 						  const loadPosts = async () => {
 						    if (!authToken) {
 						      setAuthenticatedUI(false);
 						      blogPostsInitialized = false;
 						      return;
 						    }
+						    loadCategories();
 						
 						    const path = window.location.pathname || '';
 						    const isBlogPage =
@@ -2395,10 +2636,23 @@
 						    }
 						
 						    try {
-						      const posts = await apiRequest('/posts', {
-						        method: 'GET'
-						      });
-						      let normalized = Array.isArray(posts) ? posts : [];
+						      // GET /posts is paginated; follow next_cursor so the client-side
+						      // search and paging below still see every post.
+						      const posts = [];
+						      let cursor = '';
+						      do {
+						        const query = cursor
+						          ? `?limit=100&cursor=${encodeURIComponent(cursor)}`
+						          : '?limit=100';
+						        const page = await apiRequest(`/posts${query}`, {
+						          method: 'GET'
+						        });
+						        if (page && Array.isArray(page.posts)) {
+						          posts.push(...page.posts);
+						        }
+						        cursor = (page && page.next_cursor) || '';
+						      } while (cursor);
+						      let normalized = posts;
 						
 						      // On the main blog reader page, admins and editors should see the same
 						      // published posts that normal readers do. Drafts remain visible only
@@ -2410,10 +2664,17 @@
 						        });
 						      }
 						
+						      const isFirstLoad = !blogPostsInitialized;
 						      allPosts = normalized;
 						      currentPostsPage = 1;
 						      blogPostsInitialized = true;
+						      if (isBlogPage && isFirstLoad) {
+						        applyLinkedCategory();
+						      }
 						      applyPostFiltersAndRender();
+						      if (isBlogPage && isFirstLoad) {
+						        openLinkedPost();
+						      }
 						    } catch (err) {
 						      console.error(err);
 						      showBlogStatus(err.message || 'Could not load posts.', 'error');
@@ -2482,11 +2743,22 @@
 					      return;
 					    }
 				    const titleInput = select('#post-title');
+				    const slugInput = select('#post-slug');
 				    const descriptionInput = select('#post-description');
 				    const categoryInput = select('#post-category');
+				    const tagsInput = select('#post-tags');
 				    const bodyInput = select('#post-body');
 				    const coverSelect = select('#post-cover-key');
 				    const title = titleInput?.value.trim();
+				    // The server normalizes tags ("Go Lang" becomes "go-lang").
+				    const tags = (tagsInput?.value || '')
+				      .split(',')
+				      .map((tag) => tag.trim())
+				      .filter(Boolean);
+				    // Only send a slug the editor typed or changed; otherwise the
+				    // server keeps the current one or derives a new one from the title.
+				    const slugValue = slugInput?.value.trim() || '';
+				    const slug = slugValue !== (slugInput?.dataset.currentSlug || '') ? slugValue : '';
 				    const description = descriptionInput?.value.trim() || '';
 				    const category = categoryInput?.value.trim() || '';
 				    const coverImageKey = coverSelect && typeof coverSelect.value === 'string'
@@ -2506,15 +2778,36 @@
 		    if (submitter && submitter.dataset && submitter.dataset.status === 'draft') {
 		      status = 'draft';
 		    }
-		    const successMessage = isEditing
+		    // datetime-local inputs hold local time without a zone; send UTC.
+		    const publishAt = fromDateTimeLocal(select('#post-publish-at')?.value);
+		    const unpublishAt = fromDateTimeLocal(select('#post-unpublish-at')?.value);
+		    const membersOnly = Boolean(select('#post-members-only')?.checked);
+		    // Empty keeps the server's default number of revisions.
+		    const revisionLimit = Number.parseInt(select('#post-revision-limit')?.value || '', 10) || 0;
+		    if (status === 'published' && publishAt && new Date(publishAt) > new Date()) {
+		      status = 'scheduled';
+		    }
+		    let successMessage = isEditing
 		      ? (status === 'draft' ? 'Draft updated successfully.' : 'Post published successfully.')
 		      : (status === 'draft' ? 'Draft saved successfully.' : 'Post published successfully.');
+		    if (status === 'scheduled') {
+		      successMessage = `Post scheduled for ${new Date(publishAt).toLocaleString()}.`;
+		    }
+
+		    const headers = { 'Content-Type': 'application/json' };
+		    if (isEditing && editingPostVersion) {
+		      headers['If-Match'] = `"${editingPostVersion}"`;
+		    }
 
 		    try {
 		      await apiRequest(path, {
 		        method,
-		        headers: { 'Content-Type': 'application/json' },
-				        body: JSON.stringify({ title, description, category, cover_image_key: coverImageKey, content: body, status })
+		        headers,
+				        body: JSON.stringify({
+				          title, slug, description, category, tags, cover_image_key: coverImageKey, content: body, status,
+				          publish_at: publishAt, unpublish_at: unpublishAt, members_only: membersOnly,
+				          revision_limit: revisionLimit
+				        })
 		      });
 		      showBlogStatus(successMessage, 'success');
 		      if (event.target && typeof event.target.reset === 'function') {
@@ -2583,13 +2876,30 @@
 		      const bodyInput = select('#post-body');
 				      const coverSelect = select('#post-cover-key');
 		      if (titleInput) titleInput.value = '';
+		      const slugInput = select('#post-slug');
+		      if (slugInput) {
+		        slugInput.value = '';
+		        slugInput.dataset.currentSlug = '';
+		      }
 		      if (descriptionInput) descriptionInput.value = '';
 		      if (categoryInput) categoryInput.value = '';
+		      const tagsInput = select('#post-tags');
+		      if (tagsInput) tagsInput.value = '';
+		      const publishAtInput = select('#post-publish-at');
+		      if (publishAtInput) publishAtInput.value = '';
+		      const unpublishAtInput = select('#post-unpublish-at');
+		      if (unpublishAtInput) unpublishAtInput.value = '';
+		      const membersOnlyInput = select('#post-members-only');
+		      if (membersOnlyInput) membersOnlyInput.checked = false;
+		      const revisionLimitInput = select('#post-revision-limit');
+		      if (revisionLimitInput) revisionLimitInput.value = '';
 		      if (bodyInput) bodyInput.value = '';
 				      if (coverSelect) coverSelect.value = '';
 		
 		      createPostForm.classList.remove('d-none');
 		      if (btn) btn.textContent = 'Hide create form';
+		      hideMediaPanel();
+		      hideRevisionsPanel();
 		      if (adminPanel) {
 		        adminPanel.classList.add('d-none');
 		        if (adminPanelToggleBtn) adminPanelToggleBtn.textContent = 'Manage users';
@@ -2646,6 +2956,8 @@
 			        analysisPanel.classList.add('d-none');
 			        if (analysisToggleBtn) analysisToggleBtn.textContent = 'Blog analysis';
 			      }
+			      hideMediaPanel();
+			      hideRevisionsPanel();
 		      loadAdminUsers();
 		    } else {
 		      // Leave "manage users" mode: hide panel and show posts again.
@@ -2687,6 +2999,8 @@
 			        adminPanel.classList.add('d-none');
 			        if (adminPanelToggleBtn) adminPanelToggleBtn.textContent = 'Manage users';
 			      }
+			      hideMediaPanel();
+			      hideRevisionsPanel();
 			      // Ensure we have up-to-date user map and analytics data.
 			      loadAdminUsers();
 			    } else {
@@ -2697,6 +3011,396 @@
 			    }
 			  };
 
+			  const formatByteSize = (bytes) => {
+			    const n = Number(bytes) || 0;
+			    if (n < 1024) return `${n} B`;
+			    if (n < 1024 * 1024) return `${(n / 1024).toFixed(1)} KB`;
+			    return `${(n / (1024 * 1024)).toFixed(1)} MB`;
+			  };
+
+			  const renderMediaLibrary = (assets) => {
+			    const tbody = select('#media-body');
+			    if (!tbody) return;
+			    tbody.innerHTML = '';
+
+			    if (!Array.isArray(assets) || assets.length === 0) {
+			      const row = document.createElement('tr');
+			      const cell = document.createElement('td');
+			      cell.colSpan = 6;
+			      cell.className = 'text-muted small';
+			      cell.textContent = 'No images found.';
+			      row.appendChild(cell);
+			      tbody.appendChild(row);
+			      return;
+			    }
+
+			    assets.forEach((asset) => {
+			      const row = document.createElement('tr');
+
+			      const imageCell = document.createElement('td');
+			      const thumb = document.createElement('img');
+			      thumb.className = 'media-thumb';
+			      thumb.src = resolvePostCoverUrl(asset.key);
+			      thumb.alt = asset.filename || asset.key;
+			      thumb.loading = 'lazy';
+			      imageCell.appendChild(thumb);
+
+			      const fileCell = document.createElement('td');
+			      fileCell.textContent = asset.filename || asset.key;
+			      fileCell.title = asset.key;
+
+			      const sizeCell = document.createElement('td');
+			      sizeCell.textContent = asset.width && asset.height
+			        ? `${formatByteSize(asset.size)} (${asset.width}×${asset.height})`
+			        : formatByteSize(asset.size);
+
+			      const uploadedCell = document.createElement('td');
+			      const uploader = adminUsersById[Number(asset.uploader_id)] || `User #${asset.uploader_id}`;
+			      uploadedCell.textContent = `${formatPrettyDate(new Date(asset.created_at))} by ${uploader}`;
+
+			      const usedByCell = document.createElement('td');
+			      const usedBy = Array.isArray(asset.used_by) ? asset.used_by : [];
+			      usedByCell.textContent = usedBy.length
+			        ? usedBy
+			          .map((id) => {
+			            const post = allPosts.find((p) => String(p.id) === String(id));
+			            return post && post.title ? post.title : `Post #${id}`;
+			          })
+			          .join(', ')
+			        : 'Unused';
+			      if (!usedBy.length) usedByCell.className = 'text-muted';
+
+			      const actionsCell = document.createElement('td');
+			      actionsCell.className = 'text-end';
+			      const deleteBtn = document.createElement('button');
+			      deleteBtn.type = 'button';
+			      deleteBtn.className = 'btn btn-sm btn-outline-danger media-delete';
+			      deleteBtn.dataset.key = asset.key;
+			      deleteBtn.dataset.usedBy = String(usedBy.length);
+			      deleteBtn.textContent = 'Delete';
+			      actionsCell.appendChild(deleteBtn);
+
+			      row.append(imageCell, fileCell, sizeCell, uploadedCell, usedByCell, actionsCell);
+			      tbody.appendChild(row);
+			    });
+			  };
+
+			  const loadMediaLibrary = async () => {
+			    if (!authToken || !currentUser || currentUser.role !== 'admin') return;
+			    const query = select('#media-search')?.value.trim() || '';
+			    try {
+			      const data = await apiRequest(`/media${query ? `?q=${encodeURIComponent(query)}` : ''}`);
+			      renderMediaLibrary(data && Array.isArray(data.media) ? data.media : []);
+			    } catch (err) {
+			      console.error(err);
+			      showMediaStatus(err.message || 'Could not load the media library.', 'error');
+			    }
+			  };
+
+			  const hideMediaPanel = () => {
+			    const mediaPanel = select('#media-panel');
+			    if (!mediaPanel) return;
+			    mediaPanel.classList.add('d-none');
+			    const btn = select('#btn-toggle-media-panel');
+			    if (btn) btn.textContent = 'Media library';
+			  };
+
+			  const handleToggleMediaPanelClick = () => {
+			    if (!authToken || !currentUser || currentUser.role !== 'admin') {
+			      showBlogStatus('Only admins can manage media.', 'error');
+			      return;
+			    }
+			    const mediaPanel = select('#media-panel');
+			    if (!mediaPanel) return;
+			    const blogApp = select('#blog-app');
+			    const createPostForm = select('#create-post-form');
+			    const createPostToggleBtn = select('#btn-toggle-create-post');
+			    const adminPanel = select('#admin-panel');
+			    const adminPanelToggleBtn = select('#btn-toggle-admin-panel');
+			    const analysisPanel = select('#analysis-panel');
+			    const analysisToggleBtn = select('#btn-toggle-analysis-panel');
+
+			    const isHidden = mediaPanel.classList.contains('d-none');
+			    const btn = select('#btn-toggle-media-panel');
+
+			    if (isHidden) {
+			      // Enter "media library" mode: show the library, hide posts and other admin panels.
+			      mediaPanel.classList.remove('d-none');
+			      if (btn) btn.textContent = 'Hide media library';
+			      if (blogApp) blogApp.classList.add('d-none');
+			      if (createPostForm) {
+			        createPostForm.classList.add('d-none');
+			        const submitBtn = createPostForm.querySelector('button[type="submit"]');
+			        if (submitBtn) submitBtn.textContent = 'Publish post';
+			        editingPostId = null;
+			        if (createPostToggleBtn) createPostToggleBtn.textContent = 'Create a post';
+			      }
+			      if (adminPanel) {
+			        adminPanel.classList.add('d-none');
+			        if (adminPanelToggleBtn) adminPanelToggleBtn.textContent = 'Manage users';
+			      }
+			      if (analysisPanel) {
+			        analysisPanel.classList.add('d-none');
+			        if (analysisToggleBtn) analysisToggleBtn.textContent = 'Blog analysis';
+			      }
+			      // Load users first so uploaders show by name.
+			      loadAdminUsers().finally(loadMediaLibrary);
+			    } else {
+			      // Leave media library mode: hide the library and show posts again.
+			      hideMediaPanel();
+			      hideRevisionsPanel();
+			      if (blogApp) blogApp.classList.remove('d-none');
+			    }
+			  };
+
+			  let mediaSearchTimer = null;
+			  const handleMediaSearchInput = () => {
+			    window.clearTimeout(mediaSearchTimer);
+			    mediaSearchTimer = window.setTimeout(loadMediaLibrary, 250);
+			  };
+
+			  const handleMediaPanelClick = async (event) => {
+			    const target = event.target;
+			    if (!(target instanceof HTMLElement)) return;
+			    const deleteBtn = target.closest('.media-delete');
+			    if (!deleteBtn) return;
+			    const key = deleteBtn.dataset.key;
+			    if (!key) return;
+
+			    // The server refuses to delete images that posts still use unless
+			    // forced; say so up front rather than after a failed request.
+			    const usedBy = Number(deleteBtn.dataset.usedBy) || 0;
+			    const confirmText = usedBy
+			      ? `This image is used by ${usedBy} post(s), which will show no image. Delete it anyway?`
+			      : 'Permanently delete this image?';
+			    if (!window.confirm(confirmText)) return;
+
+			    try {
+			      await apiRequest(`/media/${encodeURIComponent(key)}${usedBy ? '?force=true' : ''}`, { method: 'DELETE' });
+			      showMediaStatus('Image deleted.', 'success');
+			    } catch (err) {
+			      console.error(err);
+			      showMediaStatus(err.message || 'Could not delete the image.', 'error');
+			    }
+			    await loadMediaLibrary();
+			  };
+
+			  const handleMediaSweepClick = async () => {
+			    try {
+			      const data = await apiRequest('/admin/media/unreferenced');
+			      const unused = data && Array.isArray(data.media) ? data.media : [];
+			      if (!unused.length) {
+			        showMediaStatus('There are no unused images older than a day.', 'info');
+			        return;
+			      }
+			      if (!window.confirm(`Delete ${unused.length} image(s) that no post uses?`)) return;
+			      const result = await apiRequest('/admin/media/sweep', { method: 'POST' });
+			      const deleted = result && Array.isArray(result.deleted) ? result.deleted.length : 0;
+			      const failed = result && Array.isArray(result.failed) ? result.failed.length : 0;
+			      showMediaStatus(
+			        failed ? `Deleted ${deleted} image(s); ${failed} could not be deleted.` : `Deleted ${deleted} image(s).`,
+			        failed ? 'error' : 'success'
+			      );
+			    } catch (err) {
+			      console.error(err);
+			      showMediaStatus(err.message || 'Could not delete unused images.', 'error');
+			    }
+			    await loadMediaLibrary();
+			  };
+
+			  let revisionsPostId = null;
+			  let revisionsLatest = 0;
+
+			  const renderRevisionList = (revisions, limit) => {
+			    const tbody = select('#revisions-body');
+			    if (!tbody) return;
+			    tbody.innerHTML = '';
+			    const limitEl = select('#revisions-limit');
+			    if (limitEl) limitEl.textContent = limit ? `The latest ${limit} revisions are kept.` : '';
+
+			    if (!revisions.length) {
+			      const row = document.createElement('tr');
+			      const cell = document.createElement('td');
+			      cell.colSpan = 5;
+			      cell.className = 'text-muted small';
+			      cell.textContent = 'No revisions yet. One is saved every time the post is edited.';
+			      row.appendChild(cell);
+			      tbody.appendChild(row);
+			      return;
+			    }
+
+			    revisions.forEach((rev) => {
+			      const row = document.createElement('tr');
+
+			      const numberCell = document.createElement('td');
+			      numberCell.textContent = rev.number === revisionsLatest ? `#${rev.number} (current)` : `#${rev.number}`;
+			      if (rev.restored_from) numberCell.textContent += `, restores #${rev.restored_from}`;
+
+			      const savedCell = document.createElement('td');
+			      savedCell.textContent = formatPrettyDate(new Date(rev.created_at));
+
+			      const editorCell = document.createElement('td');
+			      editorCell.textContent = adminUsersById[Number(rev.editor_id)] || `User #${rev.editor_id}`;
+
+			      const titleCell = document.createElement('td');
+			      titleCell.textContent = rev.title || '';
+
+			      const actionsCell = document.createElement('td');
+			      actionsCell.className = 'text-end';
+			      if (rev.number !== revisionsLatest) {
+			        const compareBtn = document.createElement('button');
+			        compareBtn.type = 'button';
+			        compareBtn.className = 'btn btn-sm btn-outline-primary revision-compare';
+			        compareBtn.dataset.number = String(rev.number);
+			        compareBtn.textContent = 'Compare with current';
+
+			        const restoreBtn = document.createElement('button');
+			        restoreBtn.type = 'button';
+			        restoreBtn.className = 'btn btn-sm btn-outline-danger ms-2 revision-restore';
+			        restoreBtn.dataset.number = String(rev.number);
+			        restoreBtn.textContent = 'Restore';
+
+			        actionsCell.append(compareBtn, restoreBtn);
+			      }
+
+			      row.append(numberCell, savedCell, editorCell, titleCell, actionsCell);
+			      tbody.appendChild(row);
+			    });
+			  };
+
+			  const renderRevisionDiff = (data) => {
+			    const container = select('#revisions-diff');
+			    if (!container) return;
+			    container.innerHTML = '';
+			    container.classList.remove('d-none');
+
+			    const heading = document.createElement('h4');
+			    heading.className = 'h6';
+			    heading.textContent = `Changes from #${data.from.number} to #${data.to.number}: ` +
+			      `${data.inserted} line(s) added, ${data.deleted} removed`;
+			    container.appendChild(heading);
+
+			    const changes = Array.isArray(data.changes) ? data.changes : [];
+			    if (changes.length) {
+			      const list = document.createElement('ul');
+			      list.className = 'small mb-2';
+			      changes.forEach((change) => {
+			        const show = (value) => {
+			          if (Array.isArray(value)) return value.join(', ') || '(none)';
+			          if (value === null || value === '') return '(none)';
+			          return String(value);
+			        };
+			        const li = document.createElement('li');
+			        li.textContent = `${change.field}: ${show(change.from)} → ${show(change.to)}`;
+			        list.appendChild(li);
+			      });
+			      container.appendChild(list);
+			    }
+
+			    const pre = document.createElement('pre');
+			    pre.className = 'revision-diff';
+			    (Array.isArray(data.lines) ? data.lines : []).forEach((line) => {
+			      const span = document.createElement('span');
+			      const marker = line.op === 'insert' ? '+ ' : (line.op === 'delete' ? '- ' : '  ');
+			      span.className = `revision-line revision-line-${line.op}`;
+			      span.textContent = `${marker}${line.text}\n`;
+			      pre.appendChild(span);
+			    });
+			    container.appendChild(pre);
+			  };
+
+			  const loadRevisionHistory = async () => {
+			    if (!revisionsPostId) return;
+			    try {
+			      const data = await apiRequest(`/posts/${revisionsPostId}/revisions`);
+			      const revisions = data && Array.isArray(data.revisions) ? data.revisions : [];
+			      revisionsLatest = revisions.length ? revisions[0].number : 0;
+			      renderRevisionList(revisions, data && data.revision_limit);
+			    } catch (err) {
+			      console.error(err);
+			      showRevisionsStatus(err.message || 'Could not load the revision history.', 'error');
+			    }
+			  };
+
+			  const hideRevisionsPanel = () => {
+			    const revisionsPanel = select('#revisions-panel');
+			    if (!revisionsPanel) return;
+			    revisionsPanel.classList.add('d-none');
+			    revisionsPostId = null;
+			    select('#revisions-diff')?.classList.add('d-none');
+			    select('#revisions-status')?.classList.add('d-none');
+			  };
+
+			  const openRevisionsPanel = (item) => {
+			    const revisionsPanel = select('#revisions-panel');
+			    if (!revisionsPanel) return;
+			    hideMediaPanel();
+			    const adminPanel = select('#admin-panel');
+			    if (adminPanel) {
+			      adminPanel.classList.add('d-none');
+			      const adminPanelToggleBtn = select('#btn-toggle-admin-panel');
+			      if (adminPanelToggleBtn) adminPanelToggleBtn.textContent = 'Manage users';
+			    }
+			    const analysisPanel = select('#analysis-panel');
+			    if (analysisPanel) {
+			      analysisPanel.classList.add('d-none');
+			      const analysisToggleBtn = select('#btn-toggle-analysis-panel');
+			      if (analysisToggleBtn) analysisToggleBtn.textContent = 'Blog analysis';
+			    }
+			    const blogApp = select('#blog-app');
+			    if (blogApp) blogApp.classList.add('d-none');
+
+			    hideRevisionsPanel();
+			    revisionsPostId = item.dataset.postId;
+			    const titleEl = select('#revisions-post-title');
+			    if (titleEl) titleEl.textContent = item.dataset.title || '';
+			    revisionsPanel.classList.remove('d-none');
+			    if (currentUser && currentUser.role === 'admin') {
+			      // Load users first so editors show by name.
+			      loadAdminUsers().finally(loadRevisionHistory);
+			    } else {
+			      loadRevisionHistory();
+			    }
+			  };
+
+			  const handleCloseRevisionsClick = () => {
+			    hideRevisionsPanel();
+			    const blogApp = select('#blog-app');
+			    if (blogApp) blogApp.classList.remove('d-none');
+			  };
+
+			  const handleRevisionsPanelClick = async (event) => {
+			    const target = event.target;
+			    if (!(target instanceof HTMLElement) || !revisionsPostId) return;
+			    const compareBtn = target.closest('.revision-compare');
+			    const restoreBtn = target.closest('.revision-restore');
+
+			    if (compareBtn) {
+			      try {
+			        const data = await apiRequest(
+			          `/posts/${revisionsPostId}/revisions/diff?from=${compareBtn.dataset.number}&to=${revisionsLatest}`
+			        );
+			        renderRevisionDiff(data);
+			      } catch (err) {
+			        console.error(err);
+			        showRevisionsStatus(err.message || 'Could not compare the revisions.', 'error');
+			      }
+			    } else if (restoreBtn) {
+			      const number = restoreBtn.dataset.number;
+			      if (!window.confirm(`Restore revision #${number}? The current version stays in the history.`)) return;
+			      try {
+			        await apiRequest(`/posts/${revisionsPostId}/revisions/${number}/restore`, { method: 'POST' });
+			        showRevisionsStatus(`Revision #${number} restored.`, 'success');
+			        select('#revisions-diff')?.classList.add('d-none');
+			        await loadRevisionHistory();
+			        await loadPosts();
+			      } catch (err) {
+			        console.error(err);
+			        showRevisionsStatus(err.message || 'Could not restore the revision.', 'error');
+			      }
+			    }
+			  };
+
 										  const handlePostListClick = async (event) => {
 									    	const target = event.target;
 									    	if (!(target instanceof HTMLElement)) return;
@@ -2750,6 +3454,7 @@
 						        // the card (including the reactions cluster) opens the reader.
 						        if (
 						          target.classList.contains('blog-edit-post') ||
+						          target.classList.contains('blog-history-post') ||
 						          target.classList.contains('blog-delete-post')
 						        ) {
 						          // Let the dedicated handlers above/below deal with these.
@@ -2765,6 +3470,7 @@
 						      	if (cardItem instanceof HTMLElement) {
 						        if (
 						          target.classList.contains('blog-edit-post') ||
+						          target.classList.contains('blog-history-post') ||
 						          target.classList.contains('blog-delete-post')
 						        ) {
 						          // Let the dedicated handlers manage these.
@@ -2783,7 +3489,9 @@
 				    const postId = item.dataset.postId;
 				    if (!postId) return;
 					
-				    if (target.classList.contains('blog-delete-post')) {
+				    if (target.classList.contains('blog-history-post')) {
+				      openRevisionsPanel(item);
+				    } else if (target.classList.contains('blog-delete-post')) {
 		      if (!window.confirm('Are you sure you want to delete this post?')) return;
 		      try {
 		        await apiRequest(`/posts/${postId}`, { method: 'DELETE' });
@@ -2795,12 +3503,17 @@
 		      }
 				    } else if (target.classList.contains('blog-edit-post')) {
 				      const titleEl = item.querySelector('.blog-post-title');
-				      const contentEl = item.querySelector('.blog-post-content');
 				      const currentTitle = item.dataset.title || (titleEl ? titleEl.textContent || '' : '');
 				      const currentDescription = item.dataset.description || '';
 				      const currentCategory = item.dataset.category || '';
-				      const currentBody =
-				        item.dataset.body || (contentEl ? contentEl.dataset.full || contentEl.textContent || '' : '');
+				      let currentBody;
+				      try {
+				        currentBody = await loadPostBody(item);
+				      } catch (err) {
+				        console.error(err);
+				        showBlogStatus(err.message || 'Could not load post.', 'error');
+				        return;
+				      }
 				      const currentCoverKey = item.dataset.coverKey || '';
 						
 				      const createPostForm = select('#create-post-form');
@@ -2813,11 +3526,30 @@
 						
 				      // Put the form into "edit" mode.
 				      editingPostId = postId;
+				      editingPostVersion = item.dataset.version || '';
 				      titleInput.value = currentTitle;
 				      descriptionInput.value = currentDescription;
+				      const slugInput = select('#post-slug');
+				      if (slugInput) {
+				        slugInput.value = item.dataset.slug || '';
+				        slugInput.dataset.currentSlug = item.dataset.slug || '';
+				      }
 				      categoryInput.value = currentCategory || '';
+				      const tagsInput = select('#post-tags');
+				      if (tagsInput) tagsInput.value = item.dataset.tags || '';
+				      const publishAtInput = select('#post-publish-at');
+				      if (publishAtInput) publishAtInput.value = toDateTimeLocal(item.dataset.publishAt);
+				      const unpublishAtInput = select('#post-unpublish-at');
+				      if (unpublishAtInput) unpublishAtInput.value = toDateTimeLocal(item.dataset.unpublishAt);
+				      const membersOnlyInput = select('#post-members-only');
+				      if (membersOnlyInput) membersOnlyInput.checked = item.dataset.membersOnly === 'true';
+				      const revisionLimitInput = select('#post-revision-limit');
+				      if (revisionLimitInput) revisionLimitInput.value = item.dataset.revisionLimit || '';
 				      bodyInput.value = currentBody;
-				      if (coverSelect) coverSelect.value = currentCoverKey || '';
+				      if (coverSelect) {
+				        ensureCoverOption(coverSelect, currentCoverKey);
+				        coverSelect.value = currentCoverKey || '';
+				      }
 		
 		      const submitBtn = createPostForm.querySelector('button[type="submit"]');
 		      if (submitBtn) submitBtn.textContent = 'Save changes';
@@ -2830,6 +3562,8 @@
 		      const adminPanelToggleBtn = select('#btn-toggle-admin-panel');
 		
 		      createPostForm.classList.remove('d-none');
+		      hideMediaPanel();
+		      hideRevisionsPanel();
 		      if (createToggleBtn) {
 		        createToggleBtn.classList.remove('d-none');
 		        createToggleBtn.textContent = 'Cancel editing';
@@ -2925,6 +3659,32 @@
 			    }
 			  };
 
+		  // Uploads the chosen image and selects it as the cover of the post
+		  // being edited.
+		  const handleCoverUploadChange = async (event) => {
+		    const input = event.target;
+		    if (!(input instanceof HTMLInputElement) || !input.files || !input.files.length) return;
+		    const formData = new FormData();
+		    formData.append('file', input.files[0]);
+		    input.disabled = true;
+		    try {
+		      const data = await apiRequest('/media', { method: 'POST', body: formData });
+		      const key = data && data.media ? data.media.key : '';
+		      const coverSelect = select('#post-cover-key');
+		      if (coverSelect && key) {
+		        ensureCoverOption(coverSelect, key);
+		        coverSelect.value = key;
+		      }
+		      showBlogStatus('Image uploaded.', 'success');
+		    } catch (err) {
+		      console.error(err);
+		      showBlogStatus(err.message || 'Could not upload image.', 'error');
+		    } finally {
+		      input.disabled = false;
+		      input.value = '';
+		    }
+		  };
+
 		  const handleCategoryFilterChange = (event) => {
 			    const selectEl = event.target;
 			    if (!(selectEl instanceof HTMLSelectElement)) return;
@@ -3092,6 +3852,30 @@
 			    if (analysisPanelToggleBtn) {
 			      analysisPanelToggleBtn.addEventListener('click', handleToggleAnalysisPanelClick);
 			    }
+			    const mediaPanelToggleBtn = select('#btn-toggle-media-panel');
+			    if (mediaPanelToggleBtn) {
+			      mediaPanelToggleBtn.addEventListener('click', handleToggleMediaPanelClick);
+			    }
+			    const mediaPanel = select('#media-panel');
+			    if (mediaPanel) {
+			      mediaPanel.addEventListener('click', handleMediaPanelClick);
+			    }
+			    const mediaSearchInput = select('#media-search');
+			    if (mediaSearchInput) {
+			      mediaSearchInput.addEventListener('input', handleMediaSearchInput);
+			    }
+			    const mediaSweepBtn = select('#btn-media-sweep');
+			    if (mediaSweepBtn) {
+			      mediaSweepBtn.addEventListener('click', handleMediaSweepClick);
+			    }
+			    const revisionsPanel = select('#revisions-panel');
+			    if (revisionsPanel) {
+			      revisionsPanel.addEventListener('click', handleRevisionsPanelClick);
+			    }
+			    const closeRevisionsBtn = select('#btn-close-revisions');
+			    if (closeRevisionsBtn) {
+			      closeRevisionsBtn.addEventListener('click', handleCloseRevisionsClick);
+			    }
 		    if (postsList) {
 		      postsList.addEventListener('click', handlePostListClick);
 		    }
@@ -3100,6 +3884,10 @@
 				    }
 				    if (categoryFilter) {
 				      categoryFilter.addEventListener('change', handleCategoryFilterChange);
+				    }
+				    const coverUploadInput = select('#post-cover-upload');
+				    if (coverUploadInput) {
+				      coverUploadInput.addEventListener('change', handleCoverUploadChange);
 				    }
 					    if (searchInput) {
 					      searchInput.addEventListener('input', handleSearchInput);
@@ -0,0 +1,193 @@
+// Package backup exports the whole blog (users, categories, posts and their
+// revisions, comments and reactions) as a newline-delimited JSON archive and
+// restores such an archive into whichever store is currently active.
+//
+// An archive is a sequence of JSON objects, one per line:
+//
+//	{"type":"header","format":"blog-backup","version":3,"created_at":"..."}
+//	{"type":"user","data":{...}}
+//	{"type":"category","data":{...}}
+//	{"type":"post","data":{...}}
+//	{"type":"revision","data":{...}}
+//	{"type":"comment","data":{...}}
+//	{"type":"reaction","data":{...}}
+//	{"type":"footer","counts":{"users":2,"categories":1,"posts":1,"revisions":2,"comments":0,"reactions":1}}
+//
+// Version 2 added category records and version 3 revision records; older
+// archives have none.
+//
+// Post records also carry "previous_slugs", the slugs the post had before,
+// so old slug URLs keep redirecting after a restore.
+//
+// The footer lets Restore detect a truncated archive before it writes
+// anything.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"example.com/blog_backend/models"
+)
+
+const (
+	// Format identifies blog backup archives in the header line.
+	Format = "blog-backup"
+	// Version is the archive version written by Export. Restore rejects
+	// archives with a newer version.
+	Version = 3
+)
+
+// Record types used in the "type" field of each line.
+const (
+	recordHeader   = "header"
+	recordUser     = "user"
+	recordCategory = "category"
+	recordPost     = "post"
+	recordRevision = "revision"
+	recordComment  = "comment"
+	recordReaction = "reaction"
+	recordFooter   = "footer"
+)
+
+// Counts is the number of records of each kind in an archive.
+type Counts struct {
+	Users      int `json:"users"`
+	Categories int `json:"categories"`
+	Posts      int `json:"posts"`
+	Revisions  int `json:"revisions"`
+	Comments   int `json:"comments"`
+	Reactions  int `json:"reactions"`
+}
+
+// archivedPost is the data of a post record.
+type archivedPost struct {
+	models.Post
+	PreviousSlugs []string `json:"previous_slugs,omitempty"`
+}
+
+// line is the envelope of every archive line. Only the fields that belong to
+// the line's type are set.
+type line struct {
+	Type      string          `json:"type"`
+	Format    string          `json:"format,omitempty"`
+	Version   int             `json:"version,omitempty"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Counts    *Counts         `json:"counts,omitempty"`
+}
+
+// Export writes every user, category, post, revision, comment and reaction
+// in the active store to w and returns how many of each it wrote. Records are
+// written as they are encoded, so w can be an HTTP response.
+func Export(ctx context.Context, w io.Writer) (*Counts, error) {
+	users, err := models.ExportUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	categories, err := models.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read categories: %w", err)
+	}
+	posts, err := models.GetAllPosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read posts: %w", err)
+	}
+	slugs, err := models.ListPostSlugs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read post slugs: %w", err)
+	}
+	revisions := make(map[int64][]models.PostRevision, len(posts))
+	for _, p := range posts {
+		if revisions[p.ID], err = models.ListPostRevisions(ctx, p.ID); err != nil {
+			return nil, fmt.Errorf("failed to read revisions of post %d: %w", p.ID, err)
+		}
+	}
+	comments, err := models.ListAllComments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comments: %w", err)
+	}
+	reactions, err := models.ListAllReactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reactions: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	now := time.Now().UTC()
+	if err := enc.Encode(line{Type: recordHeader, Format: Format, Version: Version, CreatedAt: &now}); err != nil {
+		return nil, err
+	}
+
+	counts := &Counts{}
+	write := func(recordType string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(line{Type: recordType, Data: data})
+	}
+
+	for _, u := range users {
+		if err := write(recordUser, u); err != nil {
+			return nil, err
+		}
+		counts.Users++
+	}
+	for _, c := range categories {
+		if err := write(recordCategory, c); err != nil {
+			return nil, err
+		}
+		counts.Categories++
+	}
+	previous := make(map[int64][]string)
+	for _, s := range slugs {
+		previous[s.PostID] = append(previous[s.PostID], s.Slug)
+	}
+	for _, p := range posts {
+		ap := archivedPost{Post: p}
+		for _, slug := range previous[p.ID] {
+			if slug != p.Slug {
+				ap.PreviousSlugs = append(ap.PreviousSlugs, slug)
+			}
+		}
+		if err := write(recordPost, ap); err != nil {
+			return nil, err
+		}
+		counts.Posts++
+	}
+	for _, p := range posts {
+		for _, r := range revisions[p.ID] {
+			if err := write(recordRevision, r); err != nil {
+				return nil, err
+			}
+			counts.Revisions++
+		}
+	}
+	for _, c := range comments {
+		if err := write(recordComment, c); err != nil {
+			return nil, err
+		}
+		counts.Comments++
+	}
+	for _, r := range reactions {
+		if err := write(recordReaction, r); err != nil {
+			return nil, err
+		}
+		counts.Reactions++
+	}
+
+	if err := enc.Encode(line{Type: recordFooter, Counts: counts}); err != nil {
+		return nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
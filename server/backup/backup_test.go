@@ -0,0 +1,237 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"example.com/blog_backend/db"
+	"example.com/blog_backend/models"
+)
+
+// seedStore installs a fresh in-memory store with two users, a category, a
+// post in it with a comment and two reactions, and returns it.
+func seedStore(t *testing.T) *models.MemoryStore {
+	t.Helper()
+	ctx := context.Background()
+
+	s := models.NewMemoryStore()
+	models.SetStore(s)
+
+	admin := &models.User{Username: "admin", Password: "admin-password"}
+	if err := admin.Save(ctx); err != nil {
+		t.Fatalf("failed to create admin: %v", err)
+	}
+	reader := &models.User{Username: "reader", Password: "reader-password"}
+	if err := reader.Save(ctx); err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	category := &models.Category{Name: "Notes", SortOrder: 1}
+	if err := category.Save(ctx); err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+	post := &models.Post{Title: "Hello", Content: "World", Category: "notes", AuthorID: admin.ID}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	if _, err := models.CreateComment(ctx, post.ID, reader.ID, reader.Username, "Nice"); err != nil {
+		t.Fatalf("failed to create comment: %v", err)
+	}
+	if _, err := models.SetPostReaction(ctx, admin.ID, post.ID, models.ReactionLike); err != nil {
+		t.Fatalf("failed to like post: %v", err)
+	}
+	if _, err := models.SetPostReaction(ctx, reader.ID, post.ID, models.ReactionDislike); err != nil {
+		t.Fatalf("failed to dislike post: %v", err)
+	}
+	return s
+}
+
+// exportArchive exports the seeded store, whose post has the given number
+// of revisions.
+func exportArchive(t *testing.T, revisions int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	counts, err := Export(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if *counts != (Counts{Users: 2, Categories: 1, Posts: 1, Revisions: revisions, Comments: 1, Reactions: 2}) {
+		t.Fatalf("unexpected export counts: %+v", counts)
+	}
+	return buf.Bytes()
+}
+
+// An archive restored into an empty SQLite store keeps IDs, timestamps,
+// counters, password hashes and slug history.
+func TestExportRestoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	seedStore(t)
+	renamed := &models.Post{ID: 1, Title: "Hello again", Content: "World", Category: "Notes", AuthorID: 1}
+	if err := renamed.Update(ctx, renamed.AuthorID); err != nil {
+		t.Fatalf("failed to rename seeded post: %v", err)
+	}
+	original, err := models.GetPostByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to load seeded post: %v", err)
+	}
+	revisions, err := models.ListPostRevisions(ctx, 1)
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("expected the rename to record two revisions, got %d (%v)", len(revisions), err)
+	}
+	archive := exportArchive(t, 2)
+
+	conn, err := db.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	defer conn.Close()
+	models.SetStore(models.NewSQLiteStore(conn))
+
+	report, err := Restore(ctx, bytes.NewReader(archive), Options{Mode: ModeReplace})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if report.Restored != (Counts{Users: 2, Categories: 1, Posts: 1, Revisions: 2, Comments: 1, Reactions: 2}) {
+		t.Fatalf("unexpected restore counts: %+v", report.Restored)
+	}
+	if !report.Verification.Clean() {
+		t.Fatalf("expected a clean verification, got %+v", report.Verification)
+	}
+
+	post, err := models.GetPostByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to load restored post: %v", err)
+	}
+	if !post.CreatedAt.Equal(original.CreatedAt) || post.LikesCount != 1 || post.DislikesCount != 1 || post.CommentsCount != 1 {
+		t.Fatalf("restored post differs: got %+v, want %+v", post, original)
+	}
+
+	if post.Category != "Notes" {
+		t.Fatalf("expected restored category Notes, got %q", post.Category)
+	}
+	if c, err := models.GetCategory(ctx, "notes"); err != nil || c.SortOrder != 1 {
+		t.Fatalf("expected the category to be restored, got %+v, %v", c, err)
+	}
+	if post.Slug != "hello-again" {
+		t.Fatalf("expected restored slug hello-again, got %q", post.Slug)
+	}
+	if old, moved, err := models.GetPostBySlug(ctx, "hello"); err != nil || !moved || old.ID != 1 {
+		t.Fatalf("expected the previous slug to redirect to post 1, got %+v, moved=%v, err=%v", old, moved, err)
+	}
+
+	restored, err := models.ListPostRevisions(ctx, 1)
+	if err != nil || len(restored) != len(revisions) {
+		t.Fatalf("expected %d restored revisions, got %d (%v)", len(revisions), len(restored), err)
+	}
+	for i, r := range restored {
+		want := revisions[i]
+		if r.Number != want.Number || r.Title != want.Title || r.Content != want.Content || r.EditorID != want.EditorID || !r.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("restored revision %d differs: got %+v, want %+v", i, r, want)
+		}
+	}
+	// Updates after the restore continue the history.
+	post.Content = "World, edited"
+	if err := post.Update(ctx, 1); err != nil {
+		t.Fatalf("failed to update restored post: %v", err)
+	}
+	if latest, err := models.ListPostRevisions(ctx, 1); err != nil || len(latest) != 3 || latest[0].Number != 3 {
+		t.Fatalf("expected revision 3 on top of the restored history, got %+v (%v)", latest, err)
+	}
+
+	login := &models.User{Username: "reader", Password: "reader-password"}
+	if err := login.ValidateCredentials(ctx); err != nil {
+		t.Fatalf("expected restored user to log in, got %v", err)
+	}
+	if login.ID != 2 || login.Role != "user" {
+		t.Fatalf("unexpected restored user: %+v", login)
+	}
+
+	// New records continue after the restored IDs.
+	next := &models.Post{Title: "Next", Content: "Post"}
+	if err := next.Save(ctx); err != nil {
+		t.Fatalf("failed to create post after restore: %v", err)
+	}
+	if next.ID != 2 {
+		t.Fatalf("expected next post ID 2, got %d", next.ID)
+	}
+}
+
+// Merge keeps records that are not in the archive; replace removes them.
+func TestRestoreMergeAndReplace(t *testing.T) {
+	ctx := context.Background()
+	seedStore(t)
+	archive := exportArchive(t, 0)
+
+	for _, tc := range []struct {
+		mode      string
+		wantPosts int
+	}{
+		{mode: ModeMerge, wantPosts: 2},
+		{mode: ModeReplace, wantPosts: 1},
+	} {
+		seedStore(t)
+		extra := &models.Post{Title: "Extra", Content: "Only in the target"}
+		if err := extra.Save(ctx); err != nil {
+			t.Fatalf("%s: failed to create extra post: %v", tc.mode, err)
+		}
+
+		if _, err := Restore(ctx, bytes.NewReader(archive), Options{Mode: tc.mode}); err != nil {
+			t.Fatalf("%s: Restore failed: %v", tc.mode, err)
+		}
+
+		posts, err := models.GetAllPosts(ctx)
+		if err != nil {
+			t.Fatalf("%s: failed to list posts: %v", tc.mode, err)
+		}
+		if len(posts) != tc.wantPosts {
+			t.Fatalf("%s: expected %d posts, got %d", tc.mode, tc.wantPosts, len(posts))
+		}
+	}
+}
+
+// A truncated archive is rejected before replace mode deletes anything.
+func TestRestoreRejectsTruncatedArchive(t *testing.T) {
+	ctx := context.Background()
+	seedStore(t)
+	archive := exportArchive(t, 0)
+
+	// Drop the footer line.
+	trimmed := bytes.TrimRight(archive, "\n")
+	truncated := trimmed[:bytes.LastIndexByte(trimmed, '\n')+1]
+
+	_, err := Restore(ctx, bytes.NewReader(truncated), Options{Mode: ModeReplace})
+	if !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("expected ErrInvalidArchive, got %v", err)
+	}
+
+	users, err := models.GetAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("failed to list users: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected existing users to survive a rejected restore, got %d", len(users))
+	}
+}
+
+// Counters that disagree with the archived reactions are reported after the
+// restore.
+func TestRestoreReportsCounterMismatch(t *testing.T) {
+	ctx := context.Background()
+	s := seedStore(t)
+	if err := s.SetPostCounters(ctx, 1, models.PostCounters{Likes: 5, Dislikes: 1, Comments: 1}); err != nil {
+		t.Fatalf("failed to corrupt counters: %v", err)
+	}
+	archive := exportArchive(t, 0)
+
+	models.SetStore(models.NewMemoryStore())
+	report, err := Restore(ctx, bytes.NewReader(archive), Options{Mode: ModeReplace})
+	if !errors.Is(err, ErrCounterMismatch) {
+		t.Fatalf("expected ErrCounterMismatch, got %v", err)
+	}
+	if report == nil || len(report.Verification.CounterMismatches) != 1 {
+		t.Fatalf("expected one counter mismatch in the report, got %+v", report)
+	}
+}
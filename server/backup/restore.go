@@ -0,0 +1,216 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"example.com/blog_backend/models"
+)
+
+// Restore modes.
+const (
+	// ModeMerge upserts the archive's records by ID and leaves everything
+	// else in the store alone.
+	ModeMerge = "merge"
+	// ModeReplace deletes all existing data before restoring the archive.
+	ModeReplace = "replace"
+)
+
+// ErrInvalidArchive is returned when the input is not a complete backup
+// archive that this version can read.
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+// ErrCounterMismatch is returned when, after a restore, some post counters do
+// not match the restored reactions and comments.
+var ErrCounterMismatch = errors.New("post counters do not match after restore")
+
+// Options controls a restore run.
+type Options struct {
+	// Mode is ModeMerge (the default when empty) or ModeReplace.
+	Mode string
+}
+
+// RestoreReport summarizes a restore run.
+type RestoreReport struct {
+	Mode     string `json:"mode"`
+	Restored Counts `json:"restored"`
+
+	// ConflictingUsers lists archived usernames whose ID or username belongs
+	// to a different account in the store; those users are skipped. This can
+	// only happen in merge mode.
+	ConflictingUsers []string `json:"conflicting_users,omitempty"`
+
+	// Verification is the consistency check run after the restore.
+	Verification *models.ConsistencyReport `json:"verification"`
+}
+
+// archive is a fully decoded backup.
+type archive struct {
+	users      []models.ExportedUser
+	categories []models.Category
+	posts      []archivedPost
+	revisions  []models.PostRevision
+	comments   []models.Comment
+	reactions  []models.PostReaction
+}
+
+// Restore reads an archive written by Export from r and writes its records
+// into the active store with their original IDs, timestamps and counters.
+//
+// The whole archive is decoded and checked against its footer before
+// anything is written, so a truncated or corrupt file never wipes the store
+// in replace mode. Afterwards the post counters are verified against the
+// stored reactions and comments; if they disagree Restore returns the report
+// together with ErrCounterMismatch.
+func Restore(ctx context.Context, r io.Reader, opts Options) (*RestoreReport, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = ModeMerge
+	}
+	if mode != ModeMerge && mode != ModeReplace {
+		return nil, fmt.Errorf("unknown restore mode %q (use %s or %s)", mode, ModeMerge, ModeReplace)
+	}
+
+	a, err := readArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == ModeReplace {
+		if err := models.DeleteAllData(ctx); err != nil {
+			return nil, fmt.Errorf("failed to clear existing data: %w", err)
+		}
+	}
+
+	report := &RestoreReport{Mode: mode}
+
+	for _, u := range a.users {
+		if err := models.ImportUser(ctx, u.User, u.PasswordHash); err != nil {
+			if errors.Is(err, models.ErrUserAlreadyExists) {
+				report.ConflictingUsers = append(report.ConflictingUsers, u.Username)
+				continue
+			}
+			return nil, fmt.Errorf("failed to restore user %d: %w", u.ID, err)
+		}
+		report.Restored.Users++
+	}
+	for _, c := range a.categories {
+		if err := models.ImportCategory(ctx, c); err != nil {
+			return nil, fmt.Errorf("failed to restore category %q: %w", c.Slug, err)
+		}
+		report.Restored.Categories++
+	}
+	for _, p := range a.posts {
+		if err := models.ImportPost(ctx, p.Post); err != nil {
+			return nil, fmt.Errorf("failed to restore post %d: %w", p.ID, err)
+		}
+		for _, slug := range p.PreviousSlugs {
+			// In merge mode another post may have taken the slug since; it
+			// keeps it.
+			err := models.ImportPostSlug(ctx, slug, p.ID)
+			if err != nil && !errors.Is(err, models.ErrSlugTaken) {
+				return nil, fmt.Errorf("failed to restore slug %q of post %d: %w", slug, p.ID, err)
+			}
+		}
+		report.Restored.Posts++
+	}
+	for _, rev := range a.revisions {
+		if err := models.ImportPostRevision(ctx, rev); err != nil {
+			return nil, fmt.Errorf("failed to restore revision %d of post %d: %w", rev.Number, rev.PostID, err)
+		}
+		report.Restored.Revisions++
+	}
+	for _, c := range a.comments {
+		if err := models.ImportComment(ctx, c); err != nil {
+			return nil, fmt.Errorf("failed to restore comment %s: %w", c.ID, err)
+		}
+		report.Restored.Comments++
+	}
+	for _, rr := range a.reactions {
+		if err := models.ImportReaction(ctx, rr.UserID, rr.PostID, rr.Reaction); err != nil {
+			return nil, fmt.Errorf("failed to restore reaction %d/%d: %w", rr.UserID, rr.PostID, err)
+		}
+		report.Restored.Reactions++
+	}
+
+	report.Verification, err = models.CheckConsistency(ctx, false)
+	if err != nil {
+		return report, fmt.Errorf("failed to verify restored data: %w", err)
+	}
+	if n := len(report.Verification.CounterMismatches); n > 0 {
+		return report, fmt.Errorf("%w: %d posts differ", ErrCounterMismatch, n)
+	}
+	return report, nil
+}
+
+// readArchive decodes every line of r and checks the header and footer.
+func readArchive(r io.Reader) (*archive, error) {
+	dec := json.NewDecoder(r)
+	a := &archive{}
+
+	var header line
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidArchive, err)
+	}
+	if header.Type != recordHeader || header.Format != Format {
+		return nil, fmt.Errorf("%w: missing %s header", ErrInvalidArchive, Format)
+	}
+	if header.Version < 1 || header.Version > Version {
+		return nil, fmt.Errorf("%w: unsupported version %d (this build reads up to %d)", ErrInvalidArchive, header.Version, Version)
+	}
+
+	for {
+		var l line
+		if err := dec.Decode(&l); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("%w: archive ends without a footer; it may be truncated", ErrInvalidArchive)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		var err error
+		switch l.Type {
+		case recordUser:
+			var u models.ExportedUser
+			err = json.Unmarshal(l.Data, &u)
+			a.users = append(a.users, u)
+		case recordCategory:
+			var c models.Category
+			err = json.Unmarshal(l.Data, &c)
+			a.categories = append(a.categories, c)
+		case recordPost:
+			var p archivedPost
+			err = json.Unmarshal(l.Data, &p)
+			a.posts = append(a.posts, p)
+		case recordRevision:
+			var rev models.PostRevision
+			err = json.Unmarshal(l.Data, &rev)
+			a.revisions = append(a.revisions, rev)
+		case recordComment:
+			var c models.Comment
+			err = json.Unmarshal(l.Data, &c)
+			a.comments = append(a.comments, c)
+		case recordReaction:
+			var rr models.PostReaction
+			err = json.Unmarshal(l.Data, &rr)
+			a.reactions = append(a.reactions, rr)
+		case recordFooter:
+			got := Counts{
+				Users: len(a.users), Categories: len(a.categories), Posts: len(a.posts), Revisions: len(a.revisions),
+				Comments: len(a.comments), Reactions: len(a.reactions),
+			}
+			if l.Counts == nil || *l.Counts != got {
+				return nil, fmt.Errorf("%w: footer counts %+v do not match the %+v records read", ErrInvalidArchive, l.Counts, got)
+			}
+			return a, nil
+		default:
+			return nil, fmt.Errorf("%w: unknown record type %q", ErrInvalidArchive, l.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad %s record: %v", ErrInvalidArchive, l.Type, err)
+		}
+	}
+}
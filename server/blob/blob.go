@@ -0,0 +1,30 @@
+// Package blob stores uploaded files under string keys. The application
+// picks one Store at start-up: the local filesystem for self-hosted installs
+// or a Google Cloud Storage bucket next to Firestore.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when no object exists under a key.
+var ErrNotFound = errors.New("blob not found")
+
+// Object is a stored file opened for reading. The caller closes Body.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	Modified    time.Time
+}
+
+// Store keeps objects by key. Keys are relative, slash-separated paths
+// chosen by the caller; Put overwrites an existing object.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
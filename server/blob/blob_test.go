@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	files, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	return map[string]Store{"file": files, "memory": NewMemoryStore()}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := s.Get(ctx, "a/b.png"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound before Put, got %v", err)
+			}
+
+			if err := s.Put(ctx, "a/b.png", "image/png", strings.NewReader("first")); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			if err := s.Put(ctx, "a/b.png", "image/png", strings.NewReader("second")); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+
+			obj, err := s.Get(ctx, "a/b.png")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			data, _ := io.ReadAll(obj.Body)
+			obj.Body.Close()
+			if string(data) != "second" || obj.ContentType != "image/png" || obj.Size != 6 {
+				t.Errorf("unexpected object %q %q %d", data, obj.ContentType, obj.Size)
+			}
+
+			if err := s.Delete(ctx, "a/b.png"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if err := s.Delete(ctx, "a/b.png"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound for a second Delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStoreRejectsEscapingKeys(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	for _, key := range []string{"", "../x.png", "/etc/passwd", "a/../../x.png"} {
+		if err := s.Put(context.Background(), key, "image/png", strings.NewReader("x")); err == nil {
+			t.Errorf("expected Put(%q) to fail", key)
+		}
+		if _, err := s.Get(context.Background(), key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected Get(%q) to report ErrNotFound, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+)
+
+// GCSStore keeps objects in a Google Cloud Storage bucket, optionally below
+// a prefix such as "media/".
+type GCSStore struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+// NewGCSStore returns a GCSStore for the named bucket.
+func NewGCSStore(client *storage.Client, bucket, prefix string) *GCSStore {
+	return &GCSStore{bucket: client.Bucket(bucket), prefix: prefix}
+}
+
+func (s *GCSStore) Put(ctx context.Context, key, contentType string, r io.Reader) error {
+	w := s.bucket.Object(s.prefix + key).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	// The object only exists once Close has succeeded.
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+func (s *GCSStore) Get(ctx context.Context, key string) (*Object, error) {
+	r, err := s.bucket.Object(s.prefix + key).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return &Object{
+		Body:        r,
+		ContentType: r.Attrs.ContentType,
+		Size:        r.Attrs.Size,
+		Modified:    r.Attrs.LastModified,
+	}, nil
+}
+
+func (s *GCSStore) Delete(ctx context.Context, key string) error {
+	if err := s.bucket.Object(s.prefix + key).Delete(ctx); err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
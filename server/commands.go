@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"example.com/blog_backend/backup"
+	"example.com/blog_backend/db"
+	"example.com/blog_backend/legacy"
+	"example.com/blog_backend/migrations"
+	"example.com/blog_backend/models"
+)
+
+// commands maps subcommand names to their implementations. Each command
+// receives the arguments that follow its name.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"backup":        backupCommand,
+	"fsck":          fsckCommand,
+	"import-legacy": importLegacyCommand,
+	"migrate":       migrateCommand,
+	"restore":       restoreCommand,
+}
+
+func runCommand(name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for n := range commands {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command (available: %s)", strings.Join(names, ", "))
+	}
+	// Ctrl-C cancels the command's context so long-running commands stop
+	// at the next storage call instead of being killed mid-write.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return command(ctx, args)
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// importLegacyCommand copies a pre-Firestore SQLite api.db into the active
+// store:
+//
+//	server import-legacy -db models/api.db [-dry-run]
+func importLegacyCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import-legacy", flag.ContinueOnError)
+	path := fs.String("db", "api.db", "path to the legacy SQLite database")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := legacy.ImportSQLite(ctx, *path, legacy.Options{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
+
+// backupCommand writes an NDJSON archive of every user, post, comment and
+// reaction to a file, or to stdout without -out:
+//
+//	server backup export [-out blog.ndjson]
+func backupCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "export" {
+		return errors.New("usage: backup export [-out file]")
+	}
+
+	fs := flag.NewFlagSet("backup export", flag.ContinueOnError)
+	out := fs.String("out", "", "archive file to write (default stdout)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		counts, err := backup.Export(ctx, os.Stdout)
+		if err != nil {
+			return err
+		}
+		log.Printf("exported %d users, %d categories, %d posts, %d revisions, %d comments, %d reactions", counts.Users, counts.Categories, counts.Posts, counts.Revisions, counts.Comments, counts.Reactions)
+		return nil
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	counts, err := backup.Export(ctx, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(counts)
+}
+
+// restoreCommand loads an archive written by "backup export":
+//
+//	server restore -in blog.ndjson [-mode merge|replace]
+func restoreCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := fs.String("in", "", "archive file to read")
+	mode := fs.String("mode", backup.ModeMerge, "merge upserts records by ID; replace deletes all existing data first")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("-in is required")
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := backup.Restore(ctx, f, backup.Options{Mode: *mode})
+	if report != nil {
+		if printErr := printJSON(report); err == nil {
+			err = printErr
+		}
+	}
+	return err
+}
+
+// fsckCommand checks post counters and looks for orphaned reactions and
+// comments, repairing them with -repair:
+//
+//	server fsck [-repair]
+//
+// It exits with an error when problems were found and not repaired, so it
+// can be used from scripts.
+func fsckCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "fix the problems that were found")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := models.CheckConsistency(ctx, *repair)
+	if err != nil {
+		return err
+	}
+	if err := printJSON(report); err != nil {
+		return err
+	}
+
+	if !report.Clean() && !report.Repaired {
+		return errors.New("inconsistencies found; run with -repair to fix them")
+	}
+	return nil
+}
+
+// migrateCommand applies pending Firestore data migrations, or lists their
+// state with -status:
+//
+//	server migrate [-status] [-batch-size 200]
+func migrateCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	statusOnly := fs.Bool("status", false, "list migrations and whether they have been applied")
+	batchSize := fs.Int("batch-size", migrations.DefaultBatchSize, "documents per batch")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	runner, err := newMigrationRunner()
+	if err != nil {
+		return err
+	}
+	runner.BatchSize = *batchSize
+
+	if *statusOnly {
+		records, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(records)
+	}
+
+	ran, err := runner.Run(ctx)
+	if err != nil {
+		return err
+	}
+	return printJSON(ran)
+}
+
+// newMigrationRunner returns a runner for the Firestore client set up by
+// initStore. Migrations only exist for Firestore; the SQLite backend creates
+// its schema on startup.
+func newMigrationRunner() (*migrations.Runner, error) {
+	if db.FirestoreClient == nil {
+		return nil, errors.New("migrations require STORAGE_BACKEND=firestore")
+	}
+
+	runner := migrations.NewRunner(db.FirestoreClient)
+	runner.Logf = log.Printf
+	return runner, nil
+}
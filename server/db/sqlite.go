@@ -0,0 +1,194 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DB is the shared SQLite handle used when the application runs with the
+// SQLite storage backend instead of Firestore.
+var DB *sql.DB
+
+// InitDB opens (or creates) the SQLite database at path, makes sure the
+// schema exists, and stores the handle in DB.
+func InitDB(path string) error {
+	conn, err := OpenSQLite(path)
+	if err != nil {
+		return err
+	}
+
+	DB = conn
+	return nil
+}
+
+// OpenSQLite opens the SQLite database at path and creates any missing
+// tables. Pass ":memory:" for a throwaway database, for example in tests.
+//
+// The pool is limited to a single connection: SQLite only allows one writer
+// at a time, and a single connection also keeps ":memory:" databases from
+// being duplicated per connection.
+func OpenSQLite(path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	if err := createTables(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func createTables(conn *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS users (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"username" TEXT NOT NULL UNIQUE,
+			"password_hash" TEXT NOT NULL,
+			"role" TEXT NOT NULL DEFAULT 'user'
+		)`,
+		`CREATE TABLE IF NOT EXISTS posts (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"slug" TEXT NOT NULL DEFAULT '',
+			"title" TEXT NOT NULL,
+			"description" TEXT NOT NULL DEFAULT '',
+			"category" TEXT NOT NULL DEFAULT '',
+			"cover_image_key" TEXT NOT NULL DEFAULT '',
+			"content" TEXT NOT NULL,
+			"excerpt" TEXT NOT NULL DEFAULT '',
+			"status" TEXT NOT NULL DEFAULT 'published',
+			"publish_at" DATETIME,
+			"unpublish_at" DATETIME,
+			"members_only" BOOLEAN NOT NULL DEFAULT 0,
+			"revision_limit" INTEGER NOT NULL DEFAULT 0,
+			"version" INTEGER NOT NULL DEFAULT 1,
+			"created_at" DATETIME NOT NULL,
+			"updated_at" DATETIME NOT NULL,
+			"author_id" INTEGER NOT NULL DEFAULT 0,
+			"likes_count" INTEGER NOT NULL DEFAULT 0,
+			"dislikes_count" INTEGER NOT NULL DEFAULT 0,
+			"comments_count" INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_category ON posts(category)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_status ON posts(status)`,
+		`CREATE TABLE IF NOT EXISTS categories (
+			"slug" TEXT PRIMARY KEY,
+			"name" TEXT NOT NULL,
+			"description" TEXT NOT NULL DEFAULT '',
+			"sort_order" INTEGER NOT NULL DEFAULT 0,
+			"created_at" DATETIME NOT NULL,
+			"updated_at" DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS media (
+			"key" TEXT PRIMARY KEY,
+			"filename" TEXT NOT NULL DEFAULT '',
+			"content_type" TEXT NOT NULL,
+			"size" INTEGER NOT NULL,
+			"width" INTEGER NOT NULL DEFAULT 0,
+			"height" INTEGER NOT NULL DEFAULT 0,
+			"uploader_id" INTEGER NOT NULL DEFAULT 0,
+			"created_at" DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_slugs (
+			"slug" TEXT PRIMARY KEY,
+			"post_id" INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_slugs_post_id ON post_slugs(post_id)`,
+		`CREATE TABLE IF NOT EXISTS post_tags (
+			"post_id" INTEGER NOT NULL,
+			"tag" TEXT NOT NULL,
+			PRIMARY KEY (post_id, tag)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON post_tags(tag)`,
+		`CREATE TABLE IF NOT EXISTS post_revisions (
+			"post_id" INTEGER NOT NULL,
+			"number" INTEGER NOT NULL,
+			"editor_id" INTEGER NOT NULL DEFAULT 0,
+			"created_at" DATETIME NOT NULL,
+			"restored_from" INTEGER NOT NULL DEFAULT 0,
+			"slug" TEXT NOT NULL DEFAULT '',
+			"title" TEXT NOT NULL,
+			"description" TEXT NOT NULL DEFAULT '',
+			"category" TEXT NOT NULL DEFAULT '',
+			"tags" TEXT NOT NULL DEFAULT '',
+			"cover_image_key" TEXT NOT NULL DEFAULT '',
+			"content" TEXT NOT NULL,
+			"status" TEXT NOT NULL,
+			"publish_at" DATETIME,
+			"unpublish_at" DATETIME,
+			"members_only" BOOLEAN NOT NULL DEFAULT 0,
+			PRIMARY KEY (post_id, number)
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_comments (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"post_id" INTEGER NOT NULL,
+			"user_id" INTEGER NOT NULL,
+			"author_name" TEXT NOT NULL,
+			"content" TEXT NOT NULL,
+			"content_html" TEXT NOT NULL DEFAULT '',
+			"render_version" INTEGER NOT NULL DEFAULT 0,
+			"version" INTEGER NOT NULL DEFAULT 1,
+			"created_at" DATETIME NOT NULL,
+			"updated_at" DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_comments_post_id ON post_comments(post_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_comments_user_id ON post_comments(user_id)`,
+		`CREATE TABLE IF NOT EXISTS post_reactions (
+			"user_id" INTEGER NOT NULL,
+			"post_id" INTEGER NOT NULL,
+			"reaction" TEXT NOT NULL CHECK (reaction IN ('like','dislike')),
+			PRIMARY KEY (user_id, post_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_reactions_post_id ON post_reactions(post_id)`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create SQLite schema: %w", err)
+		}
+	}
+	return addMissingColumns(conn)
+}
+
+// addedColumns lists columns added to existing tables after their first
+// release, with the definition used to add them to older databases.
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"posts", "excerpt", `TEXT NOT NULL DEFAULT ''`},
+	{"posts", "slug", `TEXT NOT NULL DEFAULT ''`},
+	{"posts", "publish_at", `DATETIME`},
+	{"posts", "unpublish_at", `DATETIME`},
+	{"posts", "members_only", `BOOLEAN NOT NULL DEFAULT 0`},
+	{"posts", "revision_limit", `INTEGER NOT NULL DEFAULT 0`},
+	{"posts", "version", `INTEGER NOT NULL DEFAULT 1`},
+	{"post_comments", "content_html", `TEXT NOT NULL DEFAULT ''`},
+	{"post_comments", "render_version", `INTEGER NOT NULL DEFAULT 0`},
+	{"post_comments", "version", `INTEGER NOT NULL DEFAULT 1`},
+}
+
+// addMissingColumns upgrades databases created before a column in
+// addedColumns existed. CREATE TABLE IF NOT EXISTS leaves such tables as they
+// were.
+func addMissingColumns(conn *sql.DB) error {
+	for _, c := range addedColumns {
+		var count int
+		err := conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect SQLite table %s: %w", c.table, err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := conn.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN "%s" %s`, c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add %s.%s column: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
@@ -1,5 +0,0 @@
-package db
-
-// InitDB is kept for backwards compatibility but is currently a no-op, since
-// posts and reactions are stored in Firestore via InitFirestore.
-func InitDB() {}
@@ -0,0 +1,126 @@
+// Package diff compares two texts line by line.
+package diff
+
+import "strings"
+
+// Op says what happened to a line between the old and the new text.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// maxCells bounds the work spent on the lines that differ: when the old
+// and new middle sections (after removing the common start and end) have
+// more than this many line pairs between them, they are reported as
+// replaced outright rather than searched for common lines.
+const maxCells = 4 << 20
+
+// Line is one line of a diff. OldLine and NewLine are its 1-based numbers
+// in the old and new text, zero for an inserted and a deleted line
+// respectively.
+type Line struct {
+	Op      Op     `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Lines returns the line-level diff from one text to another: every line of
+// both in order, with the lines they share (a longest common subsequence)
+// marked Equal. Where lines were replaced, the deleted ones come first.
+// Line endings are not part of Text, and "\r\n" counts as "\n".
+func Lines(from, to string) []Line {
+	a, b := split(from), split(to)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	out := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		out = append(out, Line{Op: Equal, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	out = appendMiddle(out, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)
+	for i := suffix; i > 0; i-- {
+		out = append(out, Line{Op: Equal, Text: a[len(a)-i], OldLine: len(a) - i + 1, NewLine: len(b) - i + 1})
+	}
+	return out
+}
+
+// Stats counts the inserted and deleted lines of a diff.
+func Stats(lines []Line) (inserted, deleted int) {
+	for _, l := range lines {
+		switch l.Op {
+		case Insert:
+			inserted++
+		case Delete:
+			deleted++
+		}
+	}
+	return inserted, deleted
+}
+
+// split cuts text into lines. A trailing newline does not start another
+// line, and an empty text has none.
+func split(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// appendMiddle appends the diff of a and b, which start at line offsets
+// aOff and bOff of their texts, using the classic dynamic programming
+// table of common subsequence lengths.
+func appendMiddle(out []Line, a, b []string, aOff, bOff int) []Line {
+	if len(a)*len(b) > maxCells {
+		for i, text := range a {
+			out = append(out, Line{Op: Delete, Text: text, OldLine: aOff + i + 1})
+		}
+		for j, text := range b {
+			out = append(out, Line{Op: Insert, Text: text, NewLine: bOff + j + 1})
+		}
+		return out
+	}
+
+	// lcs[i*(len(b)+1)+j] is the length of the longest common subsequence
+	// of a[i:] and b[j:].
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, Line{Op: Equal, Text: a[i], OldLine: aOff + i + 1, NewLine: bOff + j + 1})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[(i+1)*width+j] >= lcs[i*width+j+1]):
+			out = append(out, Line{Op: Delete, Text: a[i], OldLine: aOff + i + 1})
+			i++
+		default:
+			out = append(out, Line{Op: Insert, Text: b[j], NewLine: bOff + j + 1})
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	got := Lines("title\nkeep\nold one\nold two\nend\n", "title\nkeep\nnew one\nend\nadded")
+	want := []Line{
+		{Op: Equal, Text: "title", OldLine: 1, NewLine: 1},
+		{Op: Equal, Text: "keep", OldLine: 2, NewLine: 2},
+		{Op: Delete, Text: "old one", OldLine: 3},
+		{Op: Delete, Text: "old two", OldLine: 4},
+		{Op: Insert, Text: "new one", NewLine: 3},
+		{Op: Equal, Text: "end", OldLine: 5, NewLine: 4},
+		{Op: Insert, Text: "added", NewLine: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff:\n got %+v\nwant %+v", got, want)
+	}
+	if inserted, deleted := Stats(got); inserted != 2 || deleted != 2 {
+		t.Errorf("expected 2 insertions and 2 deletions, got %d and %d", inserted, deleted)
+	}
+}
+
+func TestLinesCommonSubsequence(t *testing.T) {
+	got := Lines("a\nb\nc\nd", "b\nx\nd\ne")
+	var ops []string
+	for _, l := range got {
+		ops = append(ops, string(l.Op[0])+l.Text)
+	}
+	if want := "da eb dc ix ed ie"; strings.Join(ops, " ") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(ops, " "))
+	}
+}
+
+func TestLinesEdgeCases(t *testing.T) {
+	if got := Lines("", ""); len(got) != 0 {
+		t.Errorf("expected no lines for empty texts, got %+v", got)
+	}
+	if got := Lines("same\r\ntext\n", "same\ntext"); len(got) != 2 || got[0].Op != Equal || got[1].Op != Equal {
+		t.Errorf("expected line endings to be ignored, got %+v", got)
+	}
+	got := Lines("", "one\ntwo")
+	if inserted, deleted := Stats(got); inserted != 2 || deleted != 0 {
+		t.Errorf("expected 2 insertions, got %+v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package feed
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFeed = Feed{
+	Title:       "Blog",
+	Description: "Latest posts",
+	Link:        "https://blog.example.com/blog.html",
+	Self:        "https://api.example.com/feed.atom",
+	Author:      "Blog",
+	Updated:     time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+	Entries: []Entry{{
+		ID:         "tag:blog.example.com,2024-03-01:post-1",
+		Title:      "Fish & chips <3",
+		Link:       "https://blog.example.com/blog.html?post=fish-chips",
+		Summary:    "A summary",
+		Categories: []string{"Food", "uk"},
+		Published:  time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		Updated:    time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+	}},
+}
+
+func TestRSS(t *testing.T) {
+	data, err := testFeed.RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title      string   `xml:"title"`
+				GUID       string   `xml:"guid"`
+				Categories []string `xml:"category"`
+				PubDate    string   `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, data)
+	}
+	if doc.Version != "2.0" || doc.Channel.Title != "Blog" || doc.Channel.LastBuildDate != "Sat, 02 Mar 2024 10:00:00 +0000" {
+		t.Errorf("unexpected channel %+v", doc.Channel)
+	}
+	if len(doc.Channel.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(doc.Channel.Items))
+	}
+	item := doc.Channel.Items[0]
+	if item.Title != "Fish & chips <3" || item.GUID != testFeed.Entries[0].ID || item.PubDate != "Fri, 01 Mar 2024 09:00:00 +0000" {
+		t.Errorf("unexpected item %+v", item)
+	}
+	if !reflect.DeepEqual(item.Categories, []string{"Food", "uk"}) {
+		t.Errorf("unexpected categories %v", item.Categories)
+	}
+	if !strings.Contains(string(data), `<atom:link href="https://api.example.com/feed.atom" rel="self"`) {
+		t.Errorf("expected a self link in %s", data)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	data, err := testFeed.Atom()
+	if err != nil {
+		t.Fatalf("Atom failed: %v", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Author  string   `xml:"author>name"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Link      struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, data)
+	}
+	if doc.ID != testFeed.Self || doc.Updated != "2024-03-02T10:00:00Z" || doc.Author != "Blog" {
+		t.Errorf("unexpected feed %+v", doc)
+	}
+	if len(doc.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(doc.Entries))
+	}
+	entry := doc.Entries[0]
+	if entry.ID != testFeed.Entries[0].ID || entry.Link.Href != testFeed.Entries[0].Link || entry.Published != "2024-03-01T09:00:00Z" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if len(entry.Categories) != 2 || entry.Categories[0].Term != "Food" {
+		t.Errorf("unexpected categories %+v", entry.Categories)
+	}
+}
@@ -0,0 +1,457 @@
+// Package legacy imports data from the SQLite api.db files used before the
+// blog moved to Firestore into whichever store is currently active.
+package legacy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"example.com/blog_backend/models"
+)
+
+// Options controls an import run.
+type Options struct {
+	// DryRun reads and validates the legacy database and fills in the report
+	// without writing anything to the active store.
+	DryRun bool
+}
+
+// Report summarizes an import run. In a dry run the counts describe what
+// would have been written.
+type Report struct {
+	Users     int `json:"users"`
+	Posts     int `json:"posts"`
+	Comments  int `json:"comments"`
+	Reactions int `json:"reactions"`
+
+	// ConflictingUsers lists legacy usernames whose ID or username is already
+	// taken by a different account in the active store; those users are
+	// skipped.
+	ConflictingUsers []string `json:"conflicting_users,omitempty"`
+
+	// OrphanedComments and OrphanedReactions count legacy rows that point at
+	// a post or user that does not exist in the legacy database.
+	OrphanedComments  int `json:"orphaned_comments"`
+	OrphanedReactions int `json:"orphaned_reactions"`
+}
+
+type legacyUser struct {
+	user         models.User
+	passwordHash string
+}
+
+type legacyReaction struct {
+	userID, postID int64
+	reaction       string
+}
+
+// ImportSQLite reads the legacy SQLite database at path and writes its users
+// (keeping their bcrypt hashes and roles), posts, comments and reactions into
+// the active store under their original numeric IDs. Post counters are
+// recomputed from the imported reactions and comments.
+//
+// Every write is an upsert keyed by ID, so running the import again over the
+// same file leaves the store unchanged.
+func ImportSQLite(ctx context.Context, path string, opts Options) (*Report, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("cannot open legacy database: %w", err)
+	}
+
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open legacy database: %w", err)
+	}
+	defer conn.Close()
+
+	users, err := readUsers(conn)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := readPosts(conn)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := readComments(conn, users)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := readReactions(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+
+	postIndex := make(map[int64]*models.Post, len(posts))
+	for i := range posts {
+		postIndex[posts[i].ID] = &posts[i]
+	}
+	userIndex := make(map[int64]bool, len(users))
+	for _, u := range users {
+		userIndex[u.user.ID] = true
+	}
+
+	// Recompute the aggregate counters from the rows we are about to import
+	// rather than trusting the legacy columns.
+	var validComments []models.Comment
+	for _, c := range comments {
+		post, ok := postIndex[c.PostID]
+		if !ok {
+			report.OrphanedComments++
+			continue
+		}
+		post.CommentsCount++
+		validComments = append(validComments, c)
+	}
+
+	var validReactions []legacyReaction
+	for _, r := range reactions {
+		post, ok := postIndex[r.postID]
+		if !ok || !userIndex[r.userID] || (r.reaction != models.ReactionLike && r.reaction != models.ReactionDislike) {
+			report.OrphanedReactions++
+			continue
+		}
+		if r.reaction == models.ReactionLike {
+			post.LikesCount++
+		} else {
+			post.DislikesCount++
+		}
+		validReactions = append(validReactions, r)
+	}
+
+	for _, u := range users {
+		conflict, err := userConflicts(ctx, u.user)
+		if err != nil {
+			return nil, err
+		}
+		if conflict {
+			report.ConflictingUsers = append(report.ConflictingUsers, u.user.Username)
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := models.ImportUser(ctx, u.user, u.passwordHash); err != nil {
+				if errors.Is(err, models.ErrUserAlreadyExists) {
+					report.ConflictingUsers = append(report.ConflictingUsers, u.user.Username)
+					continue
+				}
+				return nil, fmt.Errorf("failed to import user %d: %w", u.user.ID, err)
+			}
+		}
+		report.Users++
+	}
+
+	for _, p := range posts {
+		if !opts.DryRun {
+			if err := models.ImportPost(ctx, p); err != nil {
+				return nil, fmt.Errorf("failed to import post %d: %w", p.ID, err)
+			}
+		}
+		report.Posts++
+	}
+
+	for _, c := range validComments {
+		if !opts.DryRun {
+			if err := models.ImportComment(ctx, c); err != nil {
+				return nil, fmt.Errorf("failed to import comment %s: %w", c.ID, err)
+			}
+		}
+		report.Comments++
+	}
+
+	for _, r := range validReactions {
+		if !opts.DryRun {
+			if err := models.ImportReaction(ctx, r.userID, r.postID, r.reaction); err != nil {
+				return nil, fmt.Errorf("failed to import reaction %d/%d: %w", r.userID, r.postID, err)
+			}
+		}
+		report.Reactions++
+	}
+
+	return report, nil
+}
+
+// userConflicts reports whether u's username or ID is already used by a
+// different account in the active store.
+func userConflicts(ctx context.Context, u models.User) (bool, error) {
+	byName, err := models.GetUserByUsername(ctx, u.Username)
+	if err == nil && byName.ID != u.ID {
+		return true, nil
+	}
+	if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+		return false, fmt.Errorf("failed to look up user %q: %w", u.Username, err)
+	}
+
+	byID, err := models.GetUserByID(ctx, u.ID)
+	if err == nil && byID.Username != u.Username {
+		return true, nil
+	}
+	if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+		return false, fmt.Errorf("failed to look up user %d: %w", u.ID, err)
+	}
+
+	return false, nil
+}
+
+// tableColumns returns the column names of table, or nil if the table does
+// not exist. Legacy databases were created by several versions of the
+// server, so the importer adapts to whichever columns are present.
+func tableColumns(conn *sql.DB, table string) (map[string]bool, error) {
+	rows, err := conn.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect legacy table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns map[string]bool
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to inspect legacy table %s: %w", table, err)
+		}
+		if columns == nil {
+			columns = make(map[string]bool)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// pick returns the first of names that exists in columns, or fallback (an SQL
+// literal) when none do.
+func pick(columns map[string]bool, fallback string, names ...string) string {
+	for _, name := range names {
+		if columns[name] {
+			return `"` + name + `"`
+		}
+	}
+	return fallback
+}
+
+func readUsers(conn *sql.DB) ([]legacyUser, error) {
+	columns, err := tableColumns(conn, "users")
+	if err != nil || columns == nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(`SELECT id, username, ` +
+		pick(columns, "''", "password_hash", "password") + `, ` +
+		pick(columns, "'user'", "role") +
+		` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []legacyUser
+	for rows.Next() {
+		var u legacyUser
+		if err := rows.Scan(&u.user.ID, &u.user.Username, &u.passwordHash, &u.user.Role); err != nil {
+			return nil, fmt.Errorf("failed to decode legacy user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func readPosts(conn *sql.DB) ([]models.Post, error) {
+	columns, err := tableColumns(conn, "posts")
+	if err != nil || columns == nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(`SELECT id, title, ` +
+		pick(columns, "''", "description") + `, ` +
+		pick(columns, "''", "category") + `, ` +
+		pick(columns, "''", "cover_image_key") + `, ` +
+		pick(columns, "''", "content", "body") + `, ` +
+		pick(columns, "'published'", "status") + `, ` +
+		pick(columns, "NULL", "created_at", "dateTime") + `, ` +
+		pick(columns, "NULL", "updated_at", "created_at", "dateTime") + `, ` +
+		pick(columns, "0", "author_id", "user_id") +
+		` FROM posts ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var p models.Post
+		var description, category, coverImageKey, content, status sql.NullString
+		var createdAt, updatedAt interface{}
+		var authorID sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.Title, &description, &category, &coverImageKey, &content, &status, &createdAt, &updatedAt, &authorID); err != nil {
+			return nil, fmt.Errorf("failed to decode legacy post: %w", err)
+		}
+
+		p.Description = description.String
+		p.Category = category.String
+		p.CoverImageKey = coverImageKey.String
+		p.Content = content.String
+		p.Status = status.String
+		if p.Status != "draft" {
+			p.Status = "published"
+		}
+		p.CreatedAt = parseTime(createdAt)
+		p.UpdatedAt = parseTime(updatedAt)
+		if p.UpdatedAt.IsZero() {
+			p.UpdatedAt = p.CreatedAt
+		}
+		p.AuthorID = authorID.Int64
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Later legacy versions kept post bodies in a versioned post_contents
+	// table; the newest version wins.
+	contentColumns, err := tableColumns(conn, "post_contents")
+	if err != nil {
+		return nil, err
+	}
+	if contentColumns != nil {
+		bodies, err := readLatestContents(conn)
+		if err != nil {
+			return nil, err
+		}
+		for i := range posts {
+			if body, ok := bodies[posts[i].ID]; ok {
+				posts[i].Content = body
+			}
+		}
+	}
+
+	return posts, nil
+}
+
+func readLatestContents(conn *sql.DB) (map[int64]string, error) {
+	rows, err := conn.Query(`
+		SELECT pc.post_id, pc.body_markdown FROM post_contents pc
+		WHERE pc.version = (SELECT MAX(version) FROM post_contents WHERE post_id = pc.post_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy post contents: %w", err)
+	}
+	defer rows.Close()
+
+	bodies := make(map[int64]string)
+	for rows.Next() {
+		var postID int64
+		var body string
+		if err := rows.Scan(&postID, &body); err != nil {
+			return nil, fmt.Errorf("failed to decode legacy post content: %w", err)
+		}
+		bodies[postID] = body
+	}
+	return bodies, rows.Err()
+}
+
+func readComments(conn *sql.DB, users []legacyUser) ([]models.Comment, error) {
+	columns, err := tableColumns(conn, "post_comments")
+	if err != nil || columns == nil {
+		return nil, err
+	}
+
+	usernames := make(map[int64]string, len(users))
+	for _, u := range users {
+		usernames[u.user.ID] = u.user.Username
+	}
+
+	rows, err := conn.Query(`SELECT id, post_id, user_id, ` +
+		pick(columns, "''", "author_name") + `, content, ` +
+		pick(columns, "NULL", "created_at") + `, ` +
+		pick(columns, "NULL", "updated_at", "created_at") +
+		` FROM post_comments ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var c models.Comment
+		var id int64
+		var authorName sql.NullString
+		var createdAt, updatedAt interface{}
+		if err := rows.Scan(&id, &c.PostID, &c.UserID, &authorName, &c.Content, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to decode legacy comment: %w", err)
+		}
+
+		c.ID = strconv.FormatInt(id, 10)
+		c.AuthorName = authorName.String
+		if c.AuthorName == "" {
+			c.AuthorName = usernames[c.UserID]
+		}
+		if c.AuthorName == "" {
+			c.AuthorName = "Deleted user"
+			c.UserID = 0
+		}
+		c.CreatedAt = parseTime(createdAt)
+		c.UpdatedAt = parseTime(updatedAt)
+		if c.UpdatedAt.IsZero() {
+			c.UpdatedAt = c.CreatedAt
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func readReactions(conn *sql.DB) ([]legacyReaction, error) {
+	columns, err := tableColumns(conn, "post_reactions")
+	if err != nil || columns == nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(`SELECT user_id, post_id, reaction FROM post_reactions ORDER BY post_id, user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy reactions: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []legacyReaction
+	for rows.Next() {
+		var r legacyReaction
+		if err := rows.Scan(&r.userID, &r.postID, &r.reaction); err != nil {
+			return nil, fmt.Errorf("failed to decode legacy reaction: %w", err)
+		}
+		reactions = append(reactions, r)
+	}
+	return reactions, rows.Err()
+}
+
+// legacyTimeLayouts are the formats the SQLite driver has used when writing
+// time.Time values into DATETIME columns.
+var legacyTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseTime converts a scanned timestamp (already a time.Time for columns
+// declared DATETIME, otherwise text) into a time.Time. Unparseable values
+// become the zero time.
+func parseTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case []byte:
+		return parseTime(string(t))
+	case string:
+		for _, layout := range legacyTimeLayouts {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,242 @@
+package legacy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"example.com/blog_backend/models"
+	"example.com/blog_backend/utils"
+)
+
+// createLegacyDB writes a small database using the schema of the
+// pre-Firestore server, including the versioned post_contents table and
+// deliberately wrong stored counters.
+func createLegacyDB(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "api.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to create legacy database: %v", err)
+	}
+	defer conn.Close()
+
+	hash, err := utils.HashPassword("legacy-password")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	statements := []string{
+		`CREATE TABLE users (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"username" TEXT NOT NULL UNIQUE,
+			"password" TEXT NOT NULL,
+			"role" TEXT NOT NULL DEFAULT 'user'
+		)`,
+		`CREATE TABLE posts (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"title" TEXT NOT NULL,
+			"description" TEXT,
+			"category" TEXT,
+			"status" TEXT NOT NULL DEFAULT 'published',
+			"created_at" DATETIME NOT NULL,
+			"updated_at" DATETIME NOT NULL,
+			"author_id" INTEGER,
+			"likes_count" INTEGER NOT NULL DEFAULT 0,
+			"dislikes_count" INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE post_contents (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"post_id" INTEGER NOT NULL,
+			"version" INTEGER NOT NULL,
+			"body_markdown" TEXT NOT NULL,
+			"created_at" DATETIME NOT NULL
+		)`,
+		`CREATE TABLE post_reactions (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"user_id" INTEGER NOT NULL,
+			"post_id" INTEGER NOT NULL,
+			"reaction" TEXT NOT NULL,
+			UNIQUE(user_id, post_id)
+		)`,
+		`CREATE TABLE post_comments (
+			"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+			"post_id" INTEGER NOT NULL,
+			"user_id" INTEGER NOT NULL,
+			"content" TEXT NOT NULL,
+			"created_at" DATETIME NOT NULL
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("failed to create legacy schema: %v", err)
+		}
+	}
+
+	inserts := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`INSERT INTO users (id, username, password, role) VALUES (3, 'alice', ?, 'admin')`, []interface{}{hash}},
+		{`INSERT INTO users (id, username, password, role) VALUES (7, 'bob', ?, 'editor')`, []interface{}{hash}},
+		{`INSERT INTO posts (id, title, description, category, status, created_at, updated_at, author_id, likes_count, dislikes_count)
+			VALUES (4, 'Hello', 'First post', 'go', 'published', '2025-12-21 22:47:35.349614+05:30', '2025-12-22 10:00:00+05:30', 3, 99, 99)`, nil},
+		{`INSERT INTO posts (id, title, status, created_at, updated_at, author_id)
+			VALUES (9, 'Draft', 'draft', '2025-12-23 09:00:00+05:30', '2025-12-23 09:00:00+05:30', 7)`, nil},
+		{`INSERT INTO post_contents (post_id, version, body_markdown, created_at) VALUES (4, 1, 'old body', '2025-12-21 22:47:35+05:30')`, nil},
+		{`INSERT INTO post_contents (post_id, version, body_markdown, created_at) VALUES (4, 2, 'new body', '2025-12-22 10:00:00+05:30')`, nil},
+		{`INSERT INTO post_contents (post_id, version, body_markdown, created_at) VALUES (9, 1, 'draft body', '2025-12-23 09:00:00+05:30')`, nil},
+		{`INSERT INTO post_reactions (user_id, post_id, reaction) VALUES (3, 4, 'like')`, nil},
+		{`INSERT INTO post_reactions (user_id, post_id, reaction) VALUES (7, 4, 'dislike')`, nil},
+		{`INSERT INTO post_reactions (user_id, post_id, reaction) VALUES (7, 42, 'like')`, nil},
+		{`INSERT INTO post_comments (id, post_id, user_id, content, created_at) VALUES (5, 4, 7, 'Nice!', '2025-12-22 11:00:00+05:30')`, nil},
+		{`INSERT INTO post_comments (id, post_id, user_id, content, created_at) VALUES (6, 42, 7, 'Orphan', '2025-12-22 11:00:00+05:30')`, nil},
+	}
+	for _, ins := range inserts {
+		if _, err := conn.Exec(ins.query, ins.args...); err != nil {
+			t.Fatalf("failed to seed legacy database: %v", err)
+		}
+	}
+
+	return path
+}
+
+func TestImportSQLiteDryRunWritesNothing(t *testing.T) {
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+	path := createLegacyDB(t)
+
+	report, err := ImportSQLite(ctx, path, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if report.Users != 2 || report.Posts != 2 || report.Comments != 1 || report.Reactions != 2 {
+		t.Fatalf("unexpected dry-run report: %+v", report)
+	}
+	if report.OrphanedComments != 1 || report.OrphanedReactions != 1 {
+		t.Fatalf("expected one orphaned comment and reaction, got %+v", report)
+	}
+
+	users, err := models.GetAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("failed to list users: %v", err)
+	}
+	posts, err := models.GetAllPosts(ctx)
+	if err != nil {
+		t.Fatalf("failed to list posts: %v", err)
+	}
+	if len(users) != 0 || len(posts) != 0 {
+		t.Fatalf("dry run wrote %d users and %d posts", len(users), len(posts))
+	}
+}
+
+func TestImportSQLiteKeepsIDsAndRecomputesCounters(t *testing.T) {
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+	path := createLegacyDB(t)
+
+	for run := 1; run <= 2; run++ {
+		report, err := ImportSQLite(ctx, path, Options{})
+		if err != nil {
+			t.Fatalf("import run %d failed: %v", run, err)
+		}
+		if report.Users != 2 || report.Posts != 2 || report.Comments != 1 || report.Reactions != 2 || len(report.ConflictingUsers) != 0 {
+			t.Fatalf("unexpected report on run %d: %+v", run, report)
+		}
+	}
+
+	// Bcrypt hashes and roles survive, so legacy credentials still work.
+	alice := &models.User{Username: "alice", Password: "legacy-password"}
+	if err := alice.ValidateCredentials(ctx); err != nil {
+		t.Fatalf("expected legacy credentials to validate: %v", err)
+	}
+	if alice.ID != 3 || alice.Role != "admin" {
+		t.Fatalf("unexpected imported user: %+v", alice)
+	}
+
+	post, err := models.GetPostByID(ctx, 4)
+	if err != nil {
+		t.Fatalf("failed to load imported post: %v", err)
+	}
+	if post.Content != "new body" || post.AuthorID != 3 || post.Category != "go" {
+		t.Fatalf("unexpected imported post: %+v", post)
+	}
+	if post.LikesCount != 1 || post.DislikesCount != 1 || post.CommentsCount != 1 {
+		t.Fatalf("expected counters 1/1/1 after two runs, got %d/%d/%d", post.LikesCount, post.DislikesCount, post.CommentsCount)
+	}
+
+	comments, err := models.GetCommentsForPost(ctx, 4)
+	if err != nil {
+		t.Fatalf("failed to load imported comments: %v", err)
+	}
+	if len(comments) != 1 || comments[0].ID != "5" || comments[0].AuthorName != "bob" {
+		t.Fatalf("unexpected imported comments: %+v", comments)
+	}
+
+	// Reactions were imported too, so bob clicking dislike again toggles off.
+	result, err := models.SetPostReaction(ctx, 7, 4, models.ReactionDislike)
+	if err != nil {
+		t.Fatalf("failed to toggle imported reaction: %v", err)
+	}
+	if result.UserReaction != "" || result.DislikesCount != 0 {
+		t.Fatalf("expected imported dislike to toggle off, got %+v", result)
+	}
+
+	// New records are numbered after the imported ones.
+	fresh := &models.Post{Title: "After import", Content: "Body"}
+	if err := fresh.Save(ctx); err != nil {
+		t.Fatalf("failed to save post after import: %v", err)
+	}
+	if fresh.ID != 10 {
+		t.Fatalf("expected new post ID 10, got %d", fresh.ID)
+	}
+}
+
+func TestImportSQLiteSkipsConflictingUsers(t *testing.T) {
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+	path := createLegacyDB(t)
+
+	// "alice" already exists in the active store under a different ID.
+	existing := &models.User{Username: "alice", Password: "other-password"}
+	if err := existing.Save(ctx); err != nil {
+		t.Fatalf("failed to create existing user: %v", err)
+	}
+
+	report, err := ImportSQLite(ctx, path, Options{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if report.Users != 1 || len(report.ConflictingUsers) != 1 || report.ConflictingUsers[0] != "alice" {
+		t.Fatalf("expected alice to be reported as a conflict, got %+v", report)
+	}
+
+	if _, err := models.GetUserByID(ctx, 3); !errors.Is(err, models.ErrUserNotFound) {
+		t.Fatalf("expected conflicting legacy user to be skipped, got %v", err)
+	}
+}
+
+// The api.db checked into the repository imports cleanly.
+func TestImportSQLiteShippedDatabase(t *testing.T) {
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	report, err := ImportSQLite(ctx, filepath.Join("..", "models", "api.db"), Options{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if report.Users != 6 || report.Posts != 6 || report.Reactions != 6 {
+		t.Fatalf("unexpected report for shipped database: %+v", report)
+	}
+
+	post, err := models.GetPostByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to load imported post: %v", err)
+	}
+	if post.Content == "" || post.LikesCount != 1 {
+		t.Fatalf("unexpected imported post: %+v", post)
+	}
+}
@@ -2,22 +2,67 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
+	"cloud.google.com/go/storage"
+	"example.com/blog_backend/blob"
 	"example.com/blog_backend/db"
+	"example.com/blog_backend/media"
 	"example.com/blog_backend/middlewares"
+	"example.com/blog_backend/models"
 	"example.com/blog_backend/routes"
+	"example.com/blog_backend/scheduler"
+	"example.com/blog_backend/search"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// Initialize datastore (currently a no-op; posts and reactions are Firestore-backed).
-	db.InitDB()
-
-	// Initialize Firestore for user authentication and user management.
+	// Initialize the storage backend selected by STORAGE_BACKEND.
 	ctx := context.Background()
-	if err := db.InitFirestore(ctx); err != nil {
-		log.Fatalf("failed to initialize Firestore: %v", err)
+	if err := initStore(ctx); err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+	if err := initTimeouts(); err != nil {
+		log.Fatalf("invalid storage timeout: %v", err)
+	}
+
+	// With RUN_MIGRATIONS=true the server applies pending Firestore data
+	// migrations before it starts serving.
+	if os.Getenv("RUN_MIGRATIONS") == "true" {
+		runner, err := newMigrationRunner()
+		if err != nil {
+			log.Fatalf("failed to run migrations: %v", err)
+		}
+		if _, err := runner.Run(ctx); err != nil {
+			log.Fatalf("failed to run migrations: %v", err)
+		}
+	}
+
+	// Maintenance subcommands (for example "import-legacy") run against the
+	// same store and exit instead of starting the HTTP server.
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	if err := initSearch(ctx); err != nil {
+		log.Fatalf("failed to build search index: %v", err)
+	}
+	if err := initPublisher(ctx); err != nil {
+		log.Fatalf("failed to start publisher: %v", err)
+	}
+	if err := initSite(); err != nil {
+		log.Fatalf("invalid site configuration: %v", err)
+	}
+	if err := initMedia(ctx); err != nil {
+		log.Fatalf("failed to initialize media storage: %v", err)
 	}
 
 	server := gin.Default() // create a new gin server instance with default middleware (logger and recovery)
@@ -25,3 +70,225 @@ func main() {
 	routes.RegisterRoutes(server)  // register routes from routes package
 	server.Run(":8080")          // listen and serve on 0.0.0.0:8080
 }
+
+// initStore picks the storage backend from the STORAGE_BACKEND environment
+// variable:
+//
+//   - "firestore" (default) uses Cloud Firestore in GOOGLE_CLOUD_PROJECT.
+//   - "sqlite" uses a local SQLite file at SQLITE_PATH (default "blog.db"),
+//     which suits small self-hosted installs without a GCP project.
+//   - "memory" keeps everything in process memory and is lost on restart.
+func initStore(ctx context.Context) error {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = "firestore"
+	}
+
+	switch backend {
+	case "firestore":
+		if err := db.InitFirestore(ctx); err != nil {
+			return err
+		}
+		models.SetStore(models.NewFirestoreStore(db.FirestoreClient))
+
+	case "sqlite":
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "blog.db"
+		}
+		if err := db.InitDB(path); err != nil {
+			return err
+		}
+		models.SetStore(models.NewSQLiteStore(db.DB))
+
+		// Posts created before slugs existed get one on the first start.
+		// Firestore databases are upgraded by migration 6 instead.
+		n, err := models.AssignMissingSlugs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to assign post slugs: %w", err)
+		}
+		if n > 0 {
+			log.Printf("assigned slugs to %d posts", n)
+		}
+
+		// Categories became managed records; register the ones existing
+		// posts use (migration 7 does this for Firestore).
+		n, err = models.CreateMissingCategories(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create post categories: %w", err)
+		}
+		if n > 0 {
+			log.Printf("created %d categories used by existing posts", n)
+		}
+
+	case "memory":
+		models.SetStore(models.NewMemoryStore())
+
+	default:
+		return fmt.Errorf("unknown STORAGE_BACKEND %q (use firestore, sqlite or memory)", backend)
+	}
+
+	log.Printf("using %s storage backend", backend)
+	return nil
+}
+
+// initTimeouts reads the per-operation storage timeouts from
+// STORE_READ_TIMEOUT and STORE_WRITE_TIMEOUT (Go durations such as "5s";
+// "0" disables the timeout). Unset variables keep models.DefaultTimeouts.
+func initTimeouts() error {
+	timeouts := models.DefaultTimeouts
+	for name, target := range map[string]*time.Duration{
+		"STORE_READ_TIMEOUT":  &timeouts.Read,
+		"STORE_WRITE_TIMEOUT": &timeouts.Write,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*target = d
+	}
+
+	models.SetTimeouts(timeouts)
+	return nil
+}
+
+// initSearch builds the full-text search index from the store. The index
+// follows writes made by this process; with SEARCH_REBUILD_INTERVAL (a Go
+// duration such as "10m") it is also rebuilt periodically to pick up writes
+// from other instances sharing the same database.
+func initSearch(ctx context.Context) error {
+	index, err := search.Init(ctx)
+	if err != nil {
+		return err
+	}
+
+	value := os.Getenv("SEARCH_REBUILD_INTERVAL")
+	if value == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid SEARCH_REBUILD_INTERVAL %q", value)
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			if err := index.Rebuild(ctx); err != nil {
+				log.Printf("failed to rebuild search index: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// initPublisher starts the background publisher for scheduled posts. It runs
+// every PUBLISH_INTERVAL (a Go duration, default "1m"); "0" disables it, for
+// example on all but one of several instances sharing a database.
+func initPublisher(ctx context.Context) error {
+	interval := scheduler.DefaultInterval
+	if value := os.Getenv("PUBLISH_INTERVAL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid PUBLISH_INTERVAL %q", value)
+		}
+		if d == 0 {
+			return nil
+		}
+		interval = d
+	}
+
+	go scheduler.NewPublisher(scheduler.SystemClock{}, interval).Run(ctx)
+	return nil
+}
+
+// initSite reads the public address of the blog frontend from SITE_URL (for
+// example "https://blog.example.com") and its name from SITE_TITLE. Feeds
+// and the sitemap link posts to SITE_URL; without it they link to the API
+// host. API_URL is the public address of the API itself, for feed self
+// links, the sitemap index and robots.txt; without it they use the Host
+// header of the request. ROBOTS_TXT
+// names a file served as robots.txt instead of the generated one. With
+// PUBLIC_READ=true visitors can read published posts without an account.
+func initSite() error {
+	s := routes.Site{Title: os.Getenv("SITE_TITLE"), URL: os.Getenv("SITE_URL"), APIURL: os.Getenv("API_URL")}
+	for _, v := range []struct{ name, value string }{{"SITE_URL", s.URL}, {"API_URL", s.APIURL}} {
+		if v.value == "" {
+			continue
+		}
+		u, err := url.Parse(v.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s %q", v.name, v.value)
+		}
+	}
+	if path := os.Getenv("ROBOTS_TXT"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("ROBOTS_TXT: %w", err)
+		}
+		s.Robots = string(data)
+	}
+
+	routes.SetSite(s)
+	routes.SetPublicRead(os.Getenv("PUBLIC_READ") == "true")
+	return nil
+}
+
+// initMedia picks where uploaded images are kept from MEDIA_BACKEND:
+//
+//   - "local" (default) writes files below MEDIA_DIR (default "media").
+//   - "gcs" uses the Cloud Storage bucket MEDIA_BUCKET, with object names
+//     starting with the optional MEDIA_PREFIX.
+//   - "memory" keeps uploads in process memory and loses them on restart.
+//
+// MEDIA_MAX_BYTES caps the size of one upload (default 10 MiB).
+func initMedia(ctx context.Context) error {
+	if value := os.Getenv("MEDIA_MAX_BYTES"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid MEDIA_MAX_BYTES %q", value)
+		}
+		media.SetMaxSize(n)
+	}
+
+	backend := os.Getenv("MEDIA_BACKEND")
+	if backend == "" {
+		backend = "local"
+	}
+
+	switch backend {
+	case "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "media"
+		}
+		store, err := blob.NewFileStore(dir)
+		if err != nil {
+			return err
+		}
+		media.SetStorage(store)
+
+	case "gcs":
+		bucket := os.Getenv("MEDIA_BUCKET")
+		if bucket == "" {
+			return fmt.Errorf("MEDIA_BUCKET is required with MEDIA_BACKEND=gcs")
+		}
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			return err
+		}
+		media.SetStorage(blob.NewGCSStore(client, bucket, os.Getenv("MEDIA_PREFIX")))
+
+	case "memory":
+		media.SetStorage(blob.NewMemoryStore())
+
+	default:
+		return fmt.Errorf("unknown MEDIA_BACKEND %q (use local, gcs or memory)", backend)
+	}
+
+	log.Printf("using %s media backend", backend)
+	return nil
+}
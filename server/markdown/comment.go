@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// CommentVersion identifies the output of RenderComment. Raise it whenever
+// that output changes, so comments rendered by an older version are
+// rendered again when they are next read.
+const CommentVersion = 1
+
+var (
+	// commentConverter only knows paragraphs, emphasis, links, autolinks and
+	// inline code. HTML in a comment is not parsed at all and ends up as
+	// escaped text, and block syntax such as headings or lists stays
+	// literal.
+	commentConverter = goldmark.New(
+		goldmark.WithParser(parser.NewParser(
+			parser.WithBlockParsers(
+				util.Prioritized(parser.NewParagraphParser(), 1000),
+			),
+			parser.WithInlineParsers(
+				util.Prioritized(parser.NewCodeSpanParser(), 100),
+				util.Prioritized(parser.NewLinkParser(), 200),
+				util.Prioritized(parser.NewAutoLinkParser(), 300),
+				util.Prioritized(parser.NewEmphasisParser(), 500),
+				util.Prioritized(extension.NewLinkifyParser(), 999),
+			),
+		)),
+		goldmark.WithRendererOptions(html.WithHardWraps()),
+	)
+
+	commentPolicy = newCommentPolicy()
+)
+
+// newCommentPolicy allows exactly the elements commentConverter produces.
+// Links must be http, https, mailto or relative and get rel="nofollow";
+// images are dropped.
+func newCommentPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "em", "strong", "code")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowStandardURLs()
+	p.RequireNoFollowOnLinks(true)
+	return p
+}
+
+// RenderComment converts a comment written in the comment subset of
+// Markdown to sanitized HTML. Leading indentation is dropped, since without
+// code blocks an indented line would not be shown at all.
+func RenderComment(source string) string {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimLeft(line, " \t")
+	}
+
+	// Writes to a bytes.Buffer cannot fail, so neither can conversion.
+	var buf bytes.Buffer
+	commentConverter.Convert([]byte(strings.Join(lines, "\n")), &buf)
+	return commentPolicy.Sanitize(buf.String())
+}
@@ -0,0 +1,36 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderCommentAllowsSafeSubset(t *testing.T) {
+	got := RenderComment("Nice *post*, see [the docs](https://example.com/docs) and `go vet`.\nThanks!")
+	want := `<p>Nice <em>post</em>, see <a href="https://example.com/docs" rel="nofollow">the docs</a> and <code>go vet</code>.<br>` +
+		"\nThanks!</p>\n"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if got := RenderComment("Visit https://example.com now"); !strings.Contains(got, `<a href="https://example.com" rel="nofollow">`) {
+		t.Errorf("expected a nofollow autolink, got %q", got)
+	}
+}
+
+func TestRenderCommentEscapesEverythingElse(t *testing.T) {
+	got := RenderComment("<script>alert(1)</script> <b onclick=\"x()\">hi</b>\n\n" +
+		"[click](javascript:alert(1)) ![img](https://example.com/a.png)\n\n" +
+		"# Heading\n\n- item\n\n    indented")
+
+	for _, unwanted := range []string{"<script", "<b", "<img", "<h1", "<li", "<pre", "javascript:"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("expected %q to be removed from %q", unwanted, got)
+		}
+	}
+	for _, want := range []string{"&lt;script&gt;", "# Heading", "- item", "<p>indented</p>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package markdown renders post content, written in CommonMark with GitHub
+// tables, strikethrough and autolinks, to HTML that is safe to insert into a
+// page. Raw HTML in the source is passed through the renderer and then
+// sanitized, so harmless markup such as <sup> survives while scripts, event
+// handlers and javascript: links are removed.
+//
+// Headings get stable anchors ("getting-started", "getting-started-1", ...)
+// that Render also returns as a table of contents.
+package markdown
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"golang.org/x/text/unicode/norm"
+)
+
+// WordsPerMinute is the reading speed ReadingTime assumes.
+const WordsPerMinute = 200
+
+// Heading is one entry of a document's table of contents. ID is the anchor
+// of the rendered heading.
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// Document is the rendered form of a Markdown source.
+type Document struct {
+	// HTML is the sanitized rendering.
+	HTML string
+	// TOC lists the headings in document order. It is never nil.
+	TOC []Heading
+	// WordCount counts the words of the text, including code. Punctuation
+	// on its own is not a word.
+	WordCount int
+}
+
+var (
+	converter = goldmark.New(
+		goldmark.WithExtensions(
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+			extension.Strikethrough,
+			extension.Linkify,
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+
+	policy = newPolicy()
+)
+
+// newPolicy allows the user-generated-content subset of HTML plus the
+// attributes the renderer emits: heading anchors and the language class of
+// fenced code blocks.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	return p
+}
+
+// Render converts source to sanitized HTML and collects its headings and
+// word count.
+func Render(source string) Document {
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(&headingIDs{seen: make(map[string]bool)}))
+	doc := converter.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	result := Document{TOC: []Heading{}}
+	var words strings.Builder
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			id, _ := node.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			result.TOC = append(result.TOC, Heading{
+				Level: node.Level,
+				Text:  plainText(node, src),
+				ID:    string(idBytes),
+			})
+		case *ast.Text:
+			words.Write(node.Segment.Value(src))
+			words.WriteByte(' ')
+		case *ast.String:
+			words.Write(node.Value)
+			words.WriteByte(' ')
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				words.Write(segment.Value(src))
+			}
+			words.WriteByte(' ')
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, word := range strings.Fields(words.String()) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			result.WordCount++
+		}
+	}
+
+	// Writes to a bytes.Buffer cannot fail, so neither can rendering.
+	var buf bytes.Buffer
+	converter.Renderer().Render(&buf, src, doc)
+	result.HTML = policy.Sanitize(buf.String())
+	return result
+}
+
+// ReadingTime returns the minutes needed to read words words at
+// WordsPerMinute, rounded up, and 0 for an empty text.
+func ReadingTime(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(words) / WordsPerMinute))
+}
+
+// headingIDs generates heading anchors the way post slugs are built:
+// lower-case letters and digits joined by hyphens, accents removed and other
+// scripts kept. Repeated anchors are numbered.
+type headingIDs struct {
+	seen map[string]bool
+}
+
+// Generate returns a unique anchor for a heading with the given text.
+func (ids *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var sb strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(string(value)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			hyphen = false
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+	}
+	base := norm.NFC.String(sb.String())
+	if base == "" {
+		base = "section"
+	}
+
+	id := base
+	for i := 1; ids.seen[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	ids.seen[id] = true
+	return []byte(id)
+}
+
+// Put reserves an anchor set explicitly in the source.
+func (ids *headingIDs) Put(value []byte) {
+	ids.seen[string(value)] = true
+}
+
+// plainText returns the text of n's inline children without markup.
+func plainText(n ast.Node, src []byte) string {
+	var sb strings.Builder
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := c.(type) {
+		case *ast.Text:
+			sb.Write(node.Segment.Value(src))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(node.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,64 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderSanitizesHTML(t *testing.T) {
+	doc := Render("Hello <sup>2</sup> <script>alert(1)</script>\n\n" +
+		"[click](javascript:alert(1)) <img src=\"a.png\" onerror=\"alert(1)\">\n\n" +
+		"| a | b |\n|:--|--:|\n| 1 | 2 |\n\n" +
+		"```go\nfmt.Println(\"hi\")\n```\n")
+
+	for _, want := range []string{
+		"<sup>2</sup>",
+		`<img src="a.png">`,
+		`<th align="left">a</th>`,
+		`<code class="language-go">`,
+	} {
+		if !strings.Contains(doc.HTML, want) {
+			t.Errorf("expected %q in %s", want, doc.HTML)
+		}
+	}
+	for _, unwanted := range []string{"<script", "alert(1)</script>", "javascript:", "onerror"} {
+		if strings.Contains(doc.HTML, unwanted) {
+			t.Errorf("expected %q to be removed from %s", unwanted, doc.HTML)
+		}
+	}
+}
+
+func TestRenderBuildsTableOfContents(t *testing.T) {
+	doc := Render("# Getting *started*\n\nIntro text.\n\n## Crème brûlée\n\n## Getting started\n\n### !!!\n")
+
+	want := []Heading{
+		{Level: 1, Text: "Getting started", ID: "getting-started"},
+		{Level: 2, Text: "Crème brûlée", ID: "creme-brulee"},
+		{Level: 2, Text: "Getting started", ID: "getting-started-1"},
+		{Level: 3, Text: "!!!", ID: "section"},
+	}
+	if !reflect.DeepEqual(doc.TOC, want) {
+		t.Errorf("expected TOC %+v, got %+v", want, doc.TOC)
+	}
+	if !strings.Contains(doc.HTML, `<h2 id="creme-brulee">`) {
+		t.Errorf("expected heading anchors in %s", doc.HTML)
+	}
+
+	if empty := Render("Just a paragraph."); empty.TOC == nil || len(empty.TOC) != 0 {
+		t.Errorf("expected an empty, non-nil TOC, got %#v", empty.TOC)
+	}
+}
+
+func TestWordCountAndReadingTime(t *testing.T) {
+	doc := Render("# Title here\n\nOne *two* three `four`.\n\n```\nfive six\n```\n")
+	if doc.WordCount != 8 {
+		t.Errorf("expected 8 words, got %d", doc.WordCount)
+	}
+
+	for words, want := range map[int]int{0: 0, 1: 1, 200: 1, 201: 2, 1000: 5} {
+		if got := ReadingTime(words); got != want {
+			t.Errorf("ReadingTime(%d): expected %d, got %d", words, want, got)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Package media accepts image uploads and keeps them in a blob.Store. The
+// type of an upload is sniffed from its bytes, whatever the client claims,
+// and only raster images are accepted. Uploads are re-encoded without their
+// metadata and resized into several widths (see VariantWidths); each gets a
+// new random key, which posts use as cover_image_key and which
+// GET /media/:key serves.
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"example.com/blog_backend/blob"
+)
+
+// DefaultMaxSize is the default upload limit in bytes.
+const DefaultMaxSize = 10 << 20
+
+var (
+	// ErrTooLarge is returned for uploads over the size limit, or whose
+	// decoded image, with all of its frames, would be unreasonably large.
+	ErrTooLarge = errors.New("upload is too large")
+
+	// ErrUnsupportedType is returned for uploads that are not JPEG, PNG, GIF
+	// or WebP images.
+	ErrUnsupportedType = errors.New("unsupported media type")
+
+	// ErrNotFound is returned when no upload exists under a key.
+	ErrNotFound = errors.New("media not found")
+)
+
+// acceptedTypes are the sniffed content types uploads may have.
+var acceptedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var (
+	// keyPattern matches the keys of uploads. WebP keys date from before
+	// uploads were re-encoded.
+	keyPattern = regexp.MustCompile(`^[0-9a-f]{32}\.(jpg|png|gif|webp)$`)
+	// objectPattern matches the keys GET /media/:key serves: uploads and
+	// their variants, such as "<id>-640w.jpg".
+	objectPattern = regexp.MustCompile(`^[0-9a-f]{32}(-[0-9]+w)?\.(jpg|png|gif|webp)$`)
+	// linkPattern finds links to uploads and their variants in text.
+	linkPattern = regexp.MustCompile(`/media/([0-9a-f]{32})(?:-[0-9]+w)?\.(jpg|png|gif|webp)\b`)
+)
+
+var (
+	storage blob.Store = blob.NewMemoryStore()
+	maxSize int64      = DefaultMaxSize
+)
+
+// SetStorage selects where uploads are kept.
+func SetStorage(s blob.Store) {
+	storage = s
+	cacheMu.Lock()
+	cache = make(map[string]*Image)
+	cacheMu.Unlock()
+}
+
+// SetMaxSize sets the upload limit in bytes.
+func SetMaxSize(n int64) {
+	maxSize = n
+}
+
+// MaxSize returns the upload limit in bytes.
+func MaxSize() int64 {
+	return maxSize
+}
+
+// Image describes a stored upload: the full-size image, its resized
+// variants, narrowest first, and a tiny placeholder to show while it loads.
+// URLs are paths on the API.
+type Image struct {
+	Key         string    `json:"key"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Variants    []Variant `json:"variants"`
+	Placeholder string    `json:"placeholder"`
+}
+
+// Variant is a resized copy of an Image.
+type Variant struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ValidKey reports whether key has the form of an upload key.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// URL returns the path GET /media/:key serves key at.
+func URL(key string) string {
+	return "/media/" + key
+}
+
+// manifestKey names the object holding the Image of the upload with the
+// given key.
+func manifestKey(key string) string {
+	return key[:len(key)-len(path.Ext(key))] + ".json"
+}
+
+// Save processes the image read from r and stores it, its variants and its
+// description under a new key.
+func Save(ctx context.Context, r io.Reader) (*Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !acceptedTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+	p, err := process(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate media key: %w", err)
+	}
+	base := hex.EncodeToString(id[:])
+
+	img := &Image{
+		Key:         base + p.full.ext,
+		ContentType: p.full.contentType,
+		Size:        int64(len(p.full.data)),
+		Width:       p.full.width,
+		Height:      p.full.height,
+		Variants:    []Variant{},
+		Placeholder: p.placeholder,
+	}
+	img.URL = URL(img.Key)
+
+	widths := make([]int, 0, len(p.variants))
+	for width := range p.variants {
+		widths = append(widths, width)
+	}
+	sort.Ints(widths)
+
+	// The description is written last, so an upload only shows up once all
+	// its objects exist.
+	var written []string
+	put := func(key, contentType string, data []byte) error {
+		if err := storage.Put(ctx, key, contentType, bytes.NewReader(data)); err != nil {
+			for _, k := range written {
+				_ = storage.Delete(ctx, k)
+			}
+			return err
+		}
+		written = append(written, key)
+		return nil
+	}
+	for _, width := range widths {
+		v := p.variants[width]
+		key := base + "-" + strconv.Itoa(width) + "w" + v.ext
+		if err := put(key, v.contentType, v.data); err != nil {
+			return nil, err
+		}
+		img.Variants = append(img.Variants, Variant{URL: URL(key), Width: v.width, Height: v.height})
+	}
+	if err := put(img.Key, img.ContentType, p.full.data); err != nil {
+		return nil, err
+	}
+	manifest, err := json.Marshal(img)
+	if err != nil {
+		return nil, err
+	}
+	if err := put(manifestKey(img.Key), "application/json", manifest); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Open returns the upload or variant stored under key. The caller closes its
+// Body.
+func Open(ctx context.Context, key string) (*blob.Object, error) {
+	if !objectPattern.MatchString(key) {
+		return nil, ErrNotFound
+	}
+	obj, err := storage.Get(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return obj, err
+}
+
+// Delete removes the upload stored under key together with its variants and
+// description. The description goes last, so a failed delete can be retried.
+func Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrNotFound
+	}
+	var keys []string
+	img, err := Describe(ctx, key)
+	switch {
+	case err == nil:
+		for _, v := range img.Variants {
+			keys = append(keys, strings.TrimPrefix(v.URL, "/media/"))
+		}
+	case !errors.Is(err, ErrNotFound):
+		return err
+	}
+	keys = append(keys, key, manifestKey(key))
+
+	for _, k := range keys {
+		if err := storage.Delete(ctx, k); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			return err
+		}
+	}
+	cacheMu.Lock()
+	delete(cache, key)
+	cacheMu.Unlock()
+	return nil
+}
+
+// KeysIn returns the keys of the uploads that text links to, directly or
+// through one of their variants, in order of first appearance.
+func KeysIn(text string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, m := range linkPattern.FindAllStringSubmatch(text, -1) {
+		key := m[1] + "." + m[2]
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// cacheSize bounds the number of descriptions Describe keeps in memory.
+const cacheSize = 1024
+
+var (
+	cacheMu sync.Mutex
+	// cache maps upload keys to their descriptions, or to nil for keys
+	// without one. Uploads never change, so entries stay valid.
+	cache = make(map[string]*Image)
+)
+
+// Describe returns the description of the upload stored under key. Uploads
+// made before images were processed have none and yield ErrNotFound, as do
+// keys that are not upload keys, such as the built-in cover names.
+func Describe(ctx context.Context, key string) (*Image, error) {
+	if !ValidKey(key) {
+		return nil, ErrNotFound
+	}
+	cacheMu.Lock()
+	img, ok := cache[key]
+	cacheMu.Unlock()
+	if ok {
+		if img == nil {
+			return nil, ErrNotFound
+		}
+		return img, nil
+	}
+
+	obj, err := storage.Get(ctx, manifestKey(key))
+	if err == nil {
+		defer obj.Body.Close()
+		img = new(Image)
+		if err := json.NewDecoder(obj.Body).Decode(img); err != nil {
+			return nil, fmt.Errorf("failed to read media description: %w", err)
+		}
+	} else if !errors.Is(err, blob.ErrNotFound) {
+		return nil, err
+	}
+
+	cacheMu.Lock()
+	if len(cache) >= cacheSize {
+		cache = make(map[string]*Image)
+	}
+	cache[key] = img
+	cacheMu.Unlock()
+
+	if img == nil {
+		return nil, ErrNotFound
+	}
+	return img, nil
+}
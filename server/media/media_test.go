@@ -0,0 +1,276 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"example.com/blog_backend/blob"
+)
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// animatedGIF encodes frames 1x1 paletted frames on a width x height canvas.
+func animatedGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{Config: image.Config{ColorModel: palette, Width: width, Height: height}}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 1, 1), palette)
+		frame.SetColorIndex(0, 0, uint8(i%2))
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10*(i+1))
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("failed to encode GIF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func readObject(t *testing.T, key string) []byte {
+	t.Helper()
+	obj, err := Open(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %v", key, err)
+	}
+	defer obj.Body.Close()
+	data, _ := io.ReadAll(obj.Body)
+	return data
+}
+
+func TestSaveStoresVariantsAndDescription(t *testing.T) {
+	SetStorage(blob.NewMemoryStore())
+	ctx := context.Background()
+
+	img, err := Save(ctx, bytes.NewReader(pngBytes(t, 700, 350)))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !ValidKey(img.Key) || !strings.HasSuffix(img.Key, ".png") || img.URL != "/media/"+img.Key {
+		t.Errorf("unexpected key %q and URL %q", img.Key, img.URL)
+	}
+	if img.Width != 700 || img.Height != 350 || img.ContentType != "image/png" {
+		t.Errorf("unexpected image %+v", img)
+	}
+	if len(img.Variants) != 2 || img.Variants[0].Width != 320 || img.Variants[0].Height != 160 || img.Variants[1].Width != 640 {
+		t.Fatalf("expected 320w and 640w variants, got %+v", img.Variants)
+	}
+	if !strings.HasPrefix(img.Placeholder, "data:image/") {
+		t.Errorf("expected a placeholder data URI, got %q", img.Placeholder)
+	}
+
+	variant, err := png.DecodeConfig(bytes.NewReader(readObject(t, strings.TrimPrefix(img.Variants[0].URL, "/media/"))))
+	if err != nil || variant.Width != 320 || variant.Height != 160 {
+		t.Errorf("expected a stored 320x160 variant, got %+v (%v)", variant, err)
+	}
+
+	SetStorage(storage) // drop the cached description
+	described, err := Describe(ctx, img.Key)
+	if err != nil || described.Width != 700 || len(described.Variants) != 2 {
+		t.Errorf("expected the stored description, got %+v (%v)", described, err)
+	}
+	if _, err := Describe(ctx, "cover-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a built-in cover, got %v", err)
+	}
+	if _, err := Open(ctx, "../"+img.Key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an invalid key, got %v", err)
+	}
+}
+
+// withOrientation inserts an APP1 Exif segment with the given orientation
+// right after the start of a JPEG.
+func withOrientation(data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	out = append(out, payload...)
+	return append(out, data[2:]...)
+}
+
+// JPEGs are turned upright according to their EXIF orientation, and the
+// stored copy has no EXIF left.
+func TestSaveAppliesOrientationAndStripsExif(t *testing.T) {
+	SetStorage(blob.NewMemoryStore())
+
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 20 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+
+	img, err := Save(context.Background(), bytes.NewReader(withOrientation(buf.Bytes(), 6)))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if img.Width != 20 || img.Height != 40 || img.ContentType != "image/jpeg" {
+		t.Fatalf("expected an upright 20x40 JPEG, got %+v", img)
+	}
+
+	stored := readObject(t, img.Key)
+	if bytes.Contains(stored, []byte("Exif")) {
+		t.Error("expected the EXIF segment to be stripped")
+	}
+	decoded, err := jpeg.Decode(bytes.NewReader(stored))
+	if err != nil {
+		t.Fatalf("failed to decode the stored JPEG: %v", err)
+	}
+	// Rotating a clockwise quarter turn puts the left (red) half on top.
+	if r, _, b, _ := decoded.At(10, 5).RGBA(); r < b {
+		t.Errorf("expected red at the top, got r=%d b=%d", r, b)
+	}
+	if r, _, b, _ := decoded.At(10, 35).RGBA(); b < r {
+		t.Errorf("expected blue at the bottom, got r=%d b=%d", r, b)
+	}
+}
+
+// Animated GIFs keep their frames and timing, but comments and application
+// extensions other than the loop count are dropped.
+func TestSaveReencodesAnimatedGIFs(t *testing.T) {
+	SetStorage(blob.NewMemoryStore())
+
+	data := animatedGIF(t, 8, 8, 3)
+	comment := append([]byte{0x21, 0xFE, 6}, "secret"...)
+	xmp := append([]byte{0x21, 0xFF, 11}, "XMP DataXMP"...)
+	xmp = append(xmp, 8)
+	xmp = append(xmp, "<x:xmp/>"...)
+	extensions := append(append(comment, 0), append(xmp, 0)...)
+	data = append(data[:len(data)-1:len(data)-1], append(extensions, 0x3B)...)
+
+	img, err := Save(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if img.ContentType != "image/gif" || img.Width != 8 || img.Height != 8 {
+		t.Fatalf("expected an 8x8 GIF, got %+v", img)
+	}
+
+	stored := readObject(t, img.Key)
+	for _, metadata := range []string{"secret", "XMP"} {
+		if bytes.Contains(stored, []byte(metadata)) {
+			t.Errorf("expected %q to be stripped", metadata)
+		}
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(stored))
+	if err != nil {
+		t.Fatalf("failed to decode the stored GIF: %v", err)
+	}
+	if len(anim.Image) != 3 || anim.Delay[2] != 30 {
+		t.Errorf("expected 3 frames with their delays, got %d frames and delays %v", len(anim.Image), anim.Delay)
+	}
+}
+
+func TestSaveRejectsOtherContent(t *testing.T) {
+	SetStorage(blob.NewMemoryStore())
+	ctx := context.Background()
+
+	for name, data := range map[string][]byte{
+		"html": []byte("<html><script>alert(1)</script></html>"),
+		"svg":  []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`),
+		"text": []byte("just text"),
+	} {
+		if _, err := Save(ctx, bytes.NewReader(data)); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%s: expected ErrUnsupportedType, got %v", name, err)
+		}
+	}
+
+	defer SetMaxSize(DefaultMaxSize)
+	data := pngBytes(t, 4, 4)
+	SetMaxSize(int64(len(data)) - 1)
+	if _, err := Save(ctx, bytes.NewReader(data)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	SetMaxSize(DefaultMaxSize)
+
+	// A small file that claims enormous dimensions is refused before it is
+	// decoded.
+	bomb := pngBytes(t, 4, 4)
+	ihdr := bomb[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:], 100000)
+	binary.BigEndian.PutUint32(bomb[8+8+13:], crc32.ChecksumIEEE(bomb[8+4:8+8+13]))
+	if _, err := Save(ctx, bytes.NewReader(bomb)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge for huge dimensions, got %v", err)
+	}
+
+	// So is a GIF whose frames only add up to too many pixels.
+	if _, err := Save(ctx, bytes.NewReader(animatedGIF(t, 2000, 2000, 11))); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge for too many frames, got %v", err)
+	}
+	if _, err := Save(ctx, bytes.NewReader(animatedGIF(t, 2000, 2000, 9))); err != nil {
+		t.Errorf("expected a GIF within the frame budget to be accepted, got %v", err)
+	}
+}
+
+func TestDeleteRemovesVariants(t *testing.T) {
+	SetStorage(blob.NewMemoryStore())
+	ctx := context.Background()
+
+	img, err := Save(ctx, bytes.NewReader(pngBytes(t, 400, 200)))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := Delete(ctx, img.Key); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	for _, key := range []string{img.Key, strings.TrimPrefix(img.Variants[0].URL, "/media/")} {
+		if _, err := Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected %s to be gone, got %v", key, err)
+		}
+	}
+	if _, err := Describe(ctx, img.Key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the description to be gone, got %v", err)
+	}
+}
+
+func TestKeysIn(t *testing.T) {
+	id := "0123456789abcdef0123456789abcdef"
+	text := "![a](https://api.example.com/media/" + id + "-640w.jpg) and ![b](/media/" + id + ".jpg) " +
+		"and /media/" + strings.Repeat("f", 32) + ".png but not /media/" + id + ".jpgx or " + id + ".png"
+	got := KeysIn(text)
+	want := []string{id + ".jpg", strings.Repeat("f", 32) + ".png"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
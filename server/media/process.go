@@ -0,0 +1,326 @@
+package media
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// VariantWidths are the widths uploads are resized to for srcset. Widths
+// that are not smaller than the upload are skipped.
+var VariantWidths = []int{320, 640, 960, 1280, 1920}
+
+// maxPixels bounds the decoded size of an upload, counting every frame of a
+// GIF. Compressed images can be small on the wire and still need gigabytes
+// once decoded.
+const maxPixels = 40_000_000
+
+// placeholderWidth is the width of the placeholder shown while an image
+// loads. Browsers scale it up, which blurs it.
+const placeholderWidth = 16
+
+const jpegQuality = 82
+
+// encoded is an image ready to be stored.
+type encoded struct {
+	data          []byte
+	contentType   string
+	ext           string
+	width, height int
+}
+
+// processed is the outcome of processing an upload: the full-size image, its
+// resized variants keyed by width and a placeholder data URI.
+type processed struct {
+	full        encoded
+	variants    map[int]encoded
+	placeholder string
+}
+
+// process decodes an upload of the given sniffed type and re-encodes it,
+// which drops EXIF and any other metadata. JPEGs are turned upright first
+// according to their EXIF orientation. Animated GIFs are re-encoded frame by
+// frame, which keeps their timing and loop count but drops comments and other
+// extension blocks, and get no variants.
+func process(data []byte, contentType string) (*processed, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("%w: empty image", ErrUnsupportedType)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	if contentType == "image/gif" {
+		// DecodeAll decodes every frame at once, so count them first.
+		frames, err := gifFrames(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+		}
+		if frames*config.Width*config.Height > maxPixels {
+			return nil, ErrTooLarge
+		}
+
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+		}
+		if len(anim.Image) > 1 {
+			var buf bytes.Buffer
+			if err := gif.EncodeAll(&buf, anim); err != nil {
+				return nil, fmt.Errorf("failed to encode GIF: %w", err)
+			}
+			placeholder, err := placeholderURI(anim.Image[0])
+			if err != nil {
+				return nil, err
+			}
+			return &processed{
+				full:        encoded{data: buf.Bytes(), contentType: contentType, ext: ".gif", width: config.Width, height: config.Height},
+				placeholder: placeholder,
+			}, nil
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	// Photos stay JPEG; anything that may be a graphic or have transparency
+	// becomes PNG.
+	asJPEG := contentType == "image/jpeg" || (contentType == "image/webp" && opaque(img))
+
+	p := &processed{variants: make(map[int]encoded)}
+	if p.full, err = encode(img, asJPEG); err != nil {
+		return nil, err
+	}
+	for _, width := range VariantWidths {
+		if width >= img.Bounds().Dx() {
+			continue
+		}
+		if p.variants[width], err = encode(resize(img, width), asJPEG); err != nil {
+			return nil, err
+		}
+	}
+	if p.placeholder, err = placeholderURI(img); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// gifFrames counts the frames of a GIF by walking its blocks, without
+// decoding any of them.
+func gifFrames(data []byte) (int, error) {
+	errTruncated := errors.New("gif: truncated")
+	if len(data) < 13 {
+		return 0, errTruncated
+	}
+	// Header and logical screen descriptor, then the global color table.
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+
+	frames := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21:
+			// Extension: introducer and label, then data sub-blocks.
+			i += 2
+		case 0x2C:
+			// Image descriptor and local color table, then the LZW minimum
+			// code size and the image data sub-blocks.
+			if i+10 > len(data) {
+				return 0, errTruncated
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i++
+			frames++
+		case 0x3B:
+			return frames, nil
+		default:
+			return 0, fmt.Errorf("gif: unknown block 0x%02x", data[i])
+		}
+
+		for {
+			if i >= len(data) {
+				return 0, errTruncated
+			}
+			size := int(data[i])
+			i += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+	return 0, errTruncated
+}
+
+func encode(img image.Image, asJPEG bool) (encoded, error) {
+	var buf bytes.Buffer
+	e := encoded{width: img.Bounds().Dx(), height: img.Bounds().Dy()}
+	if asJPEG {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return e, fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+		e.contentType, e.ext = "image/jpeg", ".jpg"
+	} else {
+		if err := png.Encode(&buf, img); err != nil {
+			return e, fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		e.contentType, e.ext = "image/png", ".png"
+	}
+	e.data = buf.Bytes()
+	return e, nil
+}
+
+// placeholderURI returns a tiny copy of img as a data URI.
+func placeholderURI(img image.Image) (string, error) {
+	small, err := encode(resize(img, min(placeholderWidth, img.Bounds().Dx())), opaque(img))
+	if err != nil {
+		return "", err
+	}
+	return "data:" + small.contentType + ";base64," + base64.StdEncoding.EncodeToString(small.data), nil
+}
+
+// resize scales img to the given width, keeping its aspect ratio.
+func resize(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	height := max(1, int(math.Round(float64(b.Dy())*float64(width)/float64(b.Dx()))))
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// orient returns img turned upright according to an EXIF orientation
+// (1 to 8); see the TIFF specification's Orientation tag.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 when it has
+// none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Metadata segments all come before the image data.
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		if marker == 0xE1 {
+			if orientation := exifOrientation(data[i+4 : i+2+size]); orientation != 0 {
+				return orientation
+			}
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation reads the Orientation tag from the first IFD of an APP1
+// Exif segment and returns 0 when there is none.
+func exifOrientation(segment []byte) int {
+	if len(segment) < 14 || string(segment[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := segment[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int64(order.Uint32(tiff[4:]))
+	if ifd+2 > int64(len(tiff)) {
+		return 0
+	}
+	entries := int64(order.Uint16(tiff[ifd:]))
+	for j := int64(0); j < entries; j++ {
+		entry := ifd + 2 + 12*j
+		if entry+12 > int64(len(tiff)) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 0
+		}
+	}
+	return 0
+}
@@ -27,6 +27,21 @@ func Authenticate(context *gin.Context) {
 	context.Next()
 }
 
+// OptionalAuthenticate is Authenticate for routes that anonymous visitors may
+// also use. A valid token populates "userId" and "role" as Authenticate does;
+// without one, or with an invalid one, the request goes on as an anonymous
+// reader with neither value set.
+func OptionalAuthenticate(context *gin.Context) {
+	token := context.Request.Header.Get("Authorization")
+	if token != "" {
+		if userId, role, err := utils.VerifyJWTToken(token); err == nil {
+			context.Set("userId", userId)
+			context.Set("role", role)
+		}
+	}
+	context.Next()
+}
+
 // RequireAdmin ensures that the authenticated user has the "admin" role.
 // It assumes the Authenticate middleware has already run and populated the
 // "role" value in the Gin context.
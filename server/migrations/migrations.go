@@ -0,0 +1,278 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"example.com/blog_backend/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// All returns the registered migrations in version order. New migrations are
+// appended with the next version number; shipped entries are never edited or
+// renumbered.
+func All() []Migration {
+	return []Migration{
+		{
+			Version:    1,
+			Name:       "backfill post metadata defaults",
+			Collection: "posts",
+			Apply:      backfillPostDefaults,
+		},
+		{
+			Version:    2,
+			Name:       "backfill post comments_count",
+			Collection: "posts",
+			Apply:      backfillPostCommentsCount,
+		},
+		{
+			Version:    3,
+			Name:       "backfill user role",
+			Collection: "users",
+			Apply:      backfillUserRole,
+		},
+		{
+			Version:    4,
+			Name:       "backfill comment updated_at and author_name",
+			Collection: "post_comments",
+			Apply:      backfillCommentDefaults,
+		},
+		{
+			Version:    5,
+			Name:       "move post content to post_contents",
+			Collection: "posts",
+			Apply:      movePostContent,
+		},
+		{
+			Version:    6,
+			Name:       "backfill post slugs",
+			Collection: "posts",
+			Apply:      backfillPostSlugs,
+		},
+		{
+			Version:    7,
+			Name:       "create categories used by posts",
+			Collection: "posts",
+			Apply:      createPostCategories,
+		},
+	}
+}
+
+// backfillPostDefaults writes explicit values for post fields that were added
+// after the first posts were created (description, category,
+// cover_image_key, status, updated_at and the reaction counters).
+func backfillPostDefaults(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error) {
+	var updates []firestore.Update
+
+	for _, field := range []string{"description", "category", "cover_image_key"} {
+		if _, ok := data[field]; !ok {
+			updates = append(updates, firestore.Update{Path: field, Value: ""})
+		}
+	}
+	for _, field := range []string{"likes_count", "dislikes_count"} {
+		if _, ok := data[field]; !ok {
+			updates = append(updates, firestore.Update{Path: field, Value: int64(0)})
+		}
+	}
+
+	// Only "draft", "scheduled" and "published" are valid; Post.Save treats
+	// anything else as published. A scheduled post is left for the
+	// scheduler to publish at its publish_at.
+	if status, _ := data["status"].(string); status != "draft" && status != "scheduled" && status != "published" {
+		updates = append(updates, firestore.Update{Path: "status", Value: "published"})
+	}
+
+	if _, ok := data["updated_at"]; !ok {
+		if createdAt, ok := data["created_at"]; ok {
+			updates = append(updates, firestore.Update{Path: "updated_at", Value: createdAt})
+		}
+	}
+
+	return updates, nil
+}
+
+// backfillPostCommentsCount computes comments_count for posts created before
+// the counter existed by counting their post_comments documents.
+func backfillPostCommentsCount(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error) {
+	if _, ok := data["comments_count"]; ok {
+		return nil, nil
+	}
+
+	postID, ok := data["id"].(int64)
+	if !ok {
+		return nil, fmt.Errorf("post has no numeric id field")
+	}
+
+	count, err := countWhere(ctx, client.Collection("post_comments").Where("post_id", "==", postID))
+	if err != nil {
+		return nil, err
+	}
+
+	return []firestore.Update{{Path: "comments_count", Value: count}}, nil
+}
+
+// backfillUserRole gives users created before roles existed the default
+// "user" role.
+func backfillUserRole(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error) {
+	if role, _ := data["role"].(string); role != "" {
+		return nil, nil
+	}
+	return []firestore.Update{{Path: "role", Value: "user"}}, nil
+}
+
+// backfillCommentDefaults fills in updated_at (from created_at) and the
+// author name for comments written before those fields were stored. The
+// author name comes from the owning user, or "Deleted user" if they are gone.
+func backfillCommentDefaults(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error) {
+	var updates []firestore.Update
+
+	if _, ok := data["updated_at"]; !ok {
+		if createdAt, ok := data["created_at"]; ok {
+			updates = append(updates, firestore.Update{Path: "updated_at", Value: createdAt})
+		}
+	}
+
+	if name, _ := data["author_name"].(string); name == "" {
+		authorName := "Deleted user"
+		if userID, _ := data["user_id"].(int64); userID > 0 {
+			docs, err := client.Collection("users").Where("id", "==", userID).Limit(1).Documents(ctx).GetAll()
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up comment author: %w", err)
+			}
+			if len(docs) > 0 {
+				if username, _ := docs[0].Data()["username"].(string); username != "" {
+					authorName = username
+				}
+			}
+		}
+		updates = append(updates, firestore.Update{Path: "author_name", Value: authorName})
+	}
+
+	return updates, nil
+}
+
+// movePostContent copies the body of a post written before content was
+// stored separately into post_contents/<id>, then drops it from the metadata
+// document and stores the excerpt used by listings.
+//
+// The body document is created, never overwritten: if it already exists the
+// post was edited after the migration read it (or an earlier run got this
+// far), and that body is the one to keep.
+func movePostContent(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error) {
+	content, ok := data["content"].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	_, err := client.Collection("post_contents").Doc(docID).Create(ctx, map[string]interface{}{"content": content})
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return nil, fmt.Errorf("failed to write post content: %w", err)
+	}
+
+	updates := []firestore.Update{{Path: "content", Value: firestore.Delete}}
+	if excerpt, _ := data["excerpt"].(string); excerpt == "" {
+		updates = append(updates, firestore.Update{Path: "excerpt", Value: models.PostExcerpt(content)})
+	}
+	return updates, nil
+}
+
+// backfillPostSlugs gives posts created before slugs existed one generated
+// from their title, registered in post_slugs like the store does for new
+// posts. Candidates are claimed with Create, so two posts never end up with
+// the same slug; a candidate already registered to this post (from an
+// interrupted run) is reused.
+func backfillPostSlugs(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error) {
+	if slug, _ := data["slug"].(string); slug != "" {
+		return nil, nil
+	}
+
+	postID, ok := data["id"].(int64)
+	if !ok {
+		return nil, fmt.Errorf("post has no numeric id field")
+	}
+	title, _ := data["title"].(string)
+	base := models.Slugify(title)
+	if base == "" {
+		base = models.FallbackSlug
+	}
+
+	for attempt := 0; ; attempt++ {
+		slug := models.SlugCandidate(base, attempt)
+		ref := client.Collection("post_slugs").Doc(slug)
+
+		_, err := ref.Create(ctx, map[string]interface{}{"post_id": postID})
+		if err == nil {
+			return []firestore.Update{{Path: "slug", Value: slug}}, nil
+		}
+		if status.Code(err) != codes.AlreadyExists {
+			return nil, fmt.Errorf("failed to register post slug: %w", err)
+		}
+
+		snap, err := ref.Get(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up post slug: %w", err)
+		}
+		if owner, _ := snap.Data()["post_id"].(int64); owner == postID {
+			return []firestore.Update{{Path: "slug", Value: slug}}, nil
+		}
+	}
+}
+
+// createPostCategories creates a managed category for the free-text category
+// of every post, so existing posts keep validating once categories must
+// exist. Categories are keyed by the slug of their name, so the first post
+// seen decides how a name is spelled; posts that spell it differently ("tech"
+// next to "Tech") are moved to that spelling.
+func createPostCategories(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error) {
+	name, _ := data["category"].(string)
+	name = strings.TrimSpace(name)
+	slug := models.Slugify(name)
+	if slug == "" {
+		return nil, nil
+	}
+
+	ref := client.Collection("categories").Doc(slug)
+	now := time.Now()
+	_, err := ref.Create(ctx, map[string]interface{}{
+		"name":        name,
+		"description": "",
+		"sort_order":  0,
+		"created_at":  now,
+		"updated_at":  now,
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if status.Code(err) != codes.AlreadyExists {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	snap, err := ref.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up category: %w", err)
+	}
+	if canonical, _ := snap.Data()["name"].(string); canonical != "" && canonical != data["category"] {
+		return []firestore.Update{{Path: "category", Value: canonical}}, nil
+	}
+	return nil, nil
+}
+
+// countWhere returns the number of documents matched by q using a server-side
+// count aggregation.
+func countWhere(ctx context.Context, q firestore.Query) (int64, error) {
+	result, err := q.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	value, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count aggregation result %T", result["count"])
+	}
+	return value.GetIntegerValue(), nil
+}
@@ -0,0 +1,180 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+func updatesByPath(updates []firestore.Update) map[string]interface{} {
+	byPath := make(map[string]interface{}, len(updates))
+	for _, u := range updates {
+		byPath[u.Path] = u.Value
+	}
+	return byPath
+}
+
+func TestAllMigrationsAreValid(t *testing.T) {
+	if err := Validate(All()); err != nil {
+		t.Fatalf("registered migrations are invalid: %v", err)
+	}
+}
+
+func TestValidateRejectsOutOfOrderVersions(t *testing.T) {
+	apply := func(context.Context, *firestore.Client, string, map[string]interface{}) ([]firestore.Update, error) {
+		return nil, nil
+	}
+	migrations := []Migration{
+		{Version: 2, Name: "second", Collection: "posts", Apply: apply},
+		{Version: 1, Name: "first", Collection: "posts", Apply: apply},
+	}
+	if err := Validate(migrations); err == nil {
+		t.Fatal("expected out-of-order versions to be rejected")
+	}
+}
+
+func TestBackfillPostDefaults(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updates, err := backfillPostDefaults(context.Background(), nil, "1", map[string]interface{}{
+		"id":         int64(1),
+		"title":      "Old post",
+		"status":     "",
+		"created_at": createdAt,
+		"category":   "news",
+	})
+	if err != nil {
+		t.Fatalf("backfillPostDefaults failed: %v", err)
+	}
+
+	got := updatesByPath(updates)
+	if got["status"] != "published" {
+		t.Errorf("expected status to be backfilled as published, got %v", got["status"])
+	}
+	if got["updated_at"] != createdAt {
+		t.Errorf("expected updated_at to copy created_at, got %v", got["updated_at"])
+	}
+	if got["likes_count"] != int64(0) || got["dislikes_count"] != int64(0) {
+		t.Errorf("expected reaction counters to be backfilled, got %v", got)
+	}
+	if _, ok := got["category"]; ok {
+		t.Errorf("expected existing category to be left alone, got %v", got["category"])
+	}
+
+	// A second pass over the migrated document is a no-op.
+	migrated := map[string]interface{}{"category": "news"}
+	for path, value := range got {
+		migrated[path] = value
+	}
+	if updates, _ := backfillPostDefaults(context.Background(), nil, "1", migrated); len(updates) != 0 {
+		t.Errorf("expected no updates for a migrated post, got %v", updates)
+	}
+}
+
+// Scheduled posts keep their status, so running the migrations does not
+// publish them early.
+func TestBackfillPostDefaultsKeepsScheduledPosts(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	publishAt := time.Now().Add(24 * time.Hour)
+	data := map[string]interface{}{
+		"id":              int64(1),
+		"title":           "Coming soon",
+		"status":          "scheduled",
+		"publish_at":      publishAt,
+		"created_at":      createdAt,
+		"updated_at":      createdAt,
+		"description":     "",
+		"category":        "",
+		"cover_image_key": "",
+		"likes_count":     int64(0),
+		"dislikes_count":  int64(0),
+	}
+	updates, err := backfillPostDefaults(context.Background(), nil, "1", data)
+	if err != nil {
+		t.Fatalf("backfillPostDefaults failed: %v", err)
+	}
+	for path, value := range updatesByPath(updates) {
+		data[path] = value
+	}
+	if data["status"] != "scheduled" || data["publish_at"] != publishAt {
+		t.Errorf("expected the post to stay scheduled for %v, got %v at %v", publishAt, data["status"], data["publish_at"])
+	}
+}
+
+func TestBackfillUserRole(t *testing.T) {
+	updates, err := backfillUserRole(context.Background(), nil, "1", map[string]interface{}{"username": "alice"})
+	if err != nil {
+		t.Fatalf("backfillUserRole failed: %v", err)
+	}
+	if got := updatesByPath(updates); got["role"] != "user" {
+		t.Errorf("expected missing role to become user, got %v", got)
+	}
+
+	updates, _ = backfillUserRole(context.Background(), nil, "2", map[string]interface{}{"role": "admin"})
+	if len(updates) != 0 {
+		t.Errorf("expected existing role to be kept, got %v", updates)
+	}
+}
+
+func TestBackfillCommentDefaultsForDeletedAuthor(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updates, err := backfillCommentDefaults(context.Background(), nil, "c1", map[string]interface{}{
+		"post_id":    int64(1),
+		"user_id":    int64(0),
+		"content":    "hello",
+		"created_at": createdAt,
+	})
+	if err != nil {
+		t.Fatalf("backfillCommentDefaults failed: %v", err)
+	}
+
+	got := updatesByPath(updates)
+	if got["author_name"] != "Deleted user" {
+		t.Errorf("expected anonymous author name, got %v", got["author_name"])
+	}
+	if got["updated_at"] != createdAt {
+		t.Errorf("expected updated_at to copy created_at, got %v", got["updated_at"])
+	}
+}
+
+func TestMovePostContentSkipsMigratedPosts(t *testing.T) {
+	updates, err := movePostContent(context.Background(), nil, "1", map[string]interface{}{
+		"id":      int64(1),
+		"title":   "New post",
+		"excerpt": "Already split",
+	})
+	if err != nil {
+		t.Fatalf("movePostContent failed: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Errorf("expected no updates for a post without legacy content, got %v", updates)
+	}
+}
+
+func TestBackfillPostSlugsSkipsPostsWithSlug(t *testing.T) {
+	updates, err := backfillPostSlugs(context.Background(), nil, "1", map[string]interface{}{
+		"id":    int64(1),
+		"title": "Hello",
+		"slug":  "hello",
+	})
+	if err != nil {
+		t.Fatalf("backfillPostSlugs failed: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Errorf("expected no updates for a post that has a slug, got %v", updates)
+	}
+}
+
+func TestCreatePostCategoriesSkipsPostsWithoutCategory(t *testing.T) {
+	updates, err := createPostCategories(context.Background(), nil, "1", map[string]interface{}{
+		"id":       int64(1),
+		"category": "  ",
+	})
+	if err != nil {
+		t.Fatalf("createPostCategories failed: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Errorf("expected no updates for a post without category, got %v", updates)
+	}
+}
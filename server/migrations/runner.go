@@ -0,0 +1,234 @@
+// Package migrations applies numbered, one-time data migrations to the
+// Firestore collections. Each migration visits every document of one
+// collection and may return field updates for it, so adding a field to a
+// firestore*Doc struct can come with a real backfill instead of relying on
+// zero values for old documents.
+//
+// Applied migrations are recorded in the "schema_migrations" collection. A
+// migration that is interrupted keeps a checkpoint there and resumes after
+// the last fully processed batch on the next run.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultBatchSize is the number of documents read and written per batch.
+const DefaultBatchSize = 200
+
+// Migration is one numbered data migration over a single collection.
+type Migration struct {
+	// Version orders migrations and identifies them in schema_migrations. It
+	// must be unique and must never change once the migration has shipped.
+	Version int
+	// Name is a short human-readable description.
+	Name string
+	// Collection is the Firestore collection the migration walks.
+	Collection string
+	// Apply inspects one document and returns the updates to write, or nil
+	// if the document is already up to date. It must be idempotent.
+	Apply func(ctx context.Context, client *firestore.Client, docID string, data map[string]interface{}) ([]firestore.Update, error)
+}
+
+// Record is the schema_migrations document for one migration.
+type Record struct {
+	Version          int       `firestore:"version" json:"version"`
+	Name             string    `firestore:"name" json:"name"`
+	Completed        bool      `firestore:"completed" json:"completed"`
+	LastDocumentID   string    `firestore:"last_document_id" json:"last_document_id,omitempty"`
+	DocumentsScanned int64     `firestore:"documents_scanned" json:"documents_scanned"`
+	DocumentsUpdated int64     `firestore:"documents_updated" json:"documents_updated"`
+	StartedAt        time.Time `firestore:"started_at" json:"started_at"`
+	AppliedAt        time.Time `firestore:"applied_at" json:"applied_at,omitempty"`
+}
+
+// Runner applies pending migrations to a Firestore database.
+type Runner struct {
+	Client     *firestore.Client
+	Migrations []Migration
+	BatchSize  int
+	// Logf, when set, receives progress messages.
+	Logf func(format string, args ...interface{})
+}
+
+// NewRunner returns a Runner for every registered migration.
+func NewRunner(client *firestore.Client) *Runner {
+	return &Runner{
+		Client:     client,
+		Migrations: All(),
+		BatchSize:  DefaultBatchSize,
+	}
+}
+
+func (r *Runner) logf(format string, args ...interface{}) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
+
+func (r *Runner) recordRef(m Migration) *firestore.DocumentRef {
+	return r.Client.Collection("schema_migrations").Doc(fmt.Sprintf("%04d", m.Version))
+}
+
+// loadRecord returns the stored record for m, or nil if m has never started.
+func (r *Runner) loadRecord(ctx context.Context, m Migration) (*Record, error) {
+	snap, err := r.recordRef(m).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration %d record: %w", m.Version, err)
+	}
+
+	var rec Record
+	if err := snap.DataTo(&rec); err != nil {
+		return nil, fmt.Errorf("failed to decode migration %d record: %w", m.Version, err)
+	}
+	return &rec, nil
+}
+
+// Status returns one record per registered migration. Migrations that have
+// never run are reported with only Version and Name set.
+func (r *Runner) Status(ctx context.Context) ([]Record, error) {
+	if err := Validate(r.Migrations); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(r.Migrations))
+	for _, m := range r.Migrations {
+		rec, err := r.loadRecord(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			rec = &Record{Version: m.Version, Name: m.Name}
+		}
+		records = append(records, *rec)
+	}
+	return records, nil
+}
+
+// Run applies every migration that has not completed yet, in version order,
+// and returns the records of the migrations it ran.
+func (r *Runner) Run(ctx context.Context) ([]Record, error) {
+	if err := Validate(r.Migrations); err != nil {
+		return nil, err
+	}
+
+	var ran []Record
+	for _, m := range r.Migrations {
+		rec, err := r.loadRecord(ctx, m)
+		if err != nil {
+			return ran, err
+		}
+		if rec != nil && rec.Completed {
+			continue
+		}
+		if rec == nil {
+			rec = &Record{Version: m.Version, Name: m.Name, StartedAt: time.Now()}
+		} else {
+			r.logf("migration %04d %s: resuming after document %q", m.Version, m.Name, rec.LastDocumentID)
+		}
+
+		if err := r.apply(ctx, m, rec); err != nil {
+			return ran, fmt.Errorf("migration %04d %s: %w", m.Version, m.Name, err)
+		}
+		ran = append(ran, *rec)
+	}
+
+	return ran, nil
+}
+
+// apply walks m.Collection in document-ID order, one batch at a time, and
+// checkpoints rec after every batch.
+func (r *Runner) apply(ctx context.Context, m Migration, rec *Record) error {
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	col := r.Client.Collection(m.Collection)
+
+	for {
+		q := col.OrderBy(firestore.DocumentID, firestore.Asc).Limit(batchSize)
+		if rec.LastDocumentID != "" {
+			q = q.StartAfter(rec.LastDocumentID)
+		}
+
+		docs, err := q.Documents(ctx).GetAll()
+		if err != nil {
+			return fmt.Errorf("failed to read %s batch: %w", m.Collection, err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		bw := r.Client.BulkWriter(ctx)
+		var jobs []*firestore.BulkWriterJob
+		for _, doc := range docs {
+			updates, err := m.Apply(ctx, r.Client, doc.Ref.ID, doc.Data())
+			if err != nil {
+				bw.End()
+				return fmt.Errorf("document %s: %w", doc.Ref.ID, err)
+			}
+			if len(updates) == 0 {
+				continue
+			}
+
+			// Guard against clobbering a concurrent edit; a failed
+			// precondition aborts the run and the batch is retried on resume.
+			job, err := bw.Update(doc.Ref, updates, firestore.LastUpdateTime(doc.UpdateTime))
+			if err != nil {
+				bw.End()
+				return fmt.Errorf("failed to queue update for %s: %w", doc.Ref.ID, err)
+			}
+			jobs = append(jobs, job)
+		}
+		bw.End()
+
+		for _, job := range jobs {
+			if _, err := job.Results(); err != nil {
+				return fmt.Errorf("failed to write %s batch: %w", m.Collection, err)
+			}
+		}
+
+		rec.DocumentsScanned += int64(len(docs))
+		rec.DocumentsUpdated += int64(len(jobs))
+		rec.LastDocumentID = docs[len(docs)-1].Ref.ID
+		if _, err := r.recordRef(m).Set(ctx, rec); err != nil {
+			return fmt.Errorf("failed to checkpoint progress: %w", err)
+		}
+		r.logf("migration %04d %s: %d scanned, %d updated", m.Version, m.Name, rec.DocumentsScanned, rec.DocumentsUpdated)
+
+		if len(docs) < batchSize {
+			break
+		}
+	}
+
+	rec.Completed = true
+	rec.AppliedAt = time.Now()
+	if _, err := r.recordRef(m).Set(ctx, rec); err != nil {
+		return fmt.Errorf("failed to record completion: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that versions are positive, unique and ascending.
+func Validate(migrations []Migration) error {
+	last := 0
+	for _, m := range migrations {
+		if m.Version <= last {
+			return fmt.Errorf("migration %q has version %d, which must be greater than %d", m.Name, m.Version, last)
+		}
+		if m.Collection == "" || m.Apply == nil {
+			return fmt.Errorf("migration %04d %q must set Collection and Apply", m.Version, m.Name)
+		}
+		last = m.Version
+	}
+	return nil
+}
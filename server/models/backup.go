@@ -0,0 +1,51 @@
+package models
+
+import "context"
+
+// ExportedUser is a user together with their stored password hash, as
+// written to and read back from a backup.
+type ExportedUser struct {
+	User
+	PasswordHash string `json:"password_hash"`
+}
+
+// ExportUsers returns every user including their password hash.
+// Backups need the hashes so restored accounts can still log in.
+func ExportUsers(ctx context.Context) ([]ExportedUser, error) {
+	users, err := store().ListUsers(ctx)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	exported := make([]ExportedUser, 0, len(users))
+	for _, u := range users {
+		_, passwordHash, err := store().GetUserByUsername(ctx, u.Username)
+		if err != nil {
+			return nil, storeError(ctx, err)
+		}
+		exported = append(exported, ExportedUser{User: u, PasswordHash: passwordHash})
+	}
+	return exported, nil
+}
+
+// ListAllComments returns every comment on every post.
+func ListAllComments(ctx context.Context) ([]Comment, error) {
+	comments, err := store().ListAllComments(ctx)
+	return comments, storeError(ctx, err)
+}
+
+// ListAllReactions returns every stored reaction.
+func ListAllReactions(ctx context.Context) ([]PostReaction, error) {
+	reactions, err := store().ListAllReactions(ctx)
+	return reactions, storeError(ctx, err)
+}
+
+// DeleteAllData removes every user, post, comment and reaction. It exists for
+// restoring a backup over an existing store and is bounded only by ctx.
+func DeleteAllData(ctx context.Context) error {
+	if err := store().DeleteAll(ctx); err != nil {
+		return storeError(ctx, err)
+	}
+	unindexAllPosts()
+	return nil
+}
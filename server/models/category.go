@@ -0,0 +1,226 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxCategoryNameLength is the maximum length of a category name in runes.
+const maxCategoryNameLength = 50
+
+var (
+	// ErrCategoryNotFound is returned when no category has the requested
+	// slug.
+	ErrCategoryNotFound = errors.New("category not found")
+
+	// ErrCategoryExists is returned when a category with the same slug
+	// already exists. Slugs are derived from names, so this also rejects
+	// names that only differ in case or punctuation.
+	ErrCategoryExists = errors.New("category already exists")
+
+	// ErrCategoryInUse is returned when deleting a category that posts are
+	// still filed under.
+	ErrCategoryInUse = errors.New("category is used by posts")
+
+	// ErrInvalidCategory is returned for empty or overlong category names.
+	ErrInvalidCategory = errors.New("invalid category")
+
+	// ErrUnknownCategory is returned when a post names a category that does
+	// not exist.
+	ErrUnknownCategory = errors.New("unknown category")
+)
+
+// Category is a managed post category. Posts refer to it by Name in
+// Post.Category. Slug is derived from Name and identifies the category in
+// URLs; categories are listed by SortOrder, then by name.
+type Category struct {
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description"`
+	SortOrder   int       `json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CategoryWithCount is a category together with the number of published
+// posts filed under it.
+type CategoryWithCount struct {
+	Category
+	PostCount int `json:"post_count"`
+}
+
+// normalize trims the name and description and derives the slug.
+func (c *Category) normalize() error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Description = strings.TrimSpace(c.Description)
+	c.Slug = Slugify(c.Name)
+	if c.Slug == "" || utf8.RuneCountInString(c.Name) > maxCategoryNameLength {
+		return fmt.Errorf("%w: a name needs letters or digits and at most %d characters", ErrInvalidCategory, maxCategoryNameLength)
+	}
+	return nil
+}
+
+// sortCategories orders categories by SortOrder, then by name.
+func sortCategories(categories []Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return strings.ToLower(categories[i].Name) < strings.ToLower(categories[j].Name)
+	})
+}
+
+// Save creates the category. Its slug is derived from the name and must not
+// be taken (ErrCategoryExists).
+func (c *Category) Save(ctx context.Context) error {
+	if err := c.normalize(); err != nil {
+		return err
+	}
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().CreateCategory(ctx, c))
+}
+
+// Update replaces the category stored under slug with c. Renaming a category
+// changes its slug and moves every post filed under the old name to the new
+// one.
+func (c *Category) Update(ctx context.Context, slug string) error {
+	if err := c.normalize(); err != nil {
+		return err
+	}
+	c.UpdatedAt = time.Now()
+
+	wctx, cancel := writeContext(ctx)
+	defer cancel()
+	ids, err := store().UpdateCategory(wctx, slug, c)
+	if err != nil {
+		return storeError(wctx, err)
+	}
+
+	for _, id := range ids {
+		if post, err := GetPostByID(ctx, id); err == nil {
+			indexPost(*post)
+		}
+	}
+	return nil
+}
+
+// DeleteCategory removes the category with the given slug. It fails with
+// ErrCategoryInUse while posts are filed under it.
+func DeleteCategory(ctx context.Context, slug string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().DeleteCategory(ctx, slug))
+}
+
+// GetCategory returns the category with the given slug.
+func GetCategory(ctx context.Context, slug string) (*Category, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	c, err := store().GetCategory(ctx, slug)
+	return c, storeError(ctx, err)
+}
+
+// ListCategories returns every category in display order.
+func ListCategories(ctx context.Context) ([]Category, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	categories, err := store().ListCategories(ctx)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	sortCategories(categories)
+	return categories, nil
+}
+
+// ListCategoriesWithCounts returns every category in display order with its
+// number of published posts.
+func ListCategoriesWithCounts(ctx context.Context) ([]CategoryWithCount, error) {
+	categories, err := ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	counts, err := store().CountPostsByCategory(ctx)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	result := make([]CategoryWithCount, len(categories))
+	for i, c := range categories {
+		result[i] = CategoryWithCount{Category: c, PostCount: counts[c.Name]}
+	}
+	return result, nil
+}
+
+// resolveCategory returns the canonical name of the category a post names,
+// matched by slug so "tech" and "Tech" both file the post under "Tech". An
+// empty name means the post has no category.
+func resolveCategory(ctx context.Context, name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil
+	}
+	slug := Slugify(name)
+	if slug == "" {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCategory, name)
+	}
+
+	c, err := store().GetCategory(ctx, slug)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCategory, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return c.Name, nil
+}
+
+// ImportCategory creates or overwrites the category with c.Slug, keeping its
+// timestamps.
+func ImportCategory(ctx context.Context, c Category) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().ImportCategory(ctx, c))
+}
+
+// CreateMissingCategories creates a category for every category name used
+// by a post that has none yet, so databases from before categories were
+// managed keep validating. It returns how many categories it created.
+func CreateMissingCategories(ctx context.Context) (int, error) {
+	posts, err := GetAllPosts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	categories, err := ListCategories(ctx)
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		known[c.Slug] = true
+	}
+
+	created := 0
+	for _, p := range posts {
+		c := Category{Name: p.Category}
+		if c.normalize() != nil || known[c.Slug] {
+			continue
+		}
+		if err := c.Save(ctx); err != nil && !errors.Is(err, ErrCategoryExists) {
+			return created, fmt.Errorf("category %q: %w", p.Category, err)
+		}
+		known[c.Slug] = true
+		created++
+	}
+	return created, nil
+}
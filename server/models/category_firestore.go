@@ -0,0 +1,282 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firestoreCategoryDoc is the Firestore representation of a category. The
+// slug is the document ID.
+type firestoreCategoryDoc struct {
+	Name        string    `firestore:"name"`
+	Description string    `firestore:"description"`
+	SortOrder   int       `firestore:"sort_order"`
+	CreatedAt   time.Time `firestore:"created_at"`
+	UpdatedAt   time.Time `firestore:"updated_at"`
+}
+
+func newFirestoreCategoryDoc(c Category) firestoreCategoryDoc {
+	return firestoreCategoryDoc{
+		Name:        c.Name,
+		Description: c.Description,
+		SortOrder:   c.SortOrder,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+func (d firestoreCategoryDoc) toCategory(slug string) Category {
+	return Category{
+		Slug:        slug,
+		Name:        d.Name,
+		Description: d.Description,
+		SortOrder:   d.SortOrder,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+	}
+}
+
+// CreateCategory creates categories/<slug>; Create fails if it exists.
+func (s *FirestoreStore) CreateCategory(ctx context.Context, c *Category) error {
+	if _, err := s.categoriesCollection().Doc(c.Slug).Create(ctx, newFirestoreCategoryDoc(*c)); err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return ErrCategoryExists
+		}
+		return fmt.Errorf("failed to save category: %w", err)
+	}
+	return nil
+}
+
+// ListCategories returns every category. The collection is small, so it is
+// sorted by the caller rather than with a composite index.
+func (s *FirestoreStore) ListCategories(ctx context.Context) ([]Category, error) {
+	iter := s.categoriesCollection().Documents(ctx)
+	defer iter.Stop()
+
+	var categories []Category
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate categories: %w", err)
+		}
+
+		var data firestoreCategoryDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode category document: %w", err)
+		}
+		categories = append(categories, data.toCategory(doc.Ref.ID))
+	}
+	return categories, nil
+}
+
+// GetCategory fetches a single category by slug.
+func (s *FirestoreStore) GetCategory(ctx context.Context, slug string) (*Category, error) {
+	snap, err := s.categoriesCollection().Doc(slug).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	var data firestoreCategoryDoc
+	if err := snap.DataTo(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode category document: %w", err)
+	}
+	c := data.toCategory(slug)
+	return &c, nil
+}
+
+// UpdateCategory renames the posts filed under the old name one by one, each
+// in its own transaction that also bumps the post's version, and only then
+// replaces the category document in a transaction, moving it to a new
+// document ID when the slug changes. The old category stays until its posts
+// are renamed, so if that is interrupted, running the same update again
+// renames the rest.
+func (s *FirestoreStore) UpdateCategory(ctx context.Context, slug string, c *Category) ([]int64, error) {
+	oldRef := s.categoriesCollection().Doc(slug)
+	newRef := s.categoriesCollection().Doc(c.Slug)
+
+	old, err := s.GetCategory(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if c.Slug != slug {
+		if _, err := newRef.Get(ctx); err == nil {
+			return nil, ErrCategoryExists
+		} else if status.Code(err) != codes.NotFound {
+			return nil, fmt.Errorf("failed to check for existing category: %w", err)
+		}
+	}
+
+	var changed []int64
+	if old.Name != c.Name {
+		changed, err = s.renameCategoryPosts(ctx, old.Name, c.Name)
+		if err != nil {
+			return changed, err
+		}
+	}
+
+	err = s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(oldRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+		var old firestoreCategoryDoc
+		if err := snap.DataTo(&old); err != nil {
+			return fmt.Errorf("failed to decode category document: %w", err)
+		}
+
+		if c.Slug != slug {
+			if _, err := tx.Get(newRef); err == nil {
+				return ErrCategoryExists
+			} else if status.Code(err) != codes.NotFound {
+				return err
+			}
+			if err := tx.Delete(oldRef); err != nil {
+				return err
+			}
+		}
+
+		c.CreatedAt = old.CreatedAt
+		return tx.Set(newRef, newFirestoreCategoryDoc(*c))
+	})
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) || errors.Is(err, ErrCategoryExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	return changed, nil
+}
+
+// renameCategoryPosts moves every post filed under from to to, each in its
+// own transaction that re-checks the category and bumps the post's version,
+// and returns the IDs of the moved posts.
+func (s *FirestoreStore) renameCategoryPosts(ctx context.Context, from, to string) ([]int64, error) {
+	iter := s.postsCollection().Where("category", "==", from).Select().Documents(ctx)
+	defer iter.Stop()
+
+	var refs []*firestore.DocumentRef
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate category posts: %w", err)
+		}
+		refs = append(refs, doc.Ref)
+	}
+
+	var changed []int64
+	for _, ref := range refs {
+		var postID int64
+		updated := false
+		err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			snap, err := tx.Get(ref)
+			if err != nil {
+				return err
+			}
+			var data firestorePostDoc
+			if err := snap.DataTo(&data); err != nil {
+				return fmt.Errorf("failed to decode post document: %w", err)
+			}
+
+			postID, updated = data.ID, data.Category == from
+			if !updated {
+				return nil
+			}
+			return tx.Update(ref, []firestore.Update{
+				{Path: "category", Value: to},
+				{Path: "version", Value: max(data.Version, 1) + 1},
+			})
+		})
+		if err != nil {
+			return changed, fmt.Errorf("failed to rename category on post %s: %w", ref.ID, err)
+		}
+		if updated {
+			changed = append(changed, postID)
+		}
+	}
+	return changed, nil
+}
+
+// DeleteCategory removes the category unless a post is filed under it. The
+// check and the delete run in one transaction, so a post filed under the
+// category before the delete commits makes it fail with ErrCategoryInUse.
+func (s *FirestoreStore) DeleteCategory(ctx context.Context, slug string) error {
+	ref := s.categoriesCollection().Doc(slug)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+		var data firestoreCategoryDoc
+		if err := snap.DataTo(&data); err != nil {
+			return fmt.Errorf("failed to decode category document: %w", err)
+		}
+
+		inUse, err := tx.Documents(s.postsCollection().Where("category", "==", data.Name).Limit(1).Select()).GetAll()
+		if err != nil {
+			return fmt.Errorf("failed to check category posts: %w", err)
+		}
+		if len(inUse) > 0 {
+			return ErrCategoryInUse
+		}
+		return tx.Delete(ref)
+	})
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) || errors.Is(err, ErrCategoryInUse) {
+			return err
+		}
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return nil
+}
+
+// CountPostsByCategory counts published posts per category name, reading
+// only the category field of each published post.
+func (s *FirestoreStore) CountPostsByCategory(ctx context.Context) (map[string]int, error) {
+	iter := s.postsCollection().Where("status", "==", "published").Select("category").Documents(ctx)
+	defer iter.Stop()
+
+	counts := make(map[string]int)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate posts: %w", err)
+		}
+		if name, _ := doc.Data()["category"].(string); name != "" {
+			counts[name]++
+		}
+	}
+	return counts, nil
+}
+
+// ImportCategory creates or overwrites categories/<slug>.
+func (s *FirestoreStore) ImportCategory(ctx context.Context, c Category) error {
+	if _, err := s.categoriesCollection().Doc(c.Slug).Set(ctx, newFirestoreCategoryDoc(c)); err != nil {
+		return fmt.Errorf("failed to import category: %w", err)
+	}
+	return nil
+}
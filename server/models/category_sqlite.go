@@ -0,0 +1,202 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const sqliteCategoryColumns = `slug, name, description, sort_order, created_at, updated_at`
+
+func scanSQLiteCategory(row rowScanner) (Category, error) {
+	var c Category
+	err := row.Scan(&c.Slug, &c.Name, &c.Description, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+// CreateCategory inserts c; the slug primary key rejects duplicates.
+func (s *SQLiteStore) CreateCategory(ctx context.Context, c *Category) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM categories WHERE slug = ?`, c.Slug).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check for existing category: %w", err)
+		}
+		if exists > 0 {
+			return ErrCategoryExists
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO categories (`+sqliteCategoryColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+			c.Slug, c.Name, c.Description, c.SortOrder, c.CreatedAt.UTC(), c.UpdatedAt.UTC(),
+		); err != nil {
+			return fmt.Errorf("failed to save category: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListCategories returns every category.
+func (s *SQLiteStore) ListCategories(ctx context.Context) ([]Category, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteCategoryColumns+` FROM categories`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		c, err := scanSQLiteCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode category row: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate categories: %w", err)
+	}
+	return categories, nil
+}
+
+// GetCategory fetches a single category by slug.
+func (s *SQLiteStore) GetCategory(ctx context.Context, slug string) (*Category, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqliteCategoryColumns+` FROM categories WHERE slug = ?`, slug)
+	c, err := scanSQLiteCategory(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return &c, nil
+}
+
+// UpdateCategory replaces the category and renames its posts in one
+// transaction.
+func (s *SQLiteStore) UpdateCategory(ctx context.Context, slug string, c *Category) ([]int64, error) {
+	var changed []int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		old, err := scanSQLiteCategory(tx.QueryRowContext(ctx, `SELECT `+sqliteCategoryColumns+` FROM categories WHERE slug = ?`, slug))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategoryNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+
+		if c.Slug != slug {
+			var exists int
+			if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM categories WHERE slug = ?`, c.Slug).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to check for existing category: %w", err)
+			}
+			if exists > 0 {
+				return ErrCategoryExists
+			}
+		}
+
+		c.CreatedAt = old.CreatedAt
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE categories SET slug = ?, name = ?, description = ?, sort_order = ?, updated_at = ?
+			WHERE slug = ?`,
+			c.Slug, c.Name, c.Description, c.SortOrder, c.UpdatedAt.UTC(), slug,
+		); err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+		if old.Name == c.Name {
+			return nil
+		}
+
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM posts WHERE category = ? ORDER BY id`, old.Name)
+		if err != nil {
+			return fmt.Errorf("failed to query category posts: %w", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to decode post row: %w", err)
+			}
+			changed = append(changed, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate category posts: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE posts SET category = ?, version = version + 1 WHERE category = ?`, c.Name, old.Name); err != nil {
+			return fmt.Errorf("failed to rename category on posts: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// DeleteCategory removes the category unless a post is filed under it.
+func (s *SQLiteStore) DeleteCategory(ctx context.Context, slug string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var name string
+		err := tx.QueryRowContext(ctx, `SELECT name FROM categories WHERE slug = ?`, slug).Scan(&name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategoryNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+
+		var used int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM posts WHERE category = ?`, name).Scan(&used); err != nil {
+			return fmt.Errorf("failed to count category posts: %w", err)
+		}
+		if used > 0 {
+			return ErrCategoryInUse
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE slug = ?`, slug); err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		return nil
+	})
+}
+
+// CountPostsByCategory counts published posts per category name.
+func (s *SQLiteStore) CountPostsByCategory(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT category, COUNT(*) FROM posts
+		WHERE status = 'published' AND category != ''
+		GROUP BY category`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count posts by category: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var n int
+		if err := rows.Scan(&name, &n); err != nil {
+			return nil, fmt.Errorf("failed to decode category count row: %w", err)
+		}
+		counts[name] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate category counts: %w", err)
+	}
+	return counts, nil
+}
+
+// ImportCategory creates or overwrites the category with c.Slug.
+func (s *SQLiteStore) ImportCategory(ctx context.Context, c Category) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO categories (`+sqliteCategoryColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (slug) DO UPDATE SET
+			name = excluded.name, description = excluded.description, sort_order = excluded.sort_order,
+			created_at = excluded.created_at, updated_at = excluded.updated_at`,
+		c.Slug, c.Name, c.Description, c.SortOrder, c.CreatedAt.UTC(), c.UpdatedAt.UTC(),
+	); err != nil {
+		return fmt.Errorf("failed to import category: %w", err)
+	}
+	return nil
+}
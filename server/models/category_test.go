@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Posts can only be filed under existing categories, matched by slug;
+// renaming a category moves its posts and deleting one in use fails.
+func TestCategories(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+
+		for _, c := range []*Category{
+			{Name: "News", SortOrder: 2},
+			{Name: "Tech Notes", SortOrder: 1, Description: "How-tos"},
+		} {
+			if err := c.Save(ctx); err != nil {
+				t.Fatalf("failed to create category %q: %v", c.Name, err)
+			}
+		}
+		if err := (&Category{Name: "tech-notes"}).Save(ctx); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("expected ErrCategoryExists for a name with the same slug, got %v", err)
+		}
+		if err := (&Category{Name: "!!!"}).Save(ctx); !errors.Is(err, ErrInvalidCategory) {
+			t.Errorf("expected ErrInvalidCategory for a name without letters, got %v", err)
+		}
+
+		post := &Post{Title: "Hello", Content: "Body", Category: "tech notes"}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		if post.Category != "Tech Notes" {
+			t.Errorf("expected the canonical category name, got %q", post.Category)
+		}
+		if err := (&Post{Title: "Typo", Content: "Body", Category: "Nwes"}).Save(ctx); !errors.Is(err, ErrUnknownCategory) {
+			t.Errorf("expected ErrUnknownCategory, got %v", err)
+		}
+		draft := &Post{Title: "Draft", Content: "Body", Category: "News", Status: "draft"}
+		if err := draft.Save(ctx); err != nil {
+			t.Fatalf("failed to create draft: %v", err)
+		}
+
+		categories, err := ListCategoriesWithCounts(ctx)
+		if err != nil {
+			t.Fatalf("ListCategoriesWithCounts failed: %v", err)
+		}
+		if len(categories) != 2 || categories[0].Slug != "tech-notes" || categories[1].Slug != "news" {
+			t.Fatalf("expected categories in sort order, got %+v", categories)
+		}
+		if categories[0].PostCount != 1 || categories[1].PostCount != 0 {
+			t.Errorf("expected published post counts 1 and 0, got %d and %d", categories[0].PostCount, categories[1].PostCount)
+		}
+
+		renamed := &Category{Name: "Guides", Description: "How-tos"}
+		if err := renamed.Update(ctx, "tech-notes"); err != nil {
+			t.Fatalf("failed to rename category: %v", err)
+		}
+		if _, err := GetCategory(ctx, "tech-notes"); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("expected the old slug to be gone, got %v", err)
+		}
+		got, err := GetPostByID(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("failed to load post: %v", err)
+		}
+		if got.Category != "Guides" {
+			t.Errorf("expected the post to move to the renamed category, got %q", got.Category)
+		}
+		if err := (&Category{Name: "News"}).Update(ctx, "guides"); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("expected ErrCategoryExists when renaming onto another category, got %v", err)
+		}
+
+		if err := DeleteCategory(ctx, "news"); !errors.Is(err, ErrCategoryInUse) {
+			t.Errorf("expected ErrCategoryInUse while a draft uses the category, got %v", err)
+		}
+		if err := draft.Delete(ctx); err != nil {
+			t.Fatalf("failed to delete draft: %v", err)
+		}
+		if err := DeleteCategory(ctx, "news"); err != nil {
+			t.Errorf("failed to delete unused category: %v", err)
+		}
+		if err := DeleteCategory(ctx, "news"); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("expected ErrCategoryNotFound, got %v", err)
+		}
+	})
+}
+
+// A category rename that stopped after moving some of its posts leaves the
+// old category in place, so running it again moves the rest and the moved
+// posts keep validating.
+func TestCategoryRenameResumes(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		if err := (&Category{Name: "News"}).Save(ctx); err != nil {
+			t.Fatalf("failed to create category: %v", err)
+		}
+		var posts []*Post
+		for _, title := range []string{"First", "Second"} {
+			post := &Post{Title: title, Content: "Body", Category: "News"}
+			if err := post.Save(ctx); err != nil {
+				t.Fatalf("failed to create post: %v", err)
+			}
+			posts = append(posts, post)
+		}
+
+		// The interrupted run moved the first post only.
+		moved := *posts[0]
+		moved.Category, moved.Version = "Updates", 0
+		if err := store().UpdatePost(ctx, &moved, nil); err != nil {
+			t.Fatalf("failed to move post: %v", err)
+		}
+
+		if err := (&Category{Name: "Updates"}).Update(ctx, "news"); err != nil {
+			t.Fatalf("failed to rerun the rename: %v", err)
+		}
+		if _, err := GetCategory(ctx, "news"); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("expected the old category to be gone, got %v", err)
+		}
+		for _, post := range posts {
+			stored, err := GetPostByID(ctx, post.ID)
+			if err != nil {
+				t.Fatalf("failed to load post: %v", err)
+			}
+			if stored.Category != "Updates" {
+				t.Errorf("expected %q under the renamed category, got %q", stored.Title, stored.Category)
+			}
+			stored.Version = 0
+			if err := stored.Update(ctx, 0); err != nil {
+				t.Errorf("expected %q to save under the renamed category, got %v", stored.Title, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"example.com/blog_backend/markdown"
+)
+
+// Comment represents a reader comment attached to a blog post. Content is the
+// Markdown the reader wrote; ContentHTML is its sanitized rendering and the
+// only form that should be inserted into a page.
+//
+// Version starts at 1 and goes up by one whenever the content is edited.
+type Comment struct {
+	ID          string    `json:"id"`
+	PostID      int64     `json:"post_id"`
+	UserID      int64     `json:"user_id"`
+	AuthorName  string    `json:"author_name"`
+	Content     string    `json:"content"`
+	ContentHTML string    `json:"content_html"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int64     `json:"version"`
+	// RenderVersion is the markdown.CommentVersion ContentHTML was rendered
+	// with; 0 for comments stored before comments were rendered.
+	RenderVersion int `json:"-"`
+}
+
+// deletedUserAuthorName replaces the author name on comments whose owner has
+// been deleted.
+const deletedUserAuthorName = "Deleted user"
+
+// CreateComment creates a new comment for the given post and user and
+// increments the post's aggregate comments_count.
+func CreateComment(ctx context.Context, postID, userID int64, authorName, content string) (*Comment, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	comment, err := store().CreateComment(ctx, postID, userID, authorName, content, markdown.RenderComment(content))
+	return comment, storeError(ctx, err)
+}
+
+// GetCommentsForPost returns all comments for a post ordered by creation time
+// (oldest first). The API layer is responsible for choosing how many to
+// display.
+func GetCommentsForPost(ctx context.Context, postID int64) ([]Comment, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	comments, err := store().ListCommentsForPost(ctx, postID)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	for i := range comments {
+		rerenderComment(ctx, &comments[i])
+	}
+	return comments, nil
+}
+
+// GetCommentByID fetches a single comment by its ID.
+func GetCommentByID(ctx context.Context, id string) (*Comment, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	comment, err := store().GetComment(ctx, id)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	rerenderComment(ctx, comment)
+	return comment, nil
+}
+
+// rerenderComment renders a comment whose ContentHTML is missing or comes
+// from an older markdown.CommentVersion, and best-effort stores the result so
+// the next read does not have to.
+func rerenderComment(ctx context.Context, c *Comment) {
+	if c.RenderVersion == markdown.CommentVersion {
+		return
+	}
+	c.ContentHTML = markdown.RenderComment(c.Content)
+	c.RenderVersion = markdown.CommentVersion
+	_ = store().SetCommentHTML(ctx, c.ID, c.Content, c.ContentHTML)
+}
+
+// UpdateCommentContent updates the content of a comment owned by the given
+// user. A non-zero version is the version the caller expects the comment to
+// have; when it has changed since, ErrVersionConflict is returned and the
+// comment is left alone.
+func UpdateCommentContent(ctx context.Context, id string, userID, version int64, newContent string) (*Comment, error) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	comment, err := store().UpdateCommentContent(ctx, id, userID, version, newContent, markdown.RenderComment(newContent))
+	return comment, storeError(ctx, err)
+}
+
+// DeleteComment removes a comment by ID and decrements the owning post's
+// aggregate comments_count.
+func DeleteComment(ctx context.Context, id string, postID int64) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().DeleteComment(ctx, id, postID))
+}
+
+// AnonymizeCommentsForUser replaces the user reference on all comments owned by
+// the given user with a generic "Deleted user" label while keeping the
+// comment content intact.
+func AnonymizeCommentsForUser(ctx context.Context, userID int64) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().AnonymizeCommentsForUser(ctx, userID))
+}
@@ -8,56 +8,59 @@ import (
 	"time"
 
 	"cloud.google.com/go/firestore"
+	"example.com/blog_backend/markdown"
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-
-	"example.com/blog_backend/db"
 )
 
-// Comment represents a reader comment attached to a blog post.
-type Comment struct {
-	ID         string    `json:"id"`
-	PostID     int64     `json:"post_id"`
-	UserID     int64     `json:"user_id"`
-	AuthorName string    `json:"author_name"`
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-}
-
 // firestoreCommentDoc is the Firestore representation of a Comment document.
 type firestoreCommentDoc struct {
-	PostID     int64     `firestore:"post_id"`
-	UserID     int64     `firestore:"user_id"`
-	AuthorName string    `firestore:"author_name"`
-	Content    string    `firestore:"content"`
-	CreatedAt  time.Time `firestore:"created_at"`
-	UpdatedAt  time.Time `firestore:"updated_at"`
+	PostID        int64     `firestore:"post_id"`
+	UserID        int64     `firestore:"user_id"`
+	AuthorName    string    `firestore:"author_name"`
+	Content       string    `firestore:"content"`
+	ContentHTML   string    `firestore:"content_html"`
+	RenderVersion int       `firestore:"render_version"`
+	Version       int64     `firestore:"version"`
+	CreatedAt     time.Time `firestore:"created_at"`
+	UpdatedAt     time.Time `firestore:"updated_at"`
 }
 
-func postCommentsCollection() *firestore.CollectionRef {
-	if db.FirestoreClient == nil {
-		panic("Firestore client is not initialized")
+// toComment returns the comment stored in document id. Documents written
+// before comments had versions are at version 1.
+func (d firestoreCommentDoc) toComment(id string) Comment {
+	return Comment{
+		ID:            id,
+		PostID:        d.PostID,
+		UserID:        d.UserID,
+		AuthorName:    d.AuthorName,
+		Content:       d.Content,
+		ContentHTML:   d.ContentHTML,
+		RenderVersion: d.RenderVersion,
+		Version:       max(d.Version, 1),
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
 	}
-	return db.FirestoreClient.Collection("post_comments")
 }
 
 // CreateComment creates a new comment document for the given post and user.
-func CreateComment(postID, userID int64, authorName, content string) (*Comment, error) {
-	ctx := context.Background()
+func (s *FirestoreStore) CreateComment(ctx context.Context, postID, userID int64, authorName, content, contentHTML string) (*Comment, error) {
 	now := time.Now()
 
 	doc := firestoreCommentDoc{
-		PostID:     postID,
-		UserID:     userID,
-		AuthorName: authorName,
-		Content:    content,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		PostID:        postID,
+		UserID:        userID,
+		AuthorName:    authorName,
+		Content:       content,
+		ContentHTML:   contentHTML,
+		RenderVersion: markdown.CommentVersion,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 
-	ref, _, err := postCommentsCollection().Add(ctx, doc)
+	ref, _, err := s.postCommentsCollection().Add(ctx, doc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
@@ -66,33 +69,24 @@ func CreateComment(postID, userID int64, authorName, content string) (*Comment,
 	// If this update fails, we keep the comment but the counter may be briefly
 	// out of sync until the next write or a manual backfill.
 	if postID > 0 {
-		_, _ = postsCollection().Doc(strconv.FormatInt(postID, 10)).Update(ctx, []firestore.Update{
+		_, _ = s.postsCollection().Doc(strconv.FormatInt(postID, 10)).Update(ctx, []firestore.Update{
 			{Path: "comments_count", Value: firestore.Increment(1)},
 		})
 	}
 
-	return &Comment{
-		ID:         ref.ID,
-		PostID:     postID,
-		UserID:     userID,
-		AuthorName: authorName,
-		Content:    content,
-		CreatedAt:  now,
-		UpdatedAt:  now,
-	}, nil
+	comment := doc.toComment(ref.ID)
+	return &comment, nil
 }
 
-// GetCommentsForPost returns all comments for a post ordered by creation time
-// (oldest first). The API layer is responsible for choosing how many to
-// display.
-func GetCommentsForPost(postID int64) ([]Comment, error) {
-	ctx := context.Background()
-	col := postCommentsCollection()
-
-		// Use a simple equality filter and perform the ordering in memory. This
-		// avoids requiring a composite Firestore index on (post_id, created_at)
-		// while still returning comments in ascending creation time.
-		iter := col.Where("post_id", "==", postID).Documents(ctx)
+// ListCommentsForPost returns all comments for a post ordered by creation
+// time (oldest first).
+func (s *FirestoreStore) ListCommentsForPost(ctx context.Context, postID int64) ([]Comment, error) {
+	col := s.postCommentsCollection()
+
+	// Use a simple equality filter and perform the ordering in memory. This
+	// avoids requiring a composite Firestore index on (post_id, created_at)
+	// while still returning comments in ascending creation time.
+	iter := col.Where("post_id", "==", postID).Documents(ctx)
 	defer iter.Stop()
 
 	var comments []Comment
@@ -110,29 +104,20 @@ func GetCommentsForPost(postID int64) ([]Comment, error) {
 			return nil, fmt.Errorf("failed to decode comment document: %w", err)
 		}
 
-		comments = append(comments, Comment{
-			ID:         doc.Ref.ID,
-			PostID:     data.PostID,
-			UserID:     data.UserID,
-			AuthorName: data.AuthorName,
-			Content:    data.Content,
-			CreatedAt:  data.CreatedAt,
-			UpdatedAt:  data.UpdatedAt,
-		})
+		comments = append(comments, data.toComment(doc.Ref.ID))
 	}
 
-		// Oldest first.
-		sort.Slice(comments, func(i, j int) bool {
-			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
-		})
+	// Oldest first.
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
 
-		return comments, nil
+	return comments, nil
 }
 
-// GetCommentByID fetches a single comment document by its Firestore ID.
-func GetCommentByID(id string) (*Comment, error) {
-	ctx := context.Background()
-	doc, err := postCommentsCollection().Doc(id).Get(ctx)
+// GetComment fetches a single comment document by its Firestore ID.
+func (s *FirestoreStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	doc, err := s.postCommentsCollection().Doc(id).Get(ctx)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return nil, ErrCommentNotFound
@@ -145,69 +130,95 @@ func GetCommentByID(id string) (*Comment, error) {
 		return nil, fmt.Errorf("failed to decode comment document: %w", err)
 	}
 
-	return &Comment{
-		ID:         doc.Ref.ID,
-		PostID:     data.PostID,
-		UserID:     data.UserID,
-		AuthorName: data.AuthorName,
-		Content:    data.Content,
-		CreatedAt:  data.CreatedAt,
-		UpdatedAt:  data.UpdatedAt,
-	}, nil
+	comment := data.toComment(doc.Ref.ID)
+	return &comment, nil
 }
 
 // UpdateCommentContent updates the content of a comment owned by the given
-// user.
-func UpdateCommentContent(id string, userID int64, newContent string) (*Comment, error) {
-	ctx := context.Background()
-	ref := postCommentsCollection().Doc(id)
+// user. The owner and version are checked in the transaction that writes
+// the new content.
+func (s *FirestoreStore) UpdateCommentContent(ctx context.Context, id string, userID, version int64, newContent, newContentHTML string) (*Comment, error) {
+	ref := s.postCommentsCollection().Doc(id)
+
+	var data firestoreCommentDoc
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		data = firestoreCommentDoc{}
+		if err := snap.DataTo(&data); err != nil {
+			return fmt.Errorf("failed to decode comment document: %w", err)
+		}
+
+		if data.UserID != userID {
+			return ErrUnauthorizedCommentAction
+		}
+		data.Version = max(data.Version, 1)
+		if err := checkVersion(version, data.Version); err != nil {
+			return err
+		}
 
-	snap, err := ref.Get(ctx)
+		data.Content = newContent
+		data.ContentHTML = newContentHTML
+		data.RenderVersion = markdown.CommentVersion
+		data.UpdatedAt = time.Now()
+		data.Version++
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "content", Value: data.Content},
+			{Path: "content_html", Value: data.ContentHTML},
+			{Path: "render_version", Value: data.RenderVersion},
+			{Path: "updated_at", Value: data.UpdatedAt},
+			{Path: "version", Value: data.Version},
+		})
+	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return nil, ErrCommentNotFound
 		}
-		return nil, fmt.Errorf("failed to get comment: %w", err)
-	}
-
-	var data firestoreCommentDoc
-	if err := snap.DataTo(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode comment document: %w", err)
+		return nil, fmt.Errorf("failed to update comment: %w", err)
 	}
 
-	if data.UserID != userID {
-		return nil, ErrUnauthorizedCommentAction
-	}
+	comment := data.toComment(ref.ID)
+	return &comment, nil
+}
 
-	data.Content = newContent
-	data.UpdatedAt = time.Now()
+// SetCommentHTML replaces the rendered HTML of a comment whose content is
+// still content, checked in a transaction.
+func (s *FirestoreStore) SetCommentHTML(ctx context.Context, id, content, contentHTML string) error {
+	ref := s.postCommentsCollection().Doc(id)
 
-	if _, err := ref.Update(ctx, []firestore.Update{
-		{Path: "content", Value: data.Content},
-		{Path: "updated_at", Value: data.UpdatedAt},
-	}); err != nil {
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var data firestoreCommentDoc
+		if err := snap.DataTo(&data); err != nil {
+			return fmt.Errorf("failed to decode comment document: %w", err)
+		}
+		if data.Content != content {
+			return nil
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: "content_html", Value: contentHTML},
+			{Path: "render_version", Value: markdown.CommentVersion},
+		})
+	})
+	if err != nil {
 		if status.Code(err) == codes.NotFound {
-			return nil, ErrCommentNotFound
+			return ErrCommentNotFound
 		}
-		return nil, fmt.Errorf("failed to update comment: %w", err)
+		return fmt.Errorf("failed to store comment html: %w", err)
 	}
-
-	return &Comment{
-		ID:         ref.ID,
-		PostID:     data.PostID,
-		UserID:     data.UserID,
-		AuthorName: data.AuthorName,
-		Content:    data.Content,
-		CreatedAt:  data.CreatedAt,
-		UpdatedAt:  data.UpdatedAt,
-	}, nil
+	return nil
 }
 
 // DeleteComment removes a comment document by ID and best-effort decrements
 // the owning post's aggregate comments_count.
-func DeleteComment(id string, postID int64) error {
-	ctx := context.Background()
-	if _, err := postCommentsCollection().Doc(id).Delete(ctx); err != nil {
+func (s *FirestoreStore) DeleteComment(ctx context.Context, id string, postID int64) error {
+	if _, err := s.postCommentsCollection().Doc(id).Delete(ctx); err != nil {
 		if status.Code(err) == codes.NotFound {
 			return ErrCommentNotFound
 		}
@@ -216,19 +227,17 @@ func DeleteComment(id string, postID int64) error {
 
 	// Best-effort decrement of the aggregate comments_count on the parent post.
 	if postID > 0 {
-		_, _ = postsCollection().Doc(strconv.FormatInt(postID, 10)).Update(ctx, []firestore.Update{
+		_, _ = s.postsCollection().Doc(strconv.FormatInt(postID, 10)).Update(ctx, []firestore.Update{
 			{Path: "comments_count", Value: firestore.Increment(-1)},
 		})
 	}
 	return nil
 }
 
-// AnonymizeCommentsForUser replaces the user reference on all comments owned by
-// the given user with a generic "Deleted user" label while keeping the
-// comment content intact.
-func AnonymizeCommentsForUser(userID int64) error {
-	ctx := context.Background()
-	col := postCommentsCollection()
+// AnonymizeCommentsForUser detaches every comment owned by the given user
+// from their account.
+func (s *FirestoreStore) AnonymizeCommentsForUser(ctx context.Context, userID int64) error {
+	col := s.postCommentsCollection()
 
 	iter := col.Where("user_id", "==", userID).Documents(ctx)
 	defer iter.Stop()
@@ -244,7 +253,7 @@ func AnonymizeCommentsForUser(userID int64) error {
 
 		if _, err := doc.Ref.Update(ctx, []firestore.Update{
 			{Path: "user_id", Value: int64(0)},
-			{Path: "author_name", Value: "Deleted user"},
+			{Path: "author_name", Value: deletedUserAuthorName},
 		}); err != nil {
 			return fmt.Errorf("failed to anonymize comment: %w", err)
 		}
@@ -252,4 +261,3 @@ func AnonymizeCommentsForUser(userID int64) error {
 
 	return nil
 }
-
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"example.com/blog_backend/markdown"
+)
+
+const sqliteCommentColumns = `id, post_id, user_id, author_name, content, content_html, render_version, version, created_at, updated_at`
+
+func scanSQLiteComment(row rowScanner) (Comment, error) {
+	var c Comment
+	var id int64
+	err := row.Scan(&id, &c.PostID, &c.UserID, &c.AuthorName, &c.Content, &c.ContentHTML, &c.RenderVersion, &c.Version, &c.CreatedAt, &c.UpdatedAt)
+	c.ID = strconv.FormatInt(id, 10)
+	return c, err
+}
+
+// parseSQLiteCommentID converts an API comment ID back to the row ID. IDs
+// that are not numeric cannot exist in SQLite and report ErrCommentNotFound.
+func parseSQLiteCommentID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, ErrCommentNotFound
+	}
+	return n, nil
+}
+
+// CreateComment inserts a comment and increments the post's comments_count in
+// the same transaction.
+func (s *SQLiteStore) CreateComment(ctx context.Context, postID, userID int64, authorName, content, contentHTML string) (*Comment, error) {
+	now := time.Now()
+	comment := &Comment{
+		PostID:        postID,
+		UserID:        userID,
+		AuthorName:    authorName,
+		Content:       content,
+		ContentHTML:   contentHTML,
+		RenderVersion: markdown.CommentVersion,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO post_comments (post_id, user_id, author_name, content, content_html, render_version, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			postID, userID, authorName, content, contentHTML, comment.RenderVersion, now, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to read new comment id: %w", err)
+		}
+		comment.ID = strconv.FormatInt(id, 10)
+
+		if _, err := tx.ExecContext(ctx, `UPDATE posts SET comments_count = comments_count + 1 WHERE id = ?`, postID); err != nil {
+			return fmt.Errorf("failed to increment comments count: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// ListCommentsForPost returns all comments for a post, oldest first.
+func (s *SQLiteStore) ListCommentsForPost(ctx context.Context, postID int64) ([]Comment, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteCommentColumns+` FROM post_comments WHERE post_id = ? ORDER BY created_at ASC, id ASC`, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		c, err := scanSQLiteComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode comment row: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// GetComment fetches a single comment by ID.
+func (s *SQLiteStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	rowID, err := parseSQLiteCommentID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := scanSQLiteComment(s.db.QueryRowContext(ctx, `SELECT `+sqliteCommentColumns+` FROM post_comments WHERE id = ?`, rowID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	return &c, nil
+}
+
+// UpdateCommentContent updates the content of a comment owned by the given
+// user, checking its version in the same transaction.
+func (s *SQLiteStore) UpdateCommentContent(ctx context.Context, id string, userID, version int64, newContent, newContentHTML string) (*Comment, error) {
+	rowID, err := parseSQLiteCommentID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment Comment
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		c, err := scanSQLiteComment(tx.QueryRowContext(ctx, `SELECT `+sqliteCommentColumns+` FROM post_comments WHERE id = ?`, rowID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get comment: %w", err)
+		}
+
+		if c.UserID != userID {
+			return ErrUnauthorizedCommentAction
+		}
+		if err := checkVersion(version, c.Version); err != nil {
+			return err
+		}
+
+		c.Content = newContent
+		c.ContentHTML = newContentHTML
+		c.RenderVersion = markdown.CommentVersion
+		c.UpdatedAt = time.Now()
+		c.Version++
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE post_comments SET content = ?, content_html = ?, render_version = ?, updated_at = ?, version = ? WHERE id = ?`,
+			c.Content, c.ContentHTML, c.RenderVersion, c.UpdatedAt, c.Version, rowID,
+		); err != nil {
+			return fmt.Errorf("failed to update comment: %w", err)
+		}
+
+		comment = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// SetCommentHTML replaces the rendered HTML of a comment whose content is
+// still content.
+func (s *SQLiteStore) SetCommentHTML(ctx context.Context, id, content, contentHTML string) error {
+	rowID, err := parseSQLiteCommentID(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE post_comments SET content_html = ?, render_version = ? WHERE id = ? AND content = ?`,
+		contentHTML, markdown.CommentVersion, rowID, content,
+	); err != nil {
+		return fmt.Errorf("failed to store comment html: %w", err)
+	}
+	return nil
+}
+
+// DeleteComment removes a comment and decrements the post's comments_count in
+// the same transaction.
+func (s *SQLiteStore) DeleteComment(ctx context.Context, id string, postID int64) error {
+	rowID, err := parseSQLiteCommentID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM post_comments WHERE id = ?`, rowID)
+		if err != nil {
+			return fmt.Errorf("failed to delete comment: %w", err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return ErrCommentNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE posts SET comments_count = comments_count - 1 WHERE id = ?`, postID); err != nil {
+			return fmt.Errorf("failed to decrement comments count: %w", err)
+		}
+		return nil
+	})
+}
+
+// AnonymizeCommentsForUser detaches every comment owned by the given user
+// from their account.
+func (s *SQLiteStore) AnonymizeCommentsForUser(ctx context.Context, userID int64) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE post_comments SET user_id = 0, author_name = ? WHERE user_id = ?`, deletedUserAuthorName, userID); err != nil {
+		return fmt.Errorf("failed to anonymize comments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/blog_backend/markdown"
+)
+
+// Comments are rendered when written, and comments stored without a current
+// rendering are rendered, and the result stored, when they are next read.
+func TestCommentContentHTML(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		post := &Post{Title: "Comments", Content: "Body"}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+
+		comment, err := CreateComment(ctx, post.ID, 1, "reader", "*Hi* <b>there</b>")
+		if err != nil {
+			t.Fatalf("failed to create comment: %v", err)
+		}
+		if want := "<p><em>Hi</em> &lt;b&gt;there&lt;/b&gt;</p>\n"; comment.ContentHTML != want {
+			t.Errorf("expected content_html %q, got %q", want, comment.ContentHTML)
+		}
+
+		updated, err := UpdateCommentContent(ctx, comment.ID, 1, 0, "**Bye**")
+		if err != nil {
+			t.Fatalf("failed to update comment: %v", err)
+		}
+		if want := "<p><strong>Bye</strong></p>\n"; updated.ContentHTML != want {
+			t.Errorf("expected content_html %q after update, got %q", want, updated.ContentHTML)
+		}
+
+		now := time.Now()
+		legacy := Comment{ID: "100", PostID: post.ID, UserID: 1, AuthorName: "reader", Content: "_old_", CreatedAt: now, UpdatedAt: now}
+		if err := store().ImportComment(ctx, legacy); err != nil {
+			t.Fatalf("failed to import comment: %v", err)
+		}
+
+		comments, err := GetCommentsForPost(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("failed to list comments: %v", err)
+		}
+		if len(comments) != 2 || comments[1].ContentHTML != "<p><em>old</em></p>\n" {
+			t.Fatalf("expected the old comment to be rendered on read, got %+v", comments)
+		}
+
+		stored, err := store().GetComment(ctx, legacy.ID)
+		if err != nil {
+			t.Fatalf("failed to load comment: %v", err)
+		}
+		if stored.ContentHTML != comments[1].ContentHTML || stored.RenderVersion != markdown.CommentVersion {
+			t.Errorf("expected the rendering to be stored, got %q at version %d", stored.ContentHTML, stored.RenderVersion)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// Reasons reported for orphaned reactions and comments.
+const (
+	OrphanPostMissing = "post_missing"
+	OrphanUserMissing = "user_missing"
+)
+
+// PostCounters holds the aggregate counters stored on a post.
+type PostCounters struct {
+	Likes    int64 `json:"likes"`
+	Dislikes int64 `json:"dislikes"`
+	Comments int64 `json:"comments"`
+}
+
+// CounterMismatch is a post whose stored counters differ from the ones
+// recomputed from post_reactions and post_comments.
+type CounterMismatch struct {
+	PostID int64        `json:"post_id"`
+	Stored PostCounters `json:"stored"`
+	Actual PostCounters `json:"actual"`
+}
+
+// OrphanedReaction is a reaction whose post or user no longer exists.
+type OrphanedReaction struct {
+	PostReaction
+	Reason string `json:"reason"`
+}
+
+// OrphanedComment is a comment whose post no longer exists, or that still
+// points at a deleted user instead of being anonymized.
+type OrphanedComment struct {
+	CommentID string `json:"comment_id"`
+	PostID    int64  `json:"post_id"`
+	UserID    int64  `json:"user_id"`
+	Reason    string `json:"reason"`
+}
+
+// ConsistencyReport lists every difference found by CheckConsistency.
+type ConsistencyReport struct {
+	PostsChecked      int                `json:"posts_checked"`
+	CommentsChecked   int                `json:"comments_checked"`
+	ReactionsChecked  int                `json:"reactions_checked"`
+	CounterMismatches []CounterMismatch  `json:"counter_mismatches"`
+	OrphanedReactions []OrphanedReaction `json:"orphaned_reactions"`
+	OrphanedComments  []OrphanedComment  `json:"orphaned_comments"`
+	Repaired          bool               `json:"repaired"`
+}
+
+// Clean reports whether no problems were found.
+func (r *ConsistencyReport) Clean() bool {
+	return len(r.CounterMismatches) == 0 && len(r.OrphanedReactions) == 0 && len(r.OrphanedComments) == 0
+}
+
+// CheckConsistency recomputes every post's like, dislike and comment counters
+// from the stored reactions and comments and looks for reactions and comments
+// whose post or user is gone.
+//
+// With repair set it also fixes what it found: orphaned reactions and
+// comments on deleted posts are removed, comments of deleted users are
+// anonymized, and mismatched counters are overwritten with the recomputed
+// values. Counters are written as absolute values, so a reaction or comment
+// arriving while the repair runs can leave its post off by one until the next
+// check.
+//
+// The check reads every post, user, comment and reaction, so it is bounded
+// only by ctx and not by the per-operation timeouts.
+func CheckConsistency(ctx context.Context, repair bool) (*ConsistencyReport, error) {
+	report, err := checkConsistency(ctx, store(), repair)
+	return report, storeError(ctx, err)
+}
+
+func checkConsistency(ctx context.Context, s Store, repair bool) (*ConsistencyReport, error) {
+	posts, err := s.ListPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.ListAllComments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.ListAllReactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ConsistencyReport{
+		PostsChecked:      len(posts),
+		CommentsChecked:   len(comments),
+		ReactionsChecked:  len(reactions),
+		CounterMismatches: []CounterMismatch{},
+		OrphanedReactions: []OrphanedReaction{},
+		OrphanedComments:  []OrphanedComment{},
+	}
+
+	userExists := make(map[int64]bool, len(users))
+	for _, u := range users {
+		userExists[u.ID] = true
+	}
+	actual := make(map[int64]*PostCounters, len(posts))
+	for _, p := range posts {
+		actual[p.ID] = &PostCounters{}
+	}
+
+	for _, r := range reactions {
+		counters, ok := actual[r.PostID]
+		switch {
+		case !ok:
+			report.OrphanedReactions = append(report.OrphanedReactions, OrphanedReaction{PostReaction: r, Reason: OrphanPostMissing})
+		case !userExists[r.UserID]:
+			report.OrphanedReactions = append(report.OrphanedReactions, OrphanedReaction{PostReaction: r, Reason: OrphanUserMissing})
+		case r.Reaction == ReactionLike:
+			counters.Likes++
+		case r.Reaction == ReactionDislike:
+			counters.Dislikes++
+		}
+	}
+
+	for _, c := range comments {
+		counters, ok := actual[c.PostID]
+		if !ok {
+			report.OrphanedComments = append(report.OrphanedComments, OrphanedComment{
+				CommentID: c.ID, PostID: c.PostID, UserID: c.UserID, Reason: OrphanPostMissing,
+			})
+			continue
+		}
+
+		// Comments of deleted users stay on the post once anonymized, so
+		// they still count towards comments_count.
+		counters.Comments++
+		if c.UserID != 0 && !userExists[c.UserID] {
+			report.OrphanedComments = append(report.OrphanedComments, OrphanedComment{
+				CommentID: c.ID, PostID: c.PostID, UserID: c.UserID, Reason: OrphanUserMissing,
+			})
+		}
+	}
+
+	for _, p := range posts {
+		stored := PostCounters{Likes: p.LikesCount, Dislikes: p.DislikesCount, Comments: p.CommentsCount}
+		if stored != *actual[p.ID] {
+			report.CounterMismatches = append(report.CounterMismatches, CounterMismatch{
+				PostID: p.ID, Stored: stored, Actual: *actual[p.ID],
+			})
+		}
+	}
+	sort.Slice(report.CounterMismatches, func(i, j int) bool {
+		return report.CounterMismatches[i].PostID < report.CounterMismatches[j].PostID
+	})
+
+	if repair {
+		if err := repairConsistency(ctx, s, report); err != nil {
+			return report, err
+		}
+		report.Repaired = true
+	}
+
+	return report, nil
+}
+
+// repairConsistency removes the orphans listed in report and then writes the
+// recomputed counters.
+func repairConsistency(ctx context.Context, s Store, report *ConsistencyReport) error {
+	for _, r := range report.OrphanedReactions {
+		if err := s.DeleteReaction(ctx, r.UserID, r.PostID); err != nil {
+			return err
+		}
+	}
+
+	anonymized := make(map[int64]bool)
+	for _, c := range report.OrphanedComments {
+		switch c.Reason {
+		case OrphanPostMissing:
+			if err := s.DeleteComment(ctx, c.CommentID, c.PostID); err != nil && !errors.Is(err, ErrCommentNotFound) {
+				return err
+			}
+		case OrphanUserMissing:
+			if anonymized[c.UserID] {
+				continue
+			}
+			if err := s.AnonymizeCommentsForUser(ctx, c.UserID); err != nil {
+				return err
+			}
+			anonymized[c.UserID] = true
+		}
+	}
+
+	for _, m := range report.CounterMismatches {
+		if err := s.SetPostCounters(ctx, m.PostID, m.Actual); err != nil && !errors.Is(err, ErrPostNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListAllComments returns every comment document.
+func (s *FirestoreStore) ListAllComments(ctx context.Context) ([]Comment, error) {
+	iter := s.postCommentsCollection().Documents(ctx)
+	defer iter.Stop()
+
+	var comments []Comment
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate comments: %w", err)
+		}
+
+		var data firestoreCommentDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode comment document: %w", err)
+		}
+		comments = append(comments, data.toComment(doc.Ref.ID))
+	}
+	return comments, nil
+}
+
+// ListAllReactions returns every reaction document.
+func (s *FirestoreStore) ListAllReactions(ctx context.Context) ([]PostReaction, error) {
+	iter := s.postReactionsCollection().Documents(ctx)
+	defer iter.Stop()
+
+	var reactions []PostReaction
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate reactions: %w", err)
+		}
+
+		var data firestorePostReactionDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode reaction document: %w", err)
+		}
+		reactions = append(reactions, PostReaction{UserID: data.UserID, PostID: data.PostID, Reaction: data.Reaction})
+	}
+	return reactions, nil
+}
+
+// SetPostCounters overwrites the aggregate counters of a post.
+func (s *FirestoreStore) SetPostCounters(ctx context.Context, postID int64, counters PostCounters) error {
+	_, err := s.postsCollection().Doc(strconv.FormatInt(postID, 10)).Update(ctx, []firestore.Update{
+		{Path: "likes_count", Value: counters.Likes},
+		{Path: "dislikes_count", Value: counters.Dislikes},
+		{Path: "comments_count", Value: counters.Comments},
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("failed to update post counters: %w", err)
+	}
+	return nil
+}
+
+// DeleteReaction removes post_reactions/<userID>_<postID> without touching
+// the post counters.
+func (s *FirestoreStore) DeleteReaction(ctx context.Context, userID, postID int64) error {
+	if _, err := s.postReactionsCollection().Doc(fmt.Sprintf("%d_%d", userID, postID)).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete reaction: %w", err)
+	}
+	return nil
+}
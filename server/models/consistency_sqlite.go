@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListAllComments returns every stored comment ordered by ID.
+func (s *SQLiteStore) ListAllComments(ctx context.Context) ([]Comment, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteCommentColumns+` FROM post_comments ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		c, err := scanSQLiteComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode comment row: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate comments: %w", err)
+	}
+	return comments, nil
+}
+
+// ListAllReactions returns every stored reaction.
+func (s *SQLiteStore) ListAllReactions(ctx context.Context) ([]PostReaction, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id, post_id, reaction FROM post_reactions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reactions: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []PostReaction
+	for rows.Next() {
+		var r PostReaction
+		if err := rows.Scan(&r.UserID, &r.PostID, &r.Reaction); err != nil {
+			return nil, fmt.Errorf("failed to decode reaction row: %w", err)
+		}
+		reactions = append(reactions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reactions: %w", err)
+	}
+	return reactions, nil
+}
+
+// SetPostCounters overwrites the aggregate counters of a post.
+func (s *SQLiteStore) SetPostCounters(ctx context.Context, postID int64, counters PostCounters) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE posts SET likes_count = ?, dislikes_count = ?, comments_count = ? WHERE id = ?`,
+		counters.Likes, counters.Dislikes, counters.Comments, postID)
+	if err != nil {
+		return fmt.Errorf("failed to update post counters: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+// DeleteReaction removes a reaction without touching the post counters.
+func (s *SQLiteStore) DeleteReaction(ctx context.Context, userID, postID int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM post_reactions WHERE user_id = ? AND post_id = ?`, userID, postID); err != nil {
+		return fmt.Errorf("failed to delete reaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// CheckConsistency finds drifted counters and orphaned reactions and
+// comments, and a repair leaves nothing for the next check to report.
+func TestCheckConsistencyFindsAndRepairsProblems(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		admin := &User{Username: "admin", Password: "testpassword"}
+		if err := admin.Save(ctx); err != nil {
+			t.Fatalf("failed to create admin: %v", err)
+		}
+		reader := &User{Username: "reader", Password: "testpassword"}
+		if err := reader.Save(ctx); err != nil {
+			t.Fatalf("failed to create reader: %v", err)
+		}
+
+		post := &Post{Title: "Post", Content: "Body", AuthorID: admin.ID}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		if _, err := SetPostReaction(ctx, admin.ID, post.ID, ReactionLike); err != nil {
+			t.Fatalf("failed to like post: %v", err)
+		}
+		if _, err := SetPostReaction(ctx, reader.ID, post.ID, ReactionDislike); err != nil {
+			t.Fatalf("failed to dislike post: %v", err)
+		}
+		if _, err := CreateComment(ctx, post.ID, admin.ID, admin.Username, "First"); err != nil {
+			t.Fatalf("failed to create comment: %v", err)
+		}
+
+		// Deleting the reader leaves their dislike behind.
+		if err := DeleteUser(ctx, reader.ID); err != nil {
+			t.Fatalf("failed to delete reader: %v", err)
+		}
+
+		now := time.Now()
+		if err := ImportReaction(ctx, admin.ID, 999, ReactionLike); err != nil {
+			t.Fatalf("failed to import orphaned reaction: %v", err)
+		}
+		if err := ImportComment(ctx, Comment{ID: "9001", PostID: 999, UserID: admin.ID, AuthorName: "admin", Content: "Lost", CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to import orphaned comment: %v", err)
+		}
+		if err := ImportComment(ctx, Comment{ID: "9002", PostID: post.ID, UserID: 42, AuthorName: "ghost", Content: "Boo", CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to import comment of missing user: %v", err)
+		}
+		if err := store().SetPostCounters(ctx, post.ID, PostCounters{Likes: 7, Dislikes: 1, Comments: 1}); err != nil {
+			t.Fatalf("failed to corrupt counters: %v", err)
+		}
+
+		report, err := CheckConsistency(ctx, false)
+		if err != nil {
+			t.Fatalf("CheckConsistency failed: %v", err)
+		}
+		if report.Repaired || report.Clean() {
+			t.Fatalf("expected an unrepaired report with problems, got %+v", report)
+		}
+
+		want := CounterMismatch{
+			PostID: post.ID,
+			Stored: PostCounters{Likes: 7, Dislikes: 1, Comments: 1},
+			Actual: PostCounters{Likes: 1, Dislikes: 0, Comments: 2},
+		}
+		if len(report.CounterMismatches) != 1 || report.CounterMismatches[0] != want {
+			t.Fatalf("expected counter mismatch %+v, got %+v", want, report.CounterMismatches)
+		}
+
+		reasons := map[string]int{}
+		for _, r := range report.OrphanedReactions {
+			reasons["reaction/"+r.Reason]++
+		}
+		for _, c := range report.OrphanedComments {
+			reasons["comment/"+c.Reason]++
+		}
+		for _, key := range []string{
+			"reaction/" + OrphanPostMissing,
+			"reaction/" + OrphanUserMissing,
+			"comment/" + OrphanPostMissing,
+			"comment/" + OrphanUserMissing,
+		} {
+			if reasons[key] != 1 {
+				t.Errorf("expected one %s orphan, got %v", key, reasons)
+			}
+		}
+
+		// The check alone must not change anything.
+		got, err := GetPostByID(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("failed to reload post: %v", err)
+		}
+		if got.LikesCount != 7 {
+			t.Fatalf("expected check without repair to leave counters alone, got %d likes", got.LikesCount)
+		}
+
+		report, err = CheckConsistency(ctx, true)
+		if err != nil {
+			t.Fatalf("repair failed: %v", err)
+		}
+		if !report.Repaired {
+			t.Fatalf("expected report to be marked repaired")
+		}
+
+		report, err = CheckConsistency(ctx, false)
+		if err != nil {
+			t.Fatalf("CheckConsistency after repair failed: %v", err)
+		}
+		if !report.Clean() {
+			t.Fatalf("expected no problems after repair, got %+v", report)
+		}
+
+		got, err = GetPostByID(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("failed to reload post: %v", err)
+		}
+		if got.LikesCount != 1 || got.DislikesCount != 0 || got.CommentsCount != 2 {
+			t.Fatalf("unexpected counters after repair: %+v", got)
+		}
+
+		ghost, err := GetCommentByID(ctx, "9002")
+		if err != nil {
+			t.Fatalf("failed to reload comment of missing user: %v", err)
+		}
+		if ghost.UserID != 0 || ghost.AuthorName != deletedUserAuthorName {
+			t.Fatalf("expected comment of missing user to be anonymized, got %+v", ghost)
+		}
+		if _, err := GetCommentByID(ctx, "9001"); !errors.Is(err, ErrCommentNotFound) {
+			t.Fatalf("expected comment on missing post to be deleted, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firestoreCounterDoc stores the last numeric ID handed out for a collection.
+// Counter documents live in the "counters" collection, keyed by the name of
+// the collection they number ("posts", "users").
+type firestoreCounterDoc struct {
+	LastID int64 `firestore:"last_id"`
+}
+
+// allocateID reserves the next numeric ID for col inside tx. Every caller
+// reads and writes the same counter document, so Firestore serializes
+// concurrent allocations and retries the losers instead of handing out the
+// same ID twice.
+//
+// When the counter document does not exist yet (for example on a database
+// created before counters were introduced), it is seeded from the highest
+// "id" currently stored in col.
+//
+// Firestore requires all transactional reads to happen before any write, so
+// callers must perform their own reads before calling allocateID.
+func (s *FirestoreStore) allocateID(tx *firestore.Transaction, col *firestore.CollectionRef) (int64, error) {
+	counterRef := s.collection("counters").Doc(col.ID)
+
+	var lastID int64
+	snap, err := tx.Get(counterRef)
+	switch {
+	case status.Code(err) == codes.NotFound:
+		lastID, err = maxStoredID(tx, col)
+		if err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, fmt.Errorf("failed to read %s id counter: %w", col.ID, err)
+	default:
+		var counter firestoreCounterDoc
+		if err := snap.DataTo(&counter); err != nil {
+			return 0, fmt.Errorf("failed to decode %s id counter: %w", col.ID, err)
+		}
+		lastID = counter.LastID
+	}
+
+	nextID := lastID + 1
+	if err := tx.Set(counterRef, firestoreCounterDoc{LastID: nextID}); err != nil {
+		return 0, fmt.Errorf("failed to update %s id counter: %w", col.ID, err)
+	}
+
+	return nextID, nil
+}
+
+// maxStoredID returns the highest "id" field in col, or 0 when it is empty.
+func maxStoredID(tx *firestore.Transaction, col *firestore.CollectionRef) (int64, error) {
+	iter := tx.Documents(col.OrderBy("id", firestore.Desc).Limit(1))
+	defer iter.Stop()
+
+	doc, err := iter.Next()
+	if err == iterator.Done {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last %s id: %w", col.ID, err)
+	}
+
+	id, err := doc.DataAt("id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read last %s id: %w", col.ID, err)
+	}
+	n, ok := id.(int64)
+	if !ok {
+		return 0, fmt.Errorf("last %s id has unexpected type %T", col.ID, id)
+	}
+	return n, nil
+}
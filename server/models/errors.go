@@ -11,8 +11,30 @@ var (
 	// ErrCommentNotFound is returned when a comment document cannot be found.
 	ErrCommentNotFound = errors.New("comment not found")
 
+	// ErrSlugTaken is returned when a post slug already belongs to another
+	// post, either as its current slug or as a previous one.
+	ErrSlugTaken = errors.New("slug already taken")
+
+	// ErrInvalidSlug is returned when a requested slug contains no letters or
+	// digits.
+	ErrInvalidSlug = errors.New("invalid slug")
+
 	// ErrUnauthorizedCommentAction is returned when a user attempts to modify a
 	// comment they do not own.
 	ErrUnauthorizedCommentAction = errors.New("unauthorized comment action")
+
+	// ErrVersionConflict is returned when a post or comment is updated with
+	// an expected version that is no longer the stored one, because someone
+	// else changed it in the meantime.
+	ErrVersionConflict = errors.New("version conflict")
+
+	// ErrTimeout is returned when a storage operation does not finish before
+	// its deadline.
+	ErrTimeout = errors.New("storage operation timed out")
+
+	// ErrUnavailable is returned when the storage backend cannot be reached
+	// or the caller gave up on the operation (for example a client that
+	// disconnected).
+	ErrUnavailable = errors.New("storage backend unavailable")
 )
 
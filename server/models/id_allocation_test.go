@@ -0,0 +1,124 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Concurrent Post.Save calls must each receive a distinct ID, and every post
+// must still be readable afterwards (no silent overwrites).
+func TestConcurrentPostSaveAllocatesUniqueIDs(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		const workers = 20
+
+		var wg sync.WaitGroup
+		ids := make([]int64, workers)
+		errs := make([]error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				post := &Post{Title: fmt.Sprintf("Concurrent post %d", i), Content: "Body"}
+				errs[i] = post.Save(ctx)
+				ids[i] = post.ID
+			}(i)
+		}
+		wg.Wait()
+
+		seen := make(map[int64]bool, workers)
+		for i, id := range ids {
+			if errs[i] != nil {
+				t.Fatalf("Save %d failed: %v", i, errs[i])
+			}
+			if seen[id] {
+				t.Fatalf("post ID %d was allocated twice", id)
+			}
+			seen[id] = true
+
+			got, err := GetPostByID(ctx, id)
+			if err != nil {
+				t.Fatalf("failed to reload post %d: %v", id, err)
+			}
+			if got.Title != fmt.Sprintf("Concurrent post %d", i) {
+				t.Fatalf("post %d was overwritten: got title %q", id, got.Title)
+			}
+		}
+	})
+}
+
+// Concurrent sign-ups must receive distinct IDs, and exactly one of them may
+// win the "first user becomes admin" check.
+func TestConcurrentUserSaveAllocatesUniqueIDsAndOneAdmin(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		const workers = 10
+
+		var wg sync.WaitGroup
+		users := make([]*User, workers)
+		errs := make([]error, workers)
+		for i := 0; i < workers; i++ {
+			users[i] = &User{Username: fmt.Sprintf("concurrent_user_%d", i), Password: "testpassword"}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = users[i].Save(ctx)
+			}(i)
+		}
+		wg.Wait()
+
+		seen := make(map[int64]bool, workers)
+		admins := 0
+		for i, u := range users {
+			if errs[i] != nil {
+				t.Fatalf("Save %d failed: %v", i, errs[i])
+			}
+			if seen[u.ID] {
+				t.Fatalf("user ID %d was allocated twice", u.ID)
+			}
+			seen[u.ID] = true
+			if u.Role == "admin" {
+				admins++
+			}
+		}
+		if admins != 1 {
+			t.Fatalf("expected exactly one admin, got %d", admins)
+		}
+	})
+}
+
+// Signing up twice with the same username at the same time must create only
+// one account.
+func TestConcurrentDuplicateUsernameCreatesOneUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		const workers = 5
+
+		var wg sync.WaitGroup
+		errs := make([]error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				u := &User{Username: "same_name", Password: "testpassword"}
+				errs[i] = u.Save(ctx)
+			}(i)
+		}
+		wg.Wait()
+
+		created := 0
+		for _, err := range errs {
+			if err == nil {
+				created++
+			} else if !errors.Is(err, ErrUserAlreadyExists) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("expected exactly one user to be created, got %d", created)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package models
+
+import "context"
+
+// ImportUser creates or overwrites the user with u.ID, keeping the given
+// password hash and role as-is.
+func ImportUser(ctx context.Context, u User, passwordHash string) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().ImportUser(ctx, u, passwordHash))
+}
+
+// ImportPost creates or overwrites the post with p.ID, including its
+// timestamps and aggregate counters. The excerpt is recomputed from the
+// content. A post without a slug gets one generated from its title, and a
+// slug that belongs to another post is numbered like a generated one.
+// Tags are normalized like in Post.Save, and a post without a version gets
+// version 1.
+func ImportPost(ctx context.Context, p Post) error {
+	tags, err := NormalizeTags(p.Tags)
+	if err != nil {
+		return err
+	}
+	p.Tags = tags
+	p.Excerpt = PostExcerpt(p.Content)
+	p.Version = max(p.Version, 1)
+
+	base := p.Slug
+	if base == "" {
+		base = p.Title
+	}
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	err = assignSlug(ctx, p.ID, base, false, func(slug string) error {
+		p.Slug = slug
+		return store().ImportPost(ctx, p)
+	})
+	if err != nil {
+		return storeError(ctx, err)
+	}
+	indexPost(p)
+	return nil
+}
+
+// ImportPostRevision creates or overwrites revision r.Number of post
+// r.PostID. Later updates of the post number their revisions after it.
+func ImportPostRevision(ctx context.Context, r PostRevision) error {
+	r.Tags = orEmptyTags(r.Tags)
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().ImportPostRevision(ctx, r))
+}
+
+// ImportComment creates or overwrites the comment with c.ID without touching
+// the post's comments_count. A comment without a version gets version 1.
+func ImportComment(ctx context.Context, c Comment) error {
+	c.Version = max(c.Version, 1)
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().ImportComment(ctx, c))
+}
+
+// ImportReaction records a user's reaction on a post without touching the
+// post's like/dislike counters.
+func ImportReaction(ctx context.Context, userID, postID int64, reaction string) error {
+	if reaction != ReactionLike && reaction != ReactionDislike {
+		return ErrInvalidReaction
+	}
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().ImportReaction(ctx, userID, postID, reaction))
+}
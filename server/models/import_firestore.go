@@ -0,0 +1,223 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// raiseCounter makes sure the ID counter for col is at least id, so IDs
+// allocated after an import never collide with imported ones. Like
+// allocateID it reads before it writes, so call it after the transaction's
+// other reads.
+func (s *FirestoreStore) raiseCounter(tx *firestore.Transaction, col *firestore.CollectionRef, id int64) error {
+	counterRef := s.collection("counters").Doc(col.ID)
+
+	snap, err := tx.Get(counterRef)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to read %s id counter: %w", col.ID, err)
+	}
+
+	var counter firestoreCounterDoc
+	if err == nil {
+		if err := snap.DataTo(&counter); err != nil {
+			return fmt.Errorf("failed to decode %s id counter: %w", col.ID, err)
+		}
+	} else {
+		// No counter yet: seed it from the collection so an import into a
+		// non-empty database does not move the counter backwards.
+		if counter.LastID, err = maxStoredID(tx, col); err != nil {
+			return err
+		}
+	}
+	if counter.LastID >= id {
+		return nil
+	}
+
+	if err := tx.Set(counterRef, firestoreCounterDoc{LastID: id}); err != nil {
+		return fmt.Errorf("failed to update %s id counter: %w", col.ID, err)
+	}
+	return nil
+}
+
+// ImportUser creates or overwrites the user document whose "id" is u.ID.
+func (s *FirestoreStore) ImportUser(ctx context.Context, u User, passwordHash string) error {
+	col := s.usersCollection()
+
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		nameIter := tx.Documents(col.Where("username", "==", u.Username))
+		defer nameIter.Stop()
+
+		// Reuse the existing document for this ID, if any, so reruns update
+		// in place instead of creating duplicates.
+		ref := col.NewDoc()
+		for {
+			doc, err := nameIter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to check for existing user: %w", err)
+			}
+			var data firestoreUserDoc
+			if err := doc.DataTo(&data); err != nil {
+				return fmt.Errorf("failed to decode user document: %w", err)
+			}
+			if data.ID != u.ID {
+				return ErrUserAlreadyExists
+			}
+			ref = doc.Ref
+		}
+
+		idIter := tx.Documents(col.Where("id", "==", u.ID).Limit(1))
+		defer idIter.Stop()
+		if doc, err := idIter.Next(); err == nil {
+			var data firestoreUserDoc
+			if err := doc.DataTo(&data); err != nil {
+				return fmt.Errorf("failed to decode user document: %w", err)
+			}
+			if data.Username != u.Username {
+				return ErrUserAlreadyExists
+			}
+			ref = doc.Ref
+		} else if err != iterator.Done {
+			return fmt.Errorf("failed to query user by id: %w", err)
+		}
+
+		if err := s.raiseCounter(tx, col, u.ID); err != nil {
+			return err
+		}
+
+		return tx.Set(ref, firestoreUserDoc{
+			ID:           u.ID,
+			Username:     u.Username,
+			PasswordHash: passwordHash,
+			Role:         u.Role,
+		})
+	})
+}
+
+// ImportPost creates or overwrites posts/<p.ID> and its post_contents
+// document, and registers its slug.
+func (s *FirestoreStore) ImportPost(ctx context.Context, p Post) error {
+	col := s.postsCollection()
+
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		registerSlug, err := s.claimSlug(tx, p.Slug, p.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.raiseCounter(tx, col, p.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Set(col.Doc(strconv.FormatInt(p.ID, 10)), newFirestorePostDoc(p)); err != nil {
+			return err
+		}
+		if err := tx.Set(s.contentRef(p.ID), firestorePostContentDoc{Content: p.Content}); err != nil {
+			return err
+		}
+		return registerSlug(p.ID)
+	})
+}
+
+// ImportPostSlug registers slug for postID in post_slugs.
+func (s *FirestoreStore) ImportPostSlug(ctx context.Context, slug string, postID int64) error {
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		registerSlug, err := s.claimSlug(tx, slug, postID)
+		if err != nil {
+			return err
+		}
+		return registerSlug(postID)
+	})
+}
+
+// ImportPostRevision creates or overwrites
+// post_revisions/<r.PostID>-<r.Number>.
+func (s *FirestoreStore) ImportPostRevision(ctx context.Context, r PostRevision) error {
+	if _, err := s.revisionRef(r.PostID, r.Number).Set(ctx, newFirestoreRevisionDoc(r)); err != nil {
+		return fmt.Errorf("failed to import post revision: %w", err)
+	}
+	return nil
+}
+
+// ImportComment creates or overwrites post_comments/<c.ID>.
+func (s *FirestoreStore) ImportComment(ctx context.Context, c Comment) error {
+	_, err := s.postCommentsCollection().Doc(c.ID).Set(ctx, firestoreCommentDoc{
+		PostID:        c.PostID,
+		UserID:        c.UserID,
+		AuthorName:    c.AuthorName,
+		Content:       c.Content,
+		ContentHTML:   c.ContentHTML,
+		RenderVersion: c.RenderVersion,
+		Version:       c.Version,
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import comment: %w", err)
+	}
+	return nil
+}
+
+// ImportReaction creates or overwrites post_reactions/<userID>_<postID>.
+func (s *FirestoreStore) ImportReaction(ctx context.Context, userID, postID int64, reaction string) error {
+	_, err := s.postReactionsCollection().Doc(fmt.Sprintf("%d_%d", userID, postID)).Set(ctx, firestorePostReactionDoc{
+		UserID:   userID,
+		PostID:   postID,
+		Reaction: reaction,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import reaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteAll deletes every document in the users, posts, post_revisions,
+// post_comments, post_reactions and counters collections. Firestore has no multi-collection
+// transaction of that size, so a failure part-way leaves the remaining
+// documents in place; running DeleteAll again finishes the job.
+func (s *FirestoreStore) DeleteAll(ctx context.Context) error {
+	collections := []*firestore.CollectionRef{
+		s.postReactionsCollection(),
+		s.postCommentsCollection(),
+		s.postsCollection(),
+		s.postContentsCollection(),
+		s.postSlugsCollection(),
+		s.postRevisionsCollection(),
+		s.categoriesCollection(),
+		s.usersCollection(),
+		s.collection("counters"),
+	}
+
+	for _, col := range collections {
+		docs, err := col.DocumentRefs(ctx).GetAll()
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", col.ID, err)
+		}
+
+		bw := s.client.BulkWriter(ctx)
+		jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+		for _, ref := range docs {
+			job, err := bw.Delete(ref)
+			if err != nil {
+				bw.End()
+				return fmt.Errorf("failed to queue delete of %s/%s: %w", col.ID, ref.ID, err)
+			}
+			jobs = append(jobs, job)
+		}
+		bw.End()
+
+		for _, job := range jobs {
+			if _, err := job.Results(); err != nil {
+				return fmt.Errorf("failed to delete from %s: %w", col.ID, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ImportUser creates or overwrites the user with u.ID. SQLite's AUTOINCREMENT
+// sequence automatically moves past explicitly inserted IDs.
+func (s *SQLiteStore) ImportUser(ctx context.Context, u User, passwordHash string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var conflicts int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE (username = ?) != (id = ?)`, u.Username, u.ID).Scan(&conflicts)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing user: %w", err)
+		}
+		if conflicts > 0 {
+			return ErrUserAlreadyExists
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO users (id, username, password_hash, role) VALUES (?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET username = excluded.username, password_hash = excluded.password_hash, role = excluded.role`,
+			u.ID, u.Username, passwordHash, u.Role,
+		); err != nil {
+			return fmt.Errorf("failed to import user: %w", err)
+		}
+		return nil
+	})
+}
+
+// ImportPost creates or overwrites the post with p.ID, replaces its tags and
+// registers its slug.
+func (s *SQLiteStore) ImportPost(ctx context.Context, p Post) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := claimSQLiteSlug(ctx, tx, p.Slug, p.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO posts (id, slug, title, description, category, cover_image_key, content, excerpt, status, publish_at, unpublish_at, members_only, revision_limit, version, created_at, updated_at, author_id, likes_count, dislikes_count, comments_count)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				slug = excluded.slug, title = excluded.title, description = excluded.description, category = excluded.category,
+				cover_image_key = excluded.cover_image_key, content = excluded.content, excerpt = excluded.excerpt, status = excluded.status,
+				publish_at = excluded.publish_at, unpublish_at = excluded.unpublish_at, members_only = excluded.members_only,
+				revision_limit = excluded.revision_limit, version = excluded.version, created_at = excluded.created_at, updated_at = excluded.updated_at, author_id = excluded.author_id,
+				likes_count = excluded.likes_count, dislikes_count = excluded.dislikes_count, comments_count = excluded.comments_count`,
+			p.ID, p.Slug, p.Title, p.Description, p.Category, p.CoverImageKey, p.Content, p.Excerpt, p.Status,
+			sqliteNullTime(p.PublishAt), sqliteNullTime(p.UnpublishAt), p.MembersOnly, p.RevisionLimit, p.Version, p.CreatedAt.UTC(), p.UpdatedAt.UTC(), p.AuthorID, p.LikesCount, p.DislikesCount, p.CommentsCount,
+		); err != nil {
+			return fmt.Errorf("failed to import post: %w", err)
+		}
+		return setSQLitePostTags(ctx, tx, p.ID, p.Tags)
+	})
+}
+
+// ImportPostSlug registers slug for postID.
+func (s *SQLiteStore) ImportPostSlug(ctx context.Context, slug string, postID int64) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		return claimSQLiteSlug(ctx, tx, slug, postID)
+	})
+}
+
+// ImportPostRevision creates or overwrites revision r.Number of post
+// r.PostID.
+func (s *SQLiteStore) ImportPostRevision(ctx context.Context, r PostRevision) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO post_revisions (`+sqliteRevisionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (post_id, number) DO UPDATE SET
+			editor_id = excluded.editor_id, created_at = excluded.created_at, restored_from = excluded.restored_from,
+			slug = excluded.slug, title = excluded.title, description = excluded.description, category = excluded.category,
+			tags = excluded.tags, cover_image_key = excluded.cover_image_key, content = excluded.content, status = excluded.status,
+			publish_at = excluded.publish_at, unpublish_at = excluded.unpublish_at, members_only = excluded.members_only`,
+		r.PostID, r.Number, r.EditorID, r.CreatedAt.UTC(), r.RestoredFrom, r.Slug, r.Title, r.Description, r.Category,
+		strings.Join(r.Tags, ","), r.CoverImageKey, r.Content, r.Status, sqliteNullTime(r.PublishAt), sqliteNullTime(r.UnpublishAt), r.MembersOnly,
+	); err != nil {
+		return fmt.Errorf("failed to import post revision: %w", err)
+	}
+	return nil
+}
+
+// ImportComment creates or overwrites the comment with c.ID. SQLite comment
+// IDs are integers, so only numeric IDs can be imported.
+func (s *SQLiteStore) ImportComment(ctx context.Context, c Comment) error {
+	rowID, err := parseSQLiteCommentID(c.ID)
+	if err != nil {
+		return fmt.Errorf("SQLite store requires numeric comment IDs, got %q", c.ID)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO post_comments (id, post_id, user_id, author_name, content, content_html, render_version, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			post_id = excluded.post_id, user_id = excluded.user_id, author_name = excluded.author_name,
+			content = excluded.content, content_html = excluded.content_html, render_version = excluded.render_version, version = excluded.version,
+			created_at = excluded.created_at, updated_at = excluded.updated_at`,
+		rowID, c.PostID, c.UserID, c.AuthorName, c.Content, c.ContentHTML, c.RenderVersion, c.Version, c.CreatedAt, c.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to import comment: %w", err)
+	}
+	return nil
+}
+
+// ImportReaction records a reaction without touching the post counters.
+func (s *SQLiteStore) ImportReaction(ctx context.Context, userID, postID int64, reaction string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO post_reactions (user_id, post_id, reaction) VALUES (?, ?, ?)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET reaction = excluded.reaction`,
+		userID, postID, reaction,
+	); err != nil {
+		return fmt.Errorf("failed to import reaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteAll empties every table and resets the AUTOINCREMENT sequences in a
+// single transaction.
+func (s *SQLiteStore) DeleteAll(ctx context.Context) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"post_reactions", "post_comments", "post_slugs", "post_tags", "post_revisions", "posts", "categories", "users"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+				return fmt.Errorf("failed to empty %s: %w", table, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sqlite_sequence WHERE name IN ('post_comments', 'posts', 'users')`); err != nil {
+			return fmt.Errorf("failed to reset ID sequences: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/blog_backend/media"
+)
+
+var (
+	// ErrMediaNotFound is returned when the media library has no asset with
+	// the requested key.
+	ErrMediaNotFound = errors.New("media not found")
+
+	// ErrMediaInUse is returned when deleting an asset that posts still use,
+	// unless the delete is forced.
+	ErrMediaInUse = errors.New("media is used by posts")
+)
+
+// MediaAsset is the media library record of an uploaded image. The image
+// itself is kept by the media package under Key; Size is that of the
+// full-size image, without its variants.
+//
+// UsedBy lists the IDs of the posts that use the asset as their cover or
+// link to it in their content, now or in one of their kept revisions, which
+// can be restored. It is worked out from the posts whenever assets are read,
+// so it cannot drift from what the posts say, and it is not stored.
+type MediaAsset struct {
+	Key         string    `json:"key"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	UploaderID  int64     `json:"uploader_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UsedBy      []int64   `json:"used_by"`
+}
+
+// MediaFilter narrows ListMedia. Query matches the file name or key,
+// ignoring case; zero values match everything.
+type MediaFilter struct {
+	Query      string
+	UploaderID int64
+}
+
+func (f MediaFilter) matches(m MediaAsset) bool {
+	if f.UploaderID != 0 && m.UploaderID != f.UploaderID {
+		return false
+	}
+	query := strings.ToLower(strings.TrimSpace(f.Query))
+	return query == "" || strings.Contains(strings.ToLower(m.Filename), query) || strings.Contains(m.Key, query)
+}
+
+// Save records the asset in the media library.
+func (m *MediaAsset) Save(ctx context.Context) error {
+	m.CreatedAt = time.Now()
+	m.UsedBy = nil
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().CreateMedia(ctx, m))
+}
+
+// GetMedia returns the asset with the given key and the posts using it.
+func GetMedia(ctx context.Context, key string) (*MediaAsset, error) {
+	rctx, cancel := readContext(ctx)
+	defer cancel()
+	m, err := store().GetMedia(rctx, key)
+	if err != nil {
+		return nil, storeError(rctx, err)
+	}
+
+	refs, err := MediaReferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.UsedBy = refs[m.Key]
+	return m, nil
+}
+
+// ListMedia returns the assets matching f, newest first, with the posts
+// using each.
+func ListMedia(ctx context.Context, f MediaFilter) ([]MediaAsset, error) {
+	rctx, cancel := readContext(ctx)
+	defer cancel()
+	all, err := store().ListMedia(rctx)
+	if err != nil {
+		return nil, storeError(rctx, err)
+	}
+
+	refs, err := MediaReferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	assets := []MediaAsset{}
+	for _, m := range all {
+		if f.matches(m) {
+			m.UsedBy = refs[m.Key]
+			assets = append(assets, m)
+		}
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		if !assets[i].CreatedAt.Equal(assets[j].CreatedAt) {
+			return assets[i].CreatedAt.After(assets[j].CreatedAt)
+		}
+		return assets[i].Key < assets[j].Key
+	})
+	return assets, nil
+}
+
+// DeleteMedia removes the asset from the media library. Unless force is set
+// it fails with ErrMediaInUse while posts use it. The caller deletes the
+// image itself with media.Delete.
+func DeleteMedia(ctx context.Context, key string, force bool) error {
+	if !force {
+		m, err := GetMedia(ctx, key)
+		if err != nil {
+			return err
+		}
+		if len(m.UsedBy) > 0 {
+			return ErrMediaInUse
+		}
+	}
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().DeleteMedia(ctx, key))
+}
+
+// UnreferencedMedia returns the assets no post uses that were uploaded
+// before cutoff, newest first. The cutoff spares images uploaded for a post
+// that is still being written.
+func UnreferencedMedia(ctx context.Context, cutoff time.Time) ([]MediaAsset, error) {
+	assets, err := ListMedia(ctx, MediaFilter{})
+	if err != nil {
+		return nil, err
+	}
+	unused := []MediaAsset{}
+	for _, m := range assets {
+		if len(m.UsedBy) == 0 && m.CreatedAt.Before(cutoff) {
+			unused = append(unused, m)
+		}
+	}
+	return unused, nil
+}
+
+// MediaReferences maps the key of every upload that posts use, as their
+// cover or through links in their content, to the IDs of those posts in
+// ascending order. Drafts and scheduled posts count as well, and so do the
+// kept revisions of a post, since restoring one brings its images back.
+func MediaReferences(ctx context.Context) (map[string][]int64, error) {
+	posts, err := GetAllPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string][]int64)
+	for _, p := range posts {
+		keys := make(map[string]bool)
+		addMediaKeys(keys, p.Content, p.CoverImageKey)
+		revisions, err := ListPostRevisions(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, rev := range revisions {
+			addMediaKeys(keys, rev.Content, rev.CoverImageKey)
+		}
+		for key := range keys {
+			refs[key] = append(refs[key], p.ID)
+		}
+	}
+	for _, ids := range refs {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	return refs, nil
+}
+
+// addMediaKeys adds the uploads that content links to and the cover key, when
+// it names an upload, to keys.
+func addMediaKeys(keys map[string]bool, content, coverImageKey string) {
+	for _, key := range media.KeysIn(content) {
+		keys[key] = true
+	}
+	if media.ValidKey(coverImageKey) {
+		keys[coverImageKey] = true
+	}
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firestoreMediaDoc is the Firestore representation of a media record. The
+// key is the document ID.
+type firestoreMediaDoc struct {
+	Filename    string    `firestore:"filename"`
+	ContentType string    `firestore:"content_type"`
+	Size        int64     `firestore:"size"`
+	Width       int       `firestore:"width"`
+	Height      int       `firestore:"height"`
+	UploaderID  int64     `firestore:"uploader_id"`
+	CreatedAt   time.Time `firestore:"created_at"`
+}
+
+func newFirestoreMediaDoc(m MediaAsset) firestoreMediaDoc {
+	return firestoreMediaDoc{
+		Filename:    m.Filename,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		UploaderID:  m.UploaderID,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func (d firestoreMediaDoc) toMediaAsset(key string) MediaAsset {
+	return MediaAsset{
+		Key:         key,
+		Filename:    d.Filename,
+		ContentType: d.ContentType,
+		Size:        d.Size,
+		Width:       d.Width,
+		Height:      d.Height,
+		UploaderID:  d.UploaderID,
+		CreatedAt:   d.CreatedAt,
+	}
+}
+
+// CreateMedia writes media/<key>.
+func (s *FirestoreStore) CreateMedia(ctx context.Context, m *MediaAsset) error {
+	if _, err := s.mediaCollection().Doc(m.Key).Set(ctx, newFirestoreMediaDoc(*m)); err != nil {
+		return fmt.Errorf("failed to save media: %w", err)
+	}
+	return nil
+}
+
+// ListMedia returns every media record; the caller sorts them.
+func (s *FirestoreStore) ListMedia(ctx context.Context) ([]MediaAsset, error) {
+	iter := s.mediaCollection().Documents(ctx)
+	defer iter.Stop()
+
+	var assets []MediaAsset
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate media: %w", err)
+		}
+
+		var data firestoreMediaDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode media document: %w", err)
+		}
+		assets = append(assets, data.toMediaAsset(doc.Ref.ID))
+	}
+	return assets, nil
+}
+
+// GetMedia fetches a single media record by key.
+func (s *FirestoreStore) GetMedia(ctx context.Context, key string) (*MediaAsset, error) {
+	snap, err := s.mediaCollection().Doc(key).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrMediaNotFound
+		}
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+
+	var data firestoreMediaDoc
+	if err := snap.DataTo(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode media document: %w", err)
+	}
+	m := data.toMediaAsset(key)
+	return &m, nil
+}
+
+// DeleteMedia removes media/<key>; the Exists precondition reports missing
+// records.
+func (s *FirestoreStore) DeleteMedia(ctx context.Context, key string) error {
+	if _, err := s.mediaCollection().Doc(key).Delete(ctx, firestore.Exists); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrMediaNotFound
+		}
+		return fmt.Errorf("failed to delete media: %w", err)
+	}
+	return nil
+}
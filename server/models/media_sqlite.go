@@ -0,0 +1,74 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const sqliteMediaColumns = `"key", filename, content_type, size, width, height, uploader_id, created_at`
+
+func scanSQLiteMedia(row rowScanner) (MediaAsset, error) {
+	var m MediaAsset
+	err := row.Scan(&m.Key, &m.Filename, &m.ContentType, &m.Size, &m.Width, &m.Height, &m.UploaderID, &m.CreatedAt)
+	return m, err
+}
+
+// CreateMedia inserts the media record.
+func (s *SQLiteStore) CreateMedia(ctx context.Context, m *MediaAsset) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO media (`+sqliteMediaColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.Key, m.Filename, m.ContentType, m.Size, m.Width, m.Height, m.UploaderID, m.CreatedAt.UTC(),
+	); err != nil {
+		return fmt.Errorf("failed to save media: %w", err)
+	}
+	return nil
+}
+
+// ListMedia returns every media record.
+func (s *SQLiteStore) ListMedia(ctx context.Context) ([]MediaAsset, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteMediaColumns+` FROM media`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query media: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []MediaAsset
+	for rows.Next() {
+		m, err := scanSQLiteMedia(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode media row: %w", err)
+		}
+		assets = append(assets, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate media: %w", err)
+	}
+	return assets, nil
+}
+
+// GetMedia fetches a single media record by key.
+func (s *SQLiteStore) GetMedia(ctx context.Context, key string) (*MediaAsset, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqliteMediaColumns+` FROM media WHERE "key" = ?`, key)
+	m, err := scanSQLiteMedia(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return &m, nil
+}
+
+// DeleteMedia removes the media record with the given key.
+func (s *SQLiteStore) DeleteMedia(ctx context.Context, key string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM media WHERE "key" = ?`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete media: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrMediaNotFound
+	}
+	return nil
+}
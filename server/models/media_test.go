@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Posts use media as their cover or through links in their content; assets
+// in use are only deleted when forced, and the sweep lists the others.
+func TestMediaLibrary(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+
+		key := func(n int) string { return fmt.Sprintf("%032x.jpg", n) }
+		for i, filename := range []string{"Cover.jpg", "inline.jpg", "unused.jpg", "fresh.jpg"} {
+			m := &MediaAsset{Key: key(i + 1), Filename: filename, ContentType: "image/jpeg", Size: 100, UploaderID: int64(i%2 + 1)}
+			if err := m.Save(ctx); err != nil {
+				t.Fatalf("failed to save media: %v", err)
+			}
+		}
+
+		cover := &Post{Title: "Cover", Content: "Body", CoverImageKey: key(1)}
+		inline := &Post{Title: "Inline", Content: "![x](https://api.example.com/media/" + strings.TrimSuffix(key(2), ".jpg") + "-640w.jpg)", Status: "draft"}
+		for _, p := range []*Post{cover, inline} {
+			if err := p.Save(ctx); err != nil {
+				t.Fatalf("failed to create post: %v", err)
+			}
+		}
+
+		m, err := GetMedia(ctx, key(1))
+		if err != nil || len(m.UsedBy) != 1 || m.UsedBy[0] != cover.ID || m.Filename != "Cover.jpg" {
+			t.Fatalf("expected key 1 used by the cover post, got %+v (%v)", m, err)
+		}
+		if m, err := GetMedia(ctx, key(2)); err != nil || len(m.UsedBy) != 1 || m.UsedBy[0] != inline.ID {
+			t.Errorf("expected key 2 used by the inline post, got %+v (%v)", m, err)
+		}
+
+		found, err := ListMedia(ctx, MediaFilter{Query: "cover"})
+		if err != nil || len(found) != 1 || found[0].Key != key(1) {
+			t.Errorf("expected the search to find Cover.jpg, got %+v (%v)", found, err)
+		}
+		found, err = ListMedia(ctx, MediaFilter{UploaderID: 2})
+		if err != nil || len(found) != 2 {
+			t.Errorf("expected two uploads by user 2, got %+v (%v)", found, err)
+		}
+
+		unused, err := UnreferencedMedia(ctx, time.Now().Add(time.Hour))
+		if err != nil || len(unused) != 2 {
+			t.Errorf("expected two unreferenced assets, got %+v (%v)", unused, err)
+		}
+		unused, err = UnreferencedMedia(ctx, time.Now().Add(-time.Hour))
+		if err != nil || len(unused) != 0 {
+			t.Errorf("expected recent uploads to be spared, got %+v (%v)", unused, err)
+		}
+
+		if err := DeleteMedia(ctx, key(1), false); !errors.Is(err, ErrMediaInUse) {
+			t.Errorf("expected ErrMediaInUse, got %v", err)
+		}
+		if err := DeleteMedia(ctx, key(1), true); err != nil {
+			t.Errorf("expected a forced delete to succeed, got %v", err)
+		}
+		if err := DeleteMedia(ctx, key(3), false); err != nil {
+			t.Errorf("expected an unused asset to be deleted, got %v", err)
+		}
+		if _, err := GetMedia(ctx, key(3)); !errors.Is(err, ErrMediaNotFound) {
+			t.Errorf("expected ErrMediaNotFound after deleting, got %v", err)
+		}
+		if err := DeleteMedia(ctx, key(3), true); !errors.Is(err, ErrMediaNotFound) {
+			t.Errorf("expected ErrMediaNotFound for a missing asset, got %v", err)
+		}
+	})
+}
+
+// An image that only a kept revision still uses counts as used, so the sweep
+// and an unforced delete leave it for a restore to bring back.
+func TestMediaUsedByRevisions(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+
+		key := fmt.Sprintf("%032x.jpg", 1)
+		if err := (&MediaAsset{Key: key, Filename: "old-cover.jpg", ContentType: "image/jpeg"}).Save(ctx); err != nil {
+			t.Fatalf("failed to save media: %v", err)
+		}
+		post := &Post{Title: "Cover", Content: "Body", CoverImageKey: key}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		post.CoverImageKey = ""
+		if err := post.Update(ctx, 1); err != nil {
+			t.Fatalf("failed to update post: %v", err)
+		}
+
+		if m, err := GetMedia(ctx, key); err != nil || len(m.UsedBy) != 1 || m.UsedBy[0] != post.ID {
+			t.Errorf("expected the old cover used by the post's revision, got %+v (%v)", m, err)
+		}
+		if unused, err := UnreferencedMedia(ctx, time.Now().Add(time.Hour)); err != nil || len(unused) != 0 {
+			t.Errorf("expected the sweep to spare the old cover, got %+v (%v)", unused, err)
+		}
+		if err := DeleteMedia(ctx, key, false); !errors.Is(err, ErrMediaInUse) {
+			t.Errorf("expected ErrMediaInUse for an image a revision uses, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,343 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"example.com/blog_backend/markdown"
+	"example.com/blog_backend/media"
+)
+
+// excerptLength is the maximum number of characters in a post excerpt.
+const excerptLength = 200
+
+// Post represents a blog post that users can read after logging in.
+//
+// Status indicates whether the post is published or still a draft. Valid
+// values are "published" (default), "draft" and "scheduled". A scheduled
+// post is published by ApplyPostSchedule once PublishAt has passed, and a
+// published post with UnpublishAt goes back to being a draft at that time.
+// Only published posts are shown to readers.
+//
+// MembersOnly posts can only be read by signed-in users, even when the blog
+// allows anonymous readers.
+//
+// Excerpt is derived from Content whenever the post is saved, so listings can
+// show a preview without reading the body.
+//
+// Tags are normalized with NormalizeTag, sorted and unique. Unlike Category,
+// a post can have several.
+//
+// Slug is the post's unique, human-readable URL name. It is generated from
+// the title unless an editor sets it, and follows title changes; previous
+// slugs keep resolving to the post.
+//
+// Content is Markdown. ContentHTML, TOC, WordCount and ReadingTimeMinutes are
+// derived from it when a single post is read or written (see
+// markdown.Render) and are not stored; post listings leave them empty.
+//
+// CoverImage describes the sizes of an uploaded cover (see media.Describe).
+// The API fills it in when serving a post; it is not stored.
+//
+// RevisionLimit is how many revisions of the post are kept (see
+// PostRevision); zero means DefaultRevisionLimit.
+//
+// Version starts at 1 and goes up by one with every Update and every other
+// change to the stored post (scheduled publishing, tag and category
+// renames), so clients can tell whether the post changed since they read it
+// (see Update).
+type Post struct {
+	ID                 int64              `json:"id"`
+	Slug               string             `json:"slug"`
+	Title              string             `json:"title" binding:"required"`
+	Description        string             `json:"description"`
+	Category           string             `json:"category"`
+	Tags               []string           `json:"tags"`
+	CoverImageKey      string             `json:"cover_image_key"`
+	CoverImage         *media.Image       `json:"cover_image,omitempty"`
+	Content            string             `json:"content" binding:"required"`
+	Excerpt            string             `json:"excerpt"`
+	ContentHTML        string             `json:"content_html,omitempty"`
+	TOC                []markdown.Heading `json:"toc,omitempty"`
+	WordCount          int                `json:"word_count,omitempty"`
+	ReadingTimeMinutes int                `json:"reading_time_minutes,omitempty"`
+	Status             string             `json:"status"`
+	PublishAt          *time.Time         `json:"publish_at,omitempty"`
+	UnpublishAt        *time.Time         `json:"unpublish_at,omitempty"`
+	MembersOnly        bool               `json:"members_only"`
+	RevisionLimit      int                `json:"revision_limit"`
+	Version            int64              `json:"version"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+	AuthorID           int64              `json:"author_id"`
+	LikesCount         int64              `json:"likes_count"`
+	DislikesCount      int64              `json:"dislikes_count"`
+	CommentsCount      int64              `json:"comments_count"`
+}
+
+// PostSummary is the listing view of a post: its metadata and excerpt
+// without the body. GetPostByID returns the full Post.
+type PostSummary struct {
+	ID            int64        `json:"id"`
+	Slug          string       `json:"slug"`
+	Title         string       `json:"title"`
+	Description   string       `json:"description"`
+	Category      string       `json:"category"`
+	Tags          []string     `json:"tags"`
+	CoverImageKey string       `json:"cover_image_key"`
+	CoverImage    *media.Image `json:"cover_image,omitempty"`
+	Excerpt       string       `json:"excerpt"`
+	Status        string       `json:"status"`
+	PublishAt     *time.Time   `json:"publish_at,omitempty"`
+	UnpublishAt   *time.Time   `json:"unpublish_at,omitempty"`
+	MembersOnly   bool         `json:"members_only"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	AuthorID      int64        `json:"author_id"`
+	LikesCount    int64        `json:"likes_count"`
+	DislikesCount int64        `json:"dislikes_count"`
+	CommentsCount int64        `json:"comments_count"`
+}
+
+// Summary returns the listing view of p.
+func (p Post) Summary() PostSummary {
+	return PostSummary{
+		ID:            p.ID,
+		Slug:          p.Slug,
+		Title:         p.Title,
+		Description:   p.Description,
+		Category:      p.Category,
+		Tags:          p.Tags,
+		CoverImageKey: p.CoverImageKey,
+		Excerpt:       p.Excerpt,
+		Status:        p.Status,
+		PublishAt:     p.PublishAt,
+		UnpublishAt:   p.UnpublishAt,
+		MembersOnly:   p.MembersOnly,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+		AuthorID:      p.AuthorID,
+		LikesCount:    p.LikesCount,
+		DislikesCount: p.DislikesCount,
+		CommentsCount: p.CommentsCount,
+	}
+}
+
+// LastModified returns when the post last changed as readers see it: its
+// UpdatedAt, or its CreatedAt when that is later, as for a scheduled post
+// that went live after it was last edited.
+func (p PostSummary) LastModified() time.Time {
+	if p.CreatedAt.After(p.UpdatedAt) {
+		return p.CreatedAt
+	}
+	return p.UpdatedAt
+}
+
+// render fills in the fields derived from p.Content.
+func (p *Post) render() {
+	doc := markdown.Render(p.Content)
+	p.ContentHTML = doc.HTML
+	p.TOC = doc.TOC
+	p.WordCount = doc.WordCount
+	p.ReadingTimeMinutes = markdown.ReadingTime(doc.WordCount)
+}
+
+// PostExcerpt returns the first excerptLength characters of content as a
+// single line of plain text, cut at a word boundary. Markdown heading, quote
+// and emphasis markers are dropped so the preview reads as prose.
+func PostExcerpt(content string) string {
+	text := strings.Join(strings.FieldsFunc(content, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("#*_`>", r)
+	}), " ")
+
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+
+	cut := runes[:excerptLength]
+	if i := strings.LastIndexFunc(string(cut), unicode.IsSpace); i > 0 {
+		return strings.TrimRightFunc(string(cut)[:i], unicode.IsPunct) + "…"
+	}
+	return string(cut) + "…"
+}
+
+// normalizePostStatus only allows "draft", "scheduled" or "published";
+// anything else defaults to published.
+func normalizePostStatus(status string) string {
+	if status != "draft" && status != "scheduled" {
+		return "published"
+	}
+	return status
+}
+
+// Save creates a new post in the active store and assigns it a numeric ID so
+// existing API consumers can continue to treat post IDs as integers.
+//
+// A slug set by the caller is normalized with Slugify and must be free
+// (ErrSlugTaken) and non-empty (ErrInvalidSlug). Otherwise one is generated
+// from the title, numbered if needed to keep it unique.
+//
+// Tags are normalized; an invalid tag fails with ErrInvalidTag. A category
+// must name an existing category (ErrUnknownCategory) and is stored under
+// its canonical name. A scheduled post needs PublishAt (ErrInvalidSchedule).
+// RevisionLimit must be at most MaxRevisionLimit (ErrInvalidRevisionLimit).
+func (p *Post) Save(ctx context.Context) error {
+	tags, err := NormalizeTags(p.Tags)
+	if err != nil {
+		return err
+	}
+	if err := checkRevisionLimit(p.RevisionLimit); err != nil {
+		return err
+	}
+	p.Tags = tags
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+	if p.UpdatedAt.IsZero() {
+		p.UpdatedAt = p.CreatedAt
+	}
+	p.Status = normalizePostStatus(p.Status)
+	if err := normalizePostSchedule(p); err != nil {
+		return err
+	}
+	p.Excerpt = PostExcerpt(p.Content)
+
+	base, explicit := p.Title, p.Slug != ""
+	if explicit {
+		base = p.Slug
+	}
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	if p.Category, err = resolveCategory(ctx, p.Category); err != nil {
+		return storeError(ctx, err)
+	}
+	err = assignSlug(ctx, 0, base, explicit, func(slug string) error {
+		p.Slug = slug
+		return store().SavePost(ctx, p)
+	})
+	if err != nil {
+		return storeError(ctx, err)
+	}
+	indexPost(*p)
+	p.render()
+	return nil
+}
+
+// GetAllPosts returns all posts ordered by creation time (newest first).
+func GetAllPosts(ctx context.Context) ([]Post, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	posts, err := store().ListPosts(ctx)
+	return posts, storeError(ctx, err)
+}
+
+// GetPostByID fetches a single post, including its content and the HTML
+// rendered from it, by its numeric ID.
+func GetPostByID(ctx context.Context, id int64) (*Post, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	post, err := store().GetPost(ctx, id)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	post.render()
+	return post, nil
+}
+
+// Update modifies an existing post's title, metadata, and content, and sets
+// p.Slug to the slug the post ends up with.
+//
+// A slug set by the caller replaces the current one under the same rules as
+// Save. Without one, the current slug is kept unless the title changed, in
+// which case a new slug is generated from it. Either way the previous slug
+// keeps resolving to the post.
+//
+// p.Tags replaces the post's tags, so an empty list removes them all. The
+// category, the schedule and the revision limit are checked like in Save.
+//
+// The updated post is recorded as a new revision by editorID, and revisions
+// beyond the post's RevisionLimit are dropped, oldest first.
+//
+// A non-zero p.Version is the version the caller expects the post to have:
+// when it has changed since, nothing is written and ErrVersionConflict is
+// returned. The check is atomic with the write. On success p.Version is the
+// post's new version.
+func (p *Post) Update(ctx context.Context, editorID int64) error {
+	return p.update(ctx, editorID, 0)
+}
+
+// update implements Update, recording that the new revision restores
+// revision restoredFrom unless that is zero.
+func (p *Post) update(ctx context.Context, editorID int64, restoredFrom int) error {
+	tags, err := NormalizeTags(p.Tags)
+	if err != nil {
+		return err
+	}
+	if err := checkRevisionLimit(p.RevisionLimit); err != nil {
+		return err
+	}
+	p.Tags = tags
+	if p.UpdatedAt.IsZero() {
+		p.UpdatedAt = time.Now()
+	}
+	p.Status = normalizePostStatus(p.Status)
+	if err := normalizePostSchedule(p); err != nil {
+		return err
+	}
+	p.Excerpt = PostExcerpt(p.Content)
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	if p.Category, err = resolveCategory(ctx, p.Category); err != nil {
+		return storeError(ctx, err)
+	}
+
+	base, explicit := p.Slug, p.Slug != ""
+	if !explicit {
+		current, err := store().GetPost(ctx, p.ID)
+		if err != nil {
+			return storeError(ctx, err)
+		}
+		base = p.Title
+		if current.Title == p.Title && current.Slug != "" {
+			base, explicit = current.Slug, true
+		}
+	}
+
+	err = assignSlug(ctx, p.ID, base, explicit, func(slug string) error {
+		p.Slug = slug
+		rev := newPostRevision(*p, editorID, restoredFrom)
+		return store().UpdatePost(ctx, p, &rev)
+	})
+	if err != nil {
+		return storeError(ctx, err)
+	}
+	indexPost(*p)
+	p.render()
+	return nil
+}
+
+// checkVersion returns ErrVersionConflict unless the expected version is
+// zero (no expectation) or the current one.
+func checkVersion(expected, current int64) error {
+	if expected != 0 && expected != current {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Delete removes a post and its associated reactions, comments and
+// revisions.
+func (p Post) Delete(ctx context.Context) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	if err := store().DeletePost(ctx, p.ID); err != nil {
+		return storeError(ctx, err)
+	}
+	unindexPost(p.ID)
+	return nil
+}
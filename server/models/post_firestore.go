@@ -10,121 +10,240 @@ import (
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-
-	"example.com/blog_backend/db"
 )
 
-// Post represents a blog post that users can read after logging in.
+// firestorePostDoc is the Firestore representation of a post's metadata. The
+// body lives in a firestorePostContentDoc with the same document ID.
 //
-// Status indicates whether the post is published or still a draft. Valid
-// values are "published" (default) and "draft".
-type Post struct {
-	ID            int64     `json:"id"`
-	Title         string    `json:"title" binding:"required"`
-	Description   string    `json:"description"`
-	Category      string    `json:"category"`
-	CoverImageKey string    `json:"cover_image_key"`
-	Content       string    `json:"content" binding:"required"`
-	Status        string    `json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	AuthorID      int64     `json:"author_id"`
-	LikesCount    int64     `json:"likes_count"`
-	DislikesCount int64     `json:"dislikes_count"`
-	CommentsCount int64     `json:"comments_count"`
+// Content is only set on documents written before the body moved to
+// post_contents and not yet migrated; new writes leave it out.
+type firestorePostDoc struct {
+	ID            int64      `firestore:"id"`
+	Slug          string     `firestore:"slug"`
+	Title         string     `firestore:"title"`
+	Description   string     `firestore:"description"`
+	Category      string     `firestore:"category"`
+	Tags          []string   `firestore:"tags"`
+	CoverImageKey string     `firestore:"cover_image_key"`
+	Content       string     `firestore:"content,omitempty"`
+	Excerpt       string     `firestore:"excerpt"`
+	Status        string     `firestore:"status"`
+	PublishAt     *time.Time `firestore:"publish_at"`
+	UnpublishAt   *time.Time `firestore:"unpublish_at"`
+	MembersOnly   bool       `firestore:"members_only"`
+	RevisionLimit int        `firestore:"revision_limit"`
+	Version       int64      `firestore:"version"`
+	CreatedAt     time.Time  `firestore:"created_at"`
+	UpdatedAt     time.Time  `firestore:"updated_at"`
+	AuthorID      int64      `firestore:"author_id"`
+	LikesCount    int64      `firestore:"likes_count"`
+	DislikesCount int64      `firestore:"dislikes_count"`
+	CommentsCount int64      `firestore:"comments_count"`
 }
 
-// firestorePostDoc is the Firestore representation of a Post document.
-type firestorePostDoc struct {
-	ID            int64     `firestore:"id"`
-	Title         string    `firestore:"title"`
-	Description   string    `firestore:"description"`
-	Category      string    `firestore:"category"`
-	CoverImageKey string    `firestore:"cover_image_key"`
-	Content       string    `firestore:"content"`
-	Status        string    `firestore:"status"`
-	CreatedAt     time.Time `firestore:"created_at"`
-	UpdatedAt     time.Time `firestore:"updated_at"`
-	AuthorID      int64     `firestore:"author_id"`
-	LikesCount    int64     `firestore:"likes_count"`
-	DislikesCount int64     `firestore:"dislikes_count"`
-	CommentsCount int64     `firestore:"comments_count"`
+// firestorePostContentDoc is the Firestore representation of a post body,
+// stored in post_contents/<post id>.
+type firestorePostContentDoc struct {
+	Content string `firestore:"content"`
+}
+
+// newFirestorePostDoc returns the metadata document for p.
+func newFirestorePostDoc(p Post) firestorePostDoc {
+	return firestorePostDoc{
+		ID:            p.ID,
+		Slug:          p.Slug,
+		Title:         p.Title,
+		Description:   p.Description,
+		Category:      p.Category,
+		Tags:          p.Tags,
+		CoverImageKey: p.CoverImageKey,
+		Excerpt:       p.Excerpt,
+		Status:        p.Status,
+		PublishAt:     p.PublishAt,
+		UnpublishAt:   p.UnpublishAt,
+		MembersOnly:   p.MembersOnly,
+		RevisionLimit: p.RevisionLimit,
+		Version:       p.Version,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+		AuthorID:      p.AuthorID,
+		LikesCount:    p.LikesCount,
+		DislikesCount: p.DislikesCount,
+		CommentsCount: p.CommentsCount,
+	}
 }
 
-func postsCollection() *firestore.CollectionRef {
-	if db.FirestoreClient == nil {
-		panic("Firestore client is not initialized")
+// toPost assembles the full post from its metadata and the body read from
+// post_contents. A missing body document (content == nil) means the post has
+// not been migrated yet, so the legacy content field is used instead.
+// Documents written before posts had versions are at version 1.
+func (d firestorePostDoc) toPost(content *firestorePostContentDoc) Post {
+	p := Post{
+		ID:            d.ID,
+		Slug:          d.Slug,
+		Title:         d.Title,
+		Description:   d.Description,
+		Category:      d.Category,
+		Tags:          orEmptyTags(d.Tags),
+		CoverImageKey: d.CoverImageKey,
+		Content:       d.Content,
+		Excerpt:       d.Excerpt,
+		Status:        d.Status,
+		PublishAt:     d.PublishAt,
+		UnpublishAt:   d.UnpublishAt,
+		MembersOnly:   d.MembersOnly,
+		RevisionLimit: d.RevisionLimit,
+		Version:       max(d.Version, 1),
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+		AuthorID:      d.AuthorID,
+		LikesCount:    d.LikesCount,
+		DislikesCount: d.DislikesCount,
+		CommentsCount: d.CommentsCount,
+	}
+	if content != nil {
+		p.Content = content.Content
 	}
-	return db.FirestoreClient.Collection("posts")
+	if p.Excerpt == "" {
+		p.Excerpt = PostExcerpt(p.Content)
+	}
+	return p
 }
 
-// nextPostID returns the next numeric post ID by looking at the existing
-// maximum id value in Firestore. If there are no posts yet, it returns 1.
-func nextPostID(ctx context.Context) (int64, error) {
-	col := postsCollection()
-	iter := col.OrderBy("id", firestore.Desc).Limit(1).Documents(ctx)
-	defer iter.Stop()
+// toSummary returns the listing view of the metadata document. Unmigrated
+// documents have no excerpt yet and compute it from their legacy content.
+func (d firestorePostDoc) toSummary() PostSummary {
+	return d.toPost(nil).Summary()
+}
+
+// firestorePostSlugDoc is the post_slugs document for one slug.
+type firestorePostSlugDoc struct {
+	PostID int64 `firestore:"post_id"`
+}
 
-	doc, err := iter.Next()
-	if err == iterator.Done {
-		return 1, nil
+// readSlugOwner returns the post that holds slug, read inside tx, or false
+// when the slug is free.
+func (s *FirestoreStore) readSlugOwner(tx *firestore.Transaction, slug string) (int64, bool, error) {
+	snap, err := tx.Get(s.postSlugsCollection().Doc(slug))
+	if status.Code(err) == codes.NotFound {
+		return 0, false, nil
 	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to get last post id: %w", err)
+		return 0, false, fmt.Errorf("failed to look up post slug: %w", err)
 	}
 
-	var data firestorePostDoc
-	if err := doc.DataTo(&data); err != nil {
-		return 0, fmt.Errorf("failed to decode last post document: %w", err)
+	var data firestorePostSlugDoc
+	if err := snap.DataTo(&data); err != nil {
+		return 0, false, fmt.Errorf("failed to decode post slug document: %w", err)
 	}
-
-	return data.ID + 1, nil
+	return data.PostID, true, nil
 }
 
-// Save creates a new post in Firestore and assigns it a numeric ID. The ID is
-// stored both as a field and used as the document ID so existing API consumers
-// can continue to treat post IDs as integers.
-func (p *Post) Save() error {
-	ctx := context.Background()
+// claimSlug registers slug for postID inside tx, failing with ErrSlugTaken
+// when another post holds it. Like all transactional reads it must run
+// before the transaction's first write; the returned function performs the
+// write. An empty slug is ignored.
+func (s *FirestoreStore) claimSlug(tx *firestore.Transaction, slug string, postID int64) (func(postID int64) error, error) {
+	if slug == "" {
+		return func(int64) error { return nil }, nil
+	}
 
-	if p.CreatedAt.IsZero() {
-		p.CreatedAt = time.Now()
+	owner, taken, err := s.readSlugOwner(tx, slug)
+	if err != nil {
+		return nil, err
 	}
-	if p.UpdatedAt.IsZero() {
-		p.UpdatedAt = p.CreatedAt
+	if taken && owner != postID {
+		return nil, ErrSlugTaken
 	}
+	return func(postID int64) error {
+		if taken {
+			return nil
+		}
+		return tx.Create(s.postSlugsCollection().Doc(slug), firestorePostSlugDoc{PostID: postID})
+	}, nil
+}
+
+// contentRef returns the post_contents document for the post with the given
+// ID.
+func (s *FirestoreStore) contentRef(id int64) *firestore.DocumentRef {
+	return s.postContentsCollection().Doc(strconv.FormatInt(id, 10))
+}
 
-	// Only allow "draft" or "published"; default to published.
-	if p.Status != "draft" {
-		p.Status = "published"
+// readContents fetches the bodies of the given posts in one round trip and
+// assembles the full posts.
+func (s *FirestoreStore) readContents(ctx context.Context, docs []firestorePostDoc) ([]Post, error) {
+	if len(docs) == 0 {
+		return nil, nil
 	}
 
-	nextID, err := nextPostID(ctx)
+	refs := make([]*firestore.DocumentRef, len(docs))
+	for i, d := range docs {
+		refs[i] = s.contentRef(d.ID)
+	}
+	snaps, err := s.client.GetAll(ctx, refs)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get post contents: %w", err)
 	}
 
-	doc := firestorePostDoc{
-		ID:            nextID,
-		Title:         p.Title,
-		Description:   p.Description,
-		Category:      p.Category,
-		CoverImageKey: p.CoverImageKey,
-		Content:       p.Content,
-		Status:        p.Status,
-		CreatedAt:     p.CreatedAt,
-		UpdatedAt:     p.UpdatedAt,
-		AuthorID:      p.AuthorID,
-		LikesCount:    0,
-		DislikesCount: 0,
-		CommentsCount: 0,
+	posts := make([]Post, len(docs))
+	for i, d := range docs {
+		var content *firestorePostContentDoc
+		if snaps[i].Exists() {
+			content = &firestorePostContentDoc{}
+			if err := snaps[i].DataTo(content); err != nil {
+				return nil, fmt.Errorf("failed to decode post content document: %w", err)
+			}
+		}
+		posts[i] = d.toPost(content)
 	}
+	return posts, nil
+}
+
+// SavePost creates a new post in Firestore and assigns it a numeric ID. The
+// ID is allocated from the posts counter in the same transaction that
+// creates the document, and is used both as a field and as the document ID.
+func (s *FirestoreStore) SavePost(ctx context.Context, p *Post) error {
+	col := s.postsCollection()
+
+	var newID int64
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		registerSlug, err := s.claimSlug(tx, p.Slug, 0)
+		if err != nil {
+			return err
+		}
+		nextID, err := s.allocateID(tx, col)
+		if err != nil {
+			return err
+		}
+
+		post := *p
+		post.ID = nextID
+		post.Version = 1
+		post.LikesCount = 0
+		post.DislikesCount = 0
+		post.CommentsCount = 0
+
+		// Create (rather than Set) so a stale counter can never overwrite an
+		// existing post.
+		if err := tx.Create(col.Doc(strconv.FormatInt(nextID, 10)), newFirestorePostDoc(post)); err != nil {
+			return fmt.Errorf("failed to save post: %w", err)
+		}
+		if err := tx.Set(s.contentRef(nextID), firestorePostContentDoc{Content: p.Content}); err != nil {
+			return fmt.Errorf("failed to save post content: %w", err)
+		}
+		if err := registerSlug(nextID); err != nil {
+			return fmt.Errorf("failed to register post slug: %w", err)
+		}
 
-	if _, err := postsCollection().Doc(strconv.FormatInt(nextID, 10)).Set(ctx, doc); err != nil {
-		return fmt.Errorf("failed to save post: %w", err)
+		newID = nextID
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	p.ID = nextID
+	p.ID = newID
+	p.Version = 1
 	p.LikesCount = 0
 	p.DislikesCount = 0
 	p.CommentsCount = 0
@@ -132,15 +251,15 @@ func (p *Post) Save() error {
 	return nil
 }
 
-// GetAllPosts returns all posts ordered by creation time (newest first).
-func GetAllPosts() ([]Post, error) {
-	ctx := context.Background()
-	col := postsCollection()
+// ListPosts returns all posts, including their content, ordered by creation
+// time (newest first).
+func (s *FirestoreStore) ListPosts(ctx context.Context) ([]Post, error) {
+	col := s.postsCollection()
 
 	iter := col.OrderBy("created_at", firestore.Desc).Documents(ctx)
 	defer iter.Stop()
 
-	var posts []Post
+	var docs []firestorePostDoc
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -155,107 +274,174 @@ func GetAllPosts() ([]Post, error) {
 			return nil, fmt.Errorf("failed to decode post document: %w", err)
 		}
 
-		posts = append(posts, Post{
-			ID:            data.ID,
-			Title:         data.Title,
-			Description:   data.Description,
-			Category:      data.Category,
-				CoverImageKey: data.CoverImageKey,
-			Content:       data.Content,
-			Status:        data.Status,
-			CreatedAt:     data.CreatedAt,
-			UpdatedAt:     data.UpdatedAt,
-				AuthorID:      data.AuthorID,
-				LikesCount:    data.LikesCount,
-				DislikesCount: data.DislikesCount,
-				CommentsCount: data.CommentsCount,
-		})
+		docs = append(docs, data)
 	}
 
-	return posts, nil
+	return s.readContents(ctx, docs)
 }
 
-// GetPostByID fetches a single post by its numeric ID.
-func GetPostByID(id int64) (*Post, error) {
-	ctx := context.Background()
-
-	doc, err := postsCollection().Doc(strconv.FormatInt(id, 10)).Get(ctx)
-	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			return nil, ErrPostNotFound
-		}
-		return nil, fmt.Errorf("failed to get post: %w", err)
+// QueryPosts returns a page of posts matching f using a Firestore query
+// ordered by created_at and id, both descending. Each combination of equality
+// filters (category, tags, status, author_id) with that ordering needs a
+// composite index; Firestore's error message links to the index to create.
+func (s *FirestoreStore) QueryPosts(ctx context.Context, f PostFilter) ([]PostSummary, error) {
+	q := s.postsCollection().Query
+	if f.Category != "" {
+		q = q.Where("category", "==", f.Category)
+	}
+	if f.Tag != "" {
+		q = q.Where("tags", "array-contains", f.Tag)
+	}
+	if f.Status != "" {
+		q = q.Where("status", "==", f.Status)
+	}
+	if f.AuthorID != 0 {
+		q = q.Where("author_id", "==", f.AuthorID)
+	}
+	if !f.CreatedAfter.IsZero() {
+		q = q.Where("created_at", ">=", f.CreatedAfter)
+	}
+	if !f.CreatedBefore.IsZero() {
+		q = q.Where("created_at", "<", f.CreatedBefore)
 	}
 
-	var data firestorePostDoc
-	if err := doc.DataTo(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode post document: %w", err)
+	q = q.OrderBy("created_at", firestore.Desc).OrderBy("id", firestore.Desc)
+	if f.After != nil {
+		q = q.StartAfter(f.After.CreatedAt, f.After.ID)
 	}
 
-	post := &Post{
-		ID:            data.ID,
-		Title:         data.Title,
-		Description:   data.Description,
-		Category:      data.Category,
-		CoverImageKey: data.CoverImageKey,
-		Content:       data.Content,
-		Status:        data.Status,
-		CreatedAt:     data.CreatedAt,
-		UpdatedAt:     data.UpdatedAt,
-		AuthorID:      data.AuthorID,
-		LikesCount:    data.LikesCount,
-		DislikesCount: data.DislikesCount,
-		CommentsCount: data.CommentsCount,
+	iter := q.Limit(f.Limit).Documents(ctx)
+	defer iter.Stop()
+
+	var posts []PostSummary
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query posts: %w", err)
+		}
+
+		var data firestorePostDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode post document: %w", err)
+		}
+		posts = append(posts, data.toSummary())
 	}
 
-	return post, nil
+	return posts, nil
 }
 
-// Update modifies an existing post's title, metadata, and content in
-// Firestore.
-func (p Post) Update() error {
-	ctx := context.Background()
+// GetPost fetches a single post by its numeric ID.
+func (s *FirestoreStore) GetPost(ctx context.Context, id int64) (*Post, error) {
+	snaps, err := s.client.GetAll(ctx, []*firestore.DocumentRef{
+		s.postsCollection().Doc(strconv.FormatInt(id, 10)),
+		s.contentRef(id),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if !snaps[0].Exists() {
+		return nil, ErrPostNotFound
+	}
 
-	if p.UpdatedAt.IsZero() {
-		p.UpdatedAt = time.Now()
+	var data firestorePostDoc
+	if err := snaps[0].DataTo(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode post document: %w", err)
 	}
 
-	// Only allow "draft" or "published"; default to published.
-	if p.Status != "draft" {
-		p.Status = "published"
+	var content *firestorePostContentDoc
+	if snaps[1].Exists() {
+		content = &firestorePostContentDoc{}
+		if err := snaps[1].DataTo(content); err != nil {
+			return nil, fmt.Errorf("failed to decode post content document: %w", err)
+		}
 	}
 
-	docRef := postsCollection().Doc(strconv.FormatInt(p.ID, 10))
+	post := data.toPost(content)
+	return &post, nil
+}
+
+// UpdatePost modifies an existing post's title, metadata, and content in
+// Firestore. The metadata and body documents are written in one
+// transaction together with rev, after checking the version read in it, and
+// a legacy content field left on the metadata document is removed. An empty
+// p.Slug keeps the current slug.
+func (s *FirestoreStore) UpdatePost(ctx context.Context, p *Post, rev *PostRevision) error {
+	docRef := s.postsCollection().Doc(strconv.FormatInt(p.ID, 10))
 	updates := []firestore.Update{
 		{Path: "title", Value: p.Title},
 		{Path: "description", Value: p.Description},
 		{Path: "category", Value: p.Category},
+		{Path: "tags", Value: p.Tags},
 		{Path: "cover_image_key", Value: p.CoverImageKey},
 		{Path: "status", Value: p.Status},
-		{Path: "content", Value: p.Content},
+		{Path: "publish_at", Value: p.PublishAt},
+		{Path: "unpublish_at", Value: p.UnpublishAt},
+		{Path: "members_only", Value: p.MembersOnly},
+		{Path: "revision_limit", Value: p.RevisionLimit},
+		{Path: "excerpt", Value: p.Excerpt},
+		{Path: "content", Value: firestore.Delete},
 		{Path: "updated_at", Value: p.UpdatedAt},
 	}
+	if p.Slug != "" {
+		updates = append(updates, firestore.Update{Path: "slug", Value: p.Slug})
+	}
+
+	var version int64
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+		var data firestorePostDoc
+		if err := snap.DataTo(&data); err != nil {
+			return fmt.Errorf("failed to decode post document: %w", err)
+		}
+		version = max(data.Version, 1)
+		if err := checkVersion(p.Version, version); err != nil {
+			return err
+		}
 
-	if _, err := docRef.Update(ctx, updates); err != nil {
+		registerSlug, err := s.claimSlug(tx, p.Slug, p.ID)
+		if err != nil {
+			return err
+		}
+		recordRevision := func() error { return nil }
+		if rev != nil {
+			if recordRevision, err = s.prepareRevisions(tx, p.ID, *rev, p.RevisionLimit); err != nil {
+				return err
+			}
+		}
+		versioned := append(updates[:len(updates):len(updates)], firestore.Update{Path: "version", Value: version + 1})
+		if err := tx.Update(docRef, versioned); err != nil {
+			return err
+		}
+		if err := tx.Set(s.contentRef(p.ID), firestorePostContentDoc{Content: p.Content}); err != nil {
+			return err
+		}
+		if err := recordRevision(); err != nil {
+			return err
+		}
+		return registerSlug(p.ID)
+	})
+	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return ErrPostNotFound
 		}
 		return fmt.Errorf("failed to update post: %w", err)
 	}
 
+	p.Version = version + 1
 	return nil
 }
 
-// Delete removes a post and its associated reactions from Firestore.
-func (p Post) Delete() error {
-	ctx := context.Background()
-	client := db.FirestoreClient
-	if client == nil {
-		panic("Firestore client is not initialized")
-	}
-
+// DeletePost removes a post and its associated reactions and comments from
+// Firestore.
+func (s *FirestoreStore) DeletePost(ctx context.Context, id int64) error {
 	// Best-effort cleanup of reactions associated with this post.
-	reactionsIter := client.Collection("post_reactions").Where("post_id", "==", p.ID).Documents(ctx)
+	reactionsIter := s.postReactionsCollection().Where("post_id", "==", id).Documents(ctx)
 	defer reactionsIter.Stop()
 
 	for {
@@ -272,8 +458,26 @@ func (p Post) Delete() error {
 		}
 	}
 
+	// Previous and current slugs become free again.
+	slugsIter := s.postSlugsCollection().Where("post_id", "==", id).Documents(ctx)
+	defer slugsIter.Stop()
+
+	for {
+		doc, err := slugsIter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to iterate slugs for deletion: %w", err)
+		}
+
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return fmt.Errorf("failed to delete slug document: %w", err)
+		}
+	}
+
 	// Best-effort cleanup of comments associated with this post.
-	commentsIter := client.Collection("post_comments").Where("post_id", "==", p.ID).Documents(ctx)
+	commentsIter := s.postCommentsCollection().Where("post_id", "==", id).Documents(ctx)
 	defer commentsIter.Stop()
 
 	for {
@@ -290,12 +494,124 @@ func (p Post) Delete() error {
 		}
 	}
 
-	if _, err := postsCollection().Doc(strconv.FormatInt(p.ID, 10)).Delete(ctx); err != nil {
+	if _, err := s.postsCollection().Doc(strconv.FormatInt(id, 10)).Delete(ctx); err != nil {
 		if status.Code(err) == codes.NotFound {
 			return ErrPostNotFound
 		}
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
+	if _, err := s.contentRef(id).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete post content: %w", err)
+	}
+	if err := s.deleteRevisions(ctx, id); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// ResolvePostSlug returns the post that has or had slug.
+func (s *FirestoreStore) ResolvePostSlug(ctx context.Context, slug string) (int64, error) {
+	snap, err := s.postSlugsCollection().Doc(slug).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return 0, ErrPostNotFound
+		}
+		return 0, fmt.Errorf("failed to resolve post slug: %w", err)
+	}
+
+	var data firestorePostSlugDoc
+	if err := snap.DataTo(&data); err != nil {
+		return 0, fmt.Errorf("failed to decode post slug document: %w", err)
+	}
+	return data.PostID, nil
+}
+
+// ListPostSlugs returns every registered slug, ordered by slug.
+func (s *FirestoreStore) ListPostSlugs(ctx context.Context) ([]PostSlug, error) {
+	iter := s.postSlugsCollection().Documents(ctx)
+	defer iter.Stop()
+
+	var slugs []PostSlug
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate post slugs: %w", err)
+		}
+
+		var data firestorePostSlugDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode post slug document: %w", err)
+		}
+		slugs = append(slugs, PostSlug{Slug: doc.Ref.ID, PostID: data.PostID})
+	}
+	return slugs, nil
+}
+
+// ApplyPostSchedule looks up the scheduled posts and the posts with an
+// expired unpublish_at, then re-checks and changes each one in its own
+// transaction. Both queries use single-field indexes; the conditions they
+// cannot express are checked by applySchedule.
+func (s *FirestoreStore) ApplyPostSchedule(ctx context.Context, now time.Time) ([]int64, error) {
+	seen := make(map[string]bool)
+	var refs []*firestore.DocumentRef
+	for _, q := range []firestore.Query{
+		s.postsCollection().Where("status", "==", "scheduled").Select(),
+		s.postsCollection().Where("unpublish_at", "<=", now).Select(),
+	} {
+		iter := q.Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				iter.Stop()
+				return nil, fmt.Errorf("failed to query scheduled posts: %w", err)
+			}
+			if !seen[doc.Ref.ID] {
+				seen[doc.Ref.ID] = true
+				refs = append(refs, doc.Ref)
+			}
+		}
+		iter.Stop()
+	}
+
+	var changed []int64
+	for _, ref := range refs {
+		var postID int64
+		updated := false
+		err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			snap, err := tx.Get(ref)
+			if err != nil {
+				return err
+			}
+			var data firestorePostDoc
+			if err := snap.DataTo(&data); err != nil {
+				return fmt.Errorf("failed to decode post document: %w", err)
+			}
+
+			p := data.toPost(nil)
+			postID, updated = p.ID, applySchedule(&p, now)
+			if !updated {
+				return nil
+			}
+			return tx.Update(ref, []firestore.Update{
+				{Path: "status", Value: p.Status},
+				{Path: "unpublish_at", Value: p.UnpublishAt},
+				{Path: "created_at", Value: p.CreatedAt},
+				{Path: "version", Value: p.Version},
+			})
+		})
+		if err != nil {
+			return changed, fmt.Errorf("failed to apply schedule of post %s: %w", ref.ID, err)
+		}
+		if updated {
+			changed = append(changed, postID)
+		}
+	}
+	return changed, nil
+}
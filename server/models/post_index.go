@@ -0,0 +1,38 @@
+package models
+
+// PostIndex is a secondary index over posts, such as the full-text search
+// index, that the model layer keeps in step with every post write. Its
+// methods are called after the store write has succeeded and must not block.
+type PostIndex interface {
+	// IndexPost adds p or replaces the indexed copy with the same ID.
+	IndexPost(p Post)
+	RemovePost(id int64)
+	RemoveAllPosts()
+}
+
+// activePostIndex is notified of post writes; nil disables indexing.
+var activePostIndex PostIndex
+
+// SetPostIndex registers the index that post writes are mirrored to. Pass nil
+// to stop indexing.
+func SetPostIndex(ix PostIndex) {
+	activePostIndex = ix
+}
+
+func indexPost(p Post) {
+	if activePostIndex != nil {
+		activePostIndex.IndexPost(p)
+	}
+}
+
+func unindexPost(id int64) {
+	if activePostIndex != nil {
+		activePostIndex.RemovePost(id)
+	}
+}
+
+func unindexAllPosts() {
+	if activePostIndex != nil {
+		activePostIndex.RemoveAllPosts()
+	}
+}
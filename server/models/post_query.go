@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultPostPageSize is used when a PostFilter has no Limit.
+	DefaultPostPageSize = 20
+	// MaxPostPageSize caps PostFilter.Limit.
+	MaxPostPageSize = 100
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PostCursor marks the last post of a page. Posts are listed newest first
+// with the ID as a tie-breaker, so the next page starts strictly after
+// (CreatedAt, ID).
+type PostCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"id"`
+}
+
+// Encode returns the opaque string handed to API clients.
+func (c PostCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePostCursor parses a cursor produced by PostCursor.Encode.
+func DecodePostCursor(s string) (*PostCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c PostCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// PostFilter selects a page of posts. Zero values mean "no filter".
+type PostFilter struct {
+	Category string
+	// Tag selects posts that carry this normalized tag.
+	Tag      string
+	Status   string
+	AuthorID int64
+	// CreatedAfter and CreatedBefore bound created_at: CreatedAfter is
+	// inclusive and CreatedBefore is exclusive.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// Limit is the page size, between 1 and MaxPostPageSize.
+	Limit int
+	// After continues the listing after a previous page.
+	After *PostCursor
+}
+
+// PostPage is one page of a post listing. NextCursor is empty on the last
+// page.
+type PostPage struct {
+	Posts      []PostSummary `json:"posts"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// postBefore reports whether a sorts before b in listing order (newest
+// first, higher ID first on equal timestamps).
+func postBefore(a, b PostCursor) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+func (p PostSummary) cursor() PostCursor {
+	return PostCursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+// QueryPosts returns one page of post summaries matching f, newest first. The
+// filtering and the page limit are applied by the store query, so only the
+// requested page is read, and post bodies are never loaded.
+func QueryPosts(ctx context.Context, f PostFilter) (*PostPage, error) {
+	if f.Limit <= 0 {
+		f.Limit = DefaultPostPageSize
+	}
+	if f.Limit > MaxPostPageSize {
+		f.Limit = MaxPostPageSize
+	}
+	pageSize := f.Limit
+
+	// Ask for one extra post to learn whether another page exists.
+	f.Limit++
+
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	posts, err := store().QueryPosts(ctx, f)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+
+	page := &PostPage{Posts: posts}
+	if len(posts) > pageSize {
+		page.Posts = posts[:pageSize]
+		page.NextCursor = page.Posts[pageSize-1].cursor().Encode()
+	}
+	if page.Posts == nil {
+		page.Posts = []PostSummary{}
+	}
+	return page, nil
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Paging through QueryPosts visits every matching post exactly once, newest
+// first, and the filters are applied by the store.
+func TestQueryPostsPaginatesAndFilters(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		// Posts 3 and 4 share a timestamp so the ID tie-breaker matters.
+		specs := []struct {
+			category string
+			status   string
+			author   int64
+			offset   time.Duration
+		}{
+			{"go", "published", 1, 0},
+			{"go", "draft", 1, time.Hour},
+			{"news", "published", 2, 2 * time.Hour},
+			{"go", "published", 2, 3 * time.Hour},
+			{"go", "published", 1, 3 * time.Hour},
+		}
+		for _, name := range []string{"go", "news"} {
+			c := &Category{Name: name}
+			if err := c.Save(ctx); err != nil {
+				t.Fatalf("failed to create category: %v", err)
+			}
+		}
+		for _, spec := range specs {
+			p := &Post{
+				Title:     "Post",
+				Content:   "Body",
+				Category:  spec.category,
+				Status:    spec.status,
+				AuthorID:  spec.author,
+				CreatedAt: base.Add(spec.offset),
+			}
+			if err := p.Save(ctx); err != nil {
+				t.Fatalf("failed to create post: %v", err)
+			}
+		}
+
+		collect := func(f PostFilter) []int64 {
+			t.Helper()
+			var ids []int64
+			for pages := 0; ; pages++ {
+				if pages > len(specs) {
+					t.Fatalf("pagination did not terminate")
+				}
+				page, err := QueryPosts(ctx, f)
+				if err != nil {
+					t.Fatalf("QueryPosts failed: %v", err)
+				}
+				for _, p := range page.Posts {
+					ids = append(ids, p.ID)
+				}
+				if page.NextCursor == "" {
+					return ids
+				}
+				f.After, err = DecodePostCursor(page.NextCursor)
+				if err != nil {
+					t.Fatalf("failed to decode cursor: %v", err)
+				}
+			}
+		}
+
+		cases := []struct {
+			name   string
+			filter PostFilter
+			want   []int64
+		}{
+			{"all", PostFilter{Limit: 2}, []int64{5, 4, 3, 2, 1}},
+			{"category", PostFilter{Limit: 2, Category: "go"}, []int64{5, 4, 2, 1}},
+			{"published go", PostFilter{Limit: 1, Category: "go", Status: "published"}, []int64{5, 4, 1}},
+			{"author", PostFilter{Limit: 10, AuthorID: 2}, []int64{4, 3}},
+			{"date range", PostFilter{
+				Limit:         2,
+				CreatedAfter:  base.Add(time.Hour),
+				CreatedBefore: base.Add(3 * time.Hour),
+			}, []int64{3, 2}},
+		}
+		for _, tc := range cases {
+			got := collect(tc.filter)
+			if len(got) != len(tc.want) {
+				t.Fatalf("%s: expected posts %v, got %v", tc.name, tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("%s: expected posts %v, got %v", tc.name, tc.want, got)
+				}
+			}
+		}
+	})
+}
+
+// Listings return summaries with an excerpt; the full content is only
+// returned for a single post.
+func TestQueryPostsReturnsSummaries(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		content := "# Hello\n\nThis is the **first** paragraph.\n\n" + strings.Repeat("More words follow here. ", 20)
+		p := &Post{Title: "Long post", Content: content}
+		if err := p.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+
+		page, err := QueryPosts(ctx, PostFilter{})
+		if err != nil {
+			t.Fatalf("QueryPosts failed: %v", err)
+		}
+		if len(page.Posts) != 1 {
+			t.Fatalf("expected 1 post, got %d", len(page.Posts))
+		}
+		excerpt := page.Posts[0].Excerpt
+		if !strings.HasPrefix(excerpt, "Hello This is the first paragraph. More words") || !strings.HasSuffix(excerpt, "…") {
+			t.Errorf("unexpected excerpt %q", excerpt)
+		}
+		if n := len([]rune(excerpt)); n > excerptLength+1 {
+			t.Errorf("excerpt has %d characters, want at most %d", n, excerptLength+1)
+		}
+
+		full, err := GetPostByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("GetPostByID failed: %v", err)
+		}
+		if full.Content != content || full.Excerpt != excerpt {
+			t.Errorf("expected full post with content and excerpt, got %+v", full)
+		}
+
+		full.Content = "Short now."
+		if err := full.Update(ctx, full.AuthorID); err != nil {
+			t.Fatalf("failed to update post: %v", err)
+		}
+		page, err = QueryPosts(ctx, PostFilter{})
+		if err != nil {
+			t.Fatalf("QueryPosts failed: %v", err)
+		}
+		if got := page.Posts[0].Excerpt; got != "Short now." {
+			t.Errorf("expected excerpt to follow the update, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,400 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const sqlitePostColumns = `id, slug, title, description, category, cover_image_key, content, excerpt, status,
+	publish_at, unpublish_at, members_only, revision_limit, version, created_at, updated_at, author_id, likes_count, dislikes_count, comments_count`
+
+// sqlitePostSummaryColumns leaves out the post body. Rows written before the
+// excerpt column existed have an empty excerpt; only for those the content
+// is read so the excerpt can be computed.
+const sqlitePostSummaryColumns = `id, slug, title, description, category, cover_image_key, excerpt,
+	CASE WHEN excerpt = '' THEN content ELSE '' END, status,
+	publish_at, unpublish_at, members_only, created_at, updated_at, author_id, likes_count, dislikes_count, comments_count`
+
+func scanSQLitePost(row rowScanner) (Post, error) {
+	var p Post
+	err := row.Scan(
+		&p.ID, &p.Slug, &p.Title, &p.Description, &p.Category, &p.CoverImageKey, &p.Content, &p.Excerpt, &p.Status,
+		&p.PublishAt, &p.UnpublishAt, &p.MembersOnly, &p.RevisionLimit, &p.Version, &p.CreatedAt, &p.UpdatedAt, &p.AuthorID, &p.LikesCount, &p.DislikesCount, &p.CommentsCount,
+	)
+	if err == nil && p.Excerpt == "" {
+		p.Excerpt = PostExcerpt(p.Content)
+	}
+	return p, err
+}
+
+func scanSQLitePostSummary(row rowScanner) (PostSummary, error) {
+	var p PostSummary
+	var legacyContent string
+	err := row.Scan(
+		&p.ID, &p.Slug, &p.Title, &p.Description, &p.Category, &p.CoverImageKey, &p.Excerpt, &legacyContent, &p.Status,
+		&p.PublishAt, &p.UnpublishAt, &p.MembersOnly, &p.CreatedAt, &p.UpdatedAt, &p.AuthorID, &p.LikesCount, &p.DislikesCount, &p.CommentsCount,
+	)
+	if err == nil && p.Excerpt == "" {
+		p.Excerpt = PostExcerpt(legacyContent)
+	}
+	return p, err
+}
+
+// sqliteNullTime returns t in UTC for a nullable timestamp column, or nil.
+func sqliteNullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// claimSQLiteSlug registers slug for postID inside tx, failing with
+// ErrSlugTaken when another post holds it. An empty slug is ignored.
+func claimSQLiteSlug(ctx context.Context, tx *sql.Tx, slug string, postID int64) error {
+	if slug == "" {
+		return nil
+	}
+
+	var owner int64
+	err := tx.QueryRowContext(ctx, `SELECT post_id FROM post_slugs WHERE slug = ?`, slug).Scan(&owner)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, err := tx.ExecContext(ctx, `INSERT INTO post_slugs (slug, post_id) VALUES (?, ?)`, slug, postID); err != nil {
+			return fmt.Errorf("failed to register post slug: %w", err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to look up post slug: %w", err)
+	case owner != postID:
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+// SavePost inserts a new post and assigns it the autoincrement ID. Post
+// timestamps are stored in UTC so that created_at sorts and compares
+// correctly as text.
+func (s *SQLiteStore) SavePost(ctx context.Context, p *Post) error {
+	var id int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO posts (slug, title, description, category, cover_image_key, content, excerpt, status, publish_at, unpublish_at, members_only, revision_limit, created_at, updated_at, author_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.Slug, p.Title, p.Description, p.Category, p.CoverImageKey, p.Content, p.Excerpt, p.Status,
+			sqliteNullTime(p.PublishAt), sqliteNullTime(p.UnpublishAt), p.MembersOnly, p.RevisionLimit, p.CreatedAt.UTC(), p.UpdatedAt.UTC(), p.AuthorID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save post: %w", err)
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to read new post id: %w", err)
+		}
+		if err := setSQLitePostTags(ctx, tx, id, p.Tags); err != nil {
+			return err
+		}
+		return claimSQLiteSlug(ctx, tx, p.Slug, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.ID = id
+	p.Version = 1
+	p.LikesCount = 0
+	p.DislikesCount = 0
+	p.CommentsCount = 0
+	return nil
+}
+
+// ListPosts returns all posts ordered by creation time (newest first).
+func (s *SQLiteStore) ListPosts(ctx context.Context) ([]Post, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqlitePostColumns+` FROM posts ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []Post
+	for rows.Next() {
+		p, err := scanSQLitePost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode post row: %w", err)
+		}
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate posts: %w", err)
+	}
+	rows.Close()
+
+	tags, err := loadSQLitePostTags(ctx, s.db, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		posts[i].Tags = orEmptyTags(tags[posts[i].ID])
+	}
+	return posts, nil
+}
+
+// QueryPosts returns a page of post summaries matching f. Timestamps are
+// stored in UTC, so created_at compares correctly as text.
+func (s *SQLiteStore) QueryPosts(ctx context.Context, f PostFilter) ([]PostSummary, error) {
+	var where []string
+	var args []interface{}
+
+	if f.Category != "" {
+		where = append(where, `category = ?`)
+		args = append(args, f.Category)
+	}
+	if f.Tag != "" {
+		where = append(where, `id IN (SELECT post_id FROM post_tags WHERE tag = ?)`)
+		args = append(args, f.Tag)
+	}
+	if f.Status != "" {
+		where = append(where, `status = ?`)
+		args = append(args, f.Status)
+	}
+	if f.AuthorID != 0 {
+		where = append(where, `author_id = ?`)
+		args = append(args, f.AuthorID)
+	}
+	if !f.CreatedAfter.IsZero() {
+		where = append(where, `created_at >= ?`)
+		args = append(args, f.CreatedAfter.UTC())
+	}
+	if !f.CreatedBefore.IsZero() {
+		where = append(where, `created_at < ?`)
+		args = append(args, f.CreatedBefore.UTC())
+	}
+	if f.After != nil {
+		where = append(where, `(created_at < ? OR (created_at = ? AND id < ?))`)
+		args = append(args, f.After.CreatedAt.UTC(), f.After.CreatedAt.UTC(), f.After.ID)
+	}
+
+	query := `SELECT ` + sqlitePostSummaryColumns + ` FROM posts`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, f.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []PostSummary
+	var ids []int64
+	for rows.Next() {
+		p, err := scanSQLitePostSummary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode post row: %w", err)
+		}
+		posts = append(posts, p)
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate posts: %w", err)
+	}
+	rows.Close()
+
+	tags, err := loadSQLitePostTags(ctx, s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		posts[i].Tags = orEmptyTags(tags[posts[i].ID])
+	}
+	return posts, nil
+}
+
+// GetPost fetches a single post by its numeric ID.
+func (s *SQLiteStore) GetPost(ctx context.Context, id int64) (*Post, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqlitePostColumns+` FROM posts WHERE id = ?`, id)
+	p, err := scanSQLitePost(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+
+	tags, err := loadSQLitePostTags(ctx, s.db, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	p.Tags = orEmptyTags(tags[id])
+	return &p, nil
+}
+
+// UpdatePost overwrites the editable fields of an existing post and records
+// rev in the same transaction, in which the stored version is also checked
+// against p.Version. An empty p.Slug keeps the current slug.
+func (s *SQLiteStore) UpdatePost(ctx context.Context, p *Post, rev *PostRevision) error {
+	var version int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT version FROM posts WHERE id = ?`, p.ID).Scan(&version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get post version: %w", err)
+		}
+		if err := checkVersion(p.Version, version); err != nil {
+			return err
+		}
+
+		if rev != nil {
+			if err := recordSQLiteRevision(ctx, tx, p.ID, *rev, p.RevisionLimit); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE posts
+			SET slug = COALESCE(NULLIF(?, ''), slug), title = ?, description = ?, category = ?, cover_image_key = ?,
+				status = ?, publish_at = ?, unpublish_at = ?, members_only = ?, revision_limit = ?, content = ?, excerpt = ?, updated_at = ?,
+				version = ?
+			WHERE id = ?`,
+			p.Slug, p.Title, p.Description, p.Category, p.CoverImageKey, p.Status, sqliteNullTime(p.PublishAt), sqliteNullTime(p.UnpublishAt), p.MembersOnly,
+			p.RevisionLimit, p.Content, p.Excerpt, p.UpdatedAt.UTC(), version+1, p.ID,
+		); err != nil {
+			return fmt.Errorf("failed to update post: %w", err)
+		}
+
+		if err := setSQLitePostTags(ctx, tx, p.ID, p.Tags); err != nil {
+			return err
+		}
+		return claimSQLiteSlug(ctx, tx, p.Slug, p.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.Version = version + 1
+	return nil
+}
+
+// DeletePost removes a post together with its reactions, comments and
+// revisions in one transaction.
+func (s *SQLiteStore) DeletePost(ctx context.Context, id int64) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_reactions WHERE post_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete post reactions: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_comments WHERE post_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete post comments: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_slugs WHERE post_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete post slugs: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_tags WHERE post_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete post tags: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_revisions WHERE post_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete post revisions: %w", err)
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM posts WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete post: %w", err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return ErrPostNotFound
+		}
+		return nil
+	})
+}
+
+// ResolvePostSlug returns the post that has or had slug.
+func (s *SQLiteStore) ResolvePostSlug(ctx context.Context, slug string) (int64, error) {
+	var postID int64
+	err := s.db.QueryRowContext(ctx, `SELECT post_id FROM post_slugs WHERE slug = ?`, slug).Scan(&postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrPostNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve post slug: %w", err)
+	}
+	return postID, nil
+}
+
+// ListPostSlugs returns every registered slug, ordered by slug.
+func (s *SQLiteStore) ListPostSlugs(ctx context.Context) ([]PostSlug, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT slug, post_id FROM post_slugs ORDER BY slug`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post slugs: %w", err)
+	}
+	defer rows.Close()
+
+	var slugs []PostSlug
+	for rows.Next() {
+		var ps PostSlug
+		if err := rows.Scan(&ps.Slug, &ps.PostID); err != nil {
+			return nil, fmt.Errorf("failed to decode post slug row: %w", err)
+		}
+		slugs = append(slugs, ps)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate post slugs: %w", err)
+	}
+	return slugs, nil
+}
+
+// ApplyPostSchedule publishes and unpublishes the posts that are due in one
+// transaction. Timestamps are stored in UTC, so they compare correctly as
+// text.
+func (s *SQLiteStore) ApplyPostSchedule(ctx context.Context, now time.Time) ([]int64, error) {
+	var changed []int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id FROM posts
+			WHERE (status = 'scheduled' AND publish_at <= ?) OR (status = 'published' AND unpublish_at <= ?)
+			ORDER BY id`,
+			now.UTC(), now.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to query scheduled posts: %w", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to decode scheduled post row: %w", err)
+			}
+			changed = append(changed, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate scheduled posts: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE posts SET status = 'published', created_at = publish_at, version = version + 1
+			WHERE status = 'scheduled' AND publish_at <= ?`,
+			now.UTC(),
+		); err != nil {
+			return fmt.Errorf("failed to publish scheduled posts: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE posts SET status = 'draft', unpublish_at = NULL, version = version + 1
+			WHERE status = 'published' AND unpublish_at <= ?`,
+			now.UTC(),
+		); err != nil {
+			return fmt.Errorf("failed to unpublish expired posts: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidReaction is returned when a caller tries to set a reaction type
+// other than "like" or "dislike".
+var ErrInvalidReaction = errors.New("invalid reaction type")
+
+const (
+	ReactionLike    = "like"
+	ReactionDislike = "dislike"
+)
+
+// PostReaction is a single user's stored reaction to a post.
+type PostReaction struct {
+	UserID   int64  `json:"user_id"`
+	PostID   int64  `json:"post_id"`
+	Reaction string `json:"reaction"`
+}
+
+// PostReactionResult represents the outcome of updating a user's reaction
+// for a given post, including the aggregate like/dislike counters.
+type PostReactionResult struct {
+	LikesCount    int64  `json:"likes_count"`
+	DislikesCount int64  `json:"dislikes_count"`
+	UserReaction  string `json:"user_reaction"`
+}
+
+// SetPostReaction records or toggles a reaction (like or dislike) from a
+// specific user on a specific post. A user can have at most one reaction per
+// post; calling this function with the same reaction twice will remove the
+// reaction (toggle off). It returns the up-to-date aggregate counters and the
+// user's effective reaction after the change.
+func SetPostReaction(ctx context.Context, userID, postID int64, reaction string) (*PostReactionResult, error) {
+	if reaction != ReactionLike && reaction != ReactionDislike {
+		return nil, ErrInvalidReaction
+	}
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	result, err := store().SetPostReaction(ctx, userID, postID, reaction)
+	return result, storeError(ctx, err)
+}
+
+// applyReaction implements the reaction state machine shared by every store.
+// Given the user's existing reaction ("" for none) and the requested one, it
+// adjusts the counters in result and sets result.UserReaction to the user's
+// effective reaction afterwards ("" when toggled off).
+func applyReaction(existing, reaction string, result *PostReactionResult) {
+	switch {
+	case existing == "":
+		// No existing reaction for this user/post; add one.
+		if reaction == ReactionLike {
+			result.LikesCount++
+		} else {
+			result.DislikesCount++
+		}
+		result.UserReaction = reaction
+
+	case existing == reaction:
+		// Same reaction clicked again: toggle off.
+		if reaction == ReactionLike {
+			if result.LikesCount > 0 {
+				result.LikesCount--
+			}
+		} else {
+			if result.DislikesCount > 0 {
+				result.DislikesCount--
+			}
+		}
+		result.UserReaction = ""
+
+	default:
+		// Switch from like->dislike or dislike->like.
+		if existing == ReactionLike {
+			if result.LikesCount > 0 {
+				result.LikesCount--
+			}
+			result.DislikesCount++
+		} else {
+			if result.DislikesCount > 0 {
+				result.DislikesCount--
+			}
+			result.LikesCount++
+		}
+		result.UserReaction = reaction
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SetPostReaction applies a reaction toggle and the matching counter update
+// in a single SQL transaction.
+func (s *SQLiteStore) SetPostReaction(ctx context.Context, userID, postID int64, reaction string) (*PostReactionResult, error) {
+	var result *PostReactionResult
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		next := &PostReactionResult{}
+		err := tx.QueryRowContext(ctx, `SELECT likes_count, dislikes_count FROM posts WHERE id = ?`, postID).
+			Scan(&next.LikesCount, &next.DislikesCount)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load post in reaction transaction: %w", err)
+		}
+
+		existingReaction := ""
+		err = tx.QueryRowContext(ctx, `SELECT reaction FROM post_reactions WHERE user_id = ? AND post_id = ?`, userID, postID).
+			Scan(&existingReaction)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to load reaction in transaction: %w", err)
+		}
+
+		applyReaction(existingReaction, reaction, next)
+
+		if next.UserReaction == "" {
+			_, err = tx.ExecContext(ctx, `DELETE FROM post_reactions WHERE user_id = ? AND post_id = ?`, userID, postID)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO post_reactions (user_id, post_id, reaction) VALUES (?, ?, ?)
+				ON CONFLICT (user_id, post_id) DO UPDATE SET reaction = excluded.reaction`,
+				userID, postID, next.UserReaction,
+			)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write reaction: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE posts SET likes_count = ?, dislikes_count = ? WHERE id = ?`,
+			next.LikesCount, next.DislikesCount, postID); err != nil {
+			return fmt.Errorf("failed to update post reaction counters: %w", err)
+		}
+
+		result = next
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultRevisionLimit is how many revisions are kept for a post whose
+	// RevisionLimit is zero.
+	DefaultRevisionLimit = 50
+	// MaxRevisionLimit is the largest RevisionLimit a post can have.
+	MaxRevisionLimit = 1000
+)
+
+var (
+	// ErrRevisionNotFound is returned when a post has no revision with the
+	// requested number, either because it never existed or because it was
+	// dropped to keep the post's revision limit.
+	ErrRevisionNotFound = errors.New("revision not found")
+
+	// ErrInvalidRevisionLimit is returned when a post's RevisionLimit is
+	// negative or larger than MaxRevisionLimit.
+	ErrInvalidRevisionLimit = errors.New("invalid revision limit")
+)
+
+// PostRevision is an immutable snapshot of a post, recorded every time the
+// post is updated. Revisions are numbered per post from 1, and numbers are
+// never reused, even once old revisions are dropped.
+//
+// A post's first update also records the post as it was before, with the
+// post's author as editor, so the original text is never lost.
+//
+// RestoredFrom is the number of the revision this one restored, or zero.
+type PostRevision struct {
+	PostID        int64      `json:"post_id"`
+	Number        int        `json:"number"`
+	EditorID      int64      `json:"editor_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RestoredFrom  int        `json:"restored_from,omitempty"`
+	Slug          string     `json:"slug"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Category      string     `json:"category"`
+	Tags          []string   `json:"tags"`
+	CoverImageKey string     `json:"cover_image_key"`
+	Content       string     `json:"content,omitempty"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt   *time.Time `json:"unpublish_at,omitempty"`
+	MembersOnly   bool       `json:"members_only"`
+}
+
+// RevisionStore reads the revisions that PostStore.UpdatePost records.
+type RevisionStore interface {
+	// ListPostRevisions returns every kept revision of the post, in any
+	// order. A post without revisions, or no post at all, gives an empty
+	// list.
+	ListPostRevisions(ctx context.Context, postID int64) ([]PostRevision, error)
+	// GetPostRevision returns one revision, or ErrRevisionNotFound.
+	GetPostRevision(ctx context.Context, postID int64, number int) (*PostRevision, error)
+}
+
+// newPostRevision returns a snapshot of p. The store numbers it.
+func newPostRevision(p Post, editorID int64, restoredFrom int) PostRevision {
+	return PostRevision{
+		PostID:        p.ID,
+		EditorID:      editorID,
+		CreatedAt:     p.UpdatedAt,
+		RestoredFrom:  restoredFrom,
+		Slug:          p.Slug,
+		Title:         p.Title,
+		Description:   p.Description,
+		Category:      p.Category,
+		Tags:          orEmptyTags(p.Tags),
+		CoverImageKey: p.CoverImageKey,
+		Content:       p.Content,
+		Status:        p.Status,
+		PublishAt:     p.PublishAt,
+		UnpublishAt:   p.UnpublishAt,
+		MembersOnly:   p.MembersOnly,
+	}
+}
+
+// EffectiveRevisionLimit returns how many revisions are kept for a post
+// with the given RevisionLimit.
+func EffectiveRevisionLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultRevisionLimit
+	}
+	return min(limit, MaxRevisionLimit)
+}
+
+func checkRevisionLimit(limit int) error {
+	if limit < 0 || limit > MaxRevisionLimit {
+		return fmt.Errorf("%w: keep between 1 and %d revisions, or 0 for the default", ErrInvalidRevisionLimit, MaxRevisionLimit)
+	}
+	return nil
+}
+
+// planRevisions works out how a store records rev for a post that already
+// has revisions with the given numbers and was before the update. It
+// returns the revisions to add, numbered and oldest first, which start with
+// a snapshot of before when the post has no revisions yet, and the numbers
+// of the oldest revisions to drop so that only limit are kept.
+func planRevisions(numbers []int, before Post, rev PostRevision, limit int) (add []PostRevision, drop []int) {
+	sorted := append([]int(nil), numbers...)
+	sort.Ints(sorted)
+
+	next := 1
+	if len(sorted) > 0 {
+		next = sorted[len(sorted)-1] + 1
+	} else {
+		baseline := newPostRevision(before, before.AuthorID, 0)
+		baseline.Number = next
+		add = append(add, baseline)
+		next++
+	}
+	rev.Number = next
+	add = append(add, rev)
+
+	limit = EffectiveRevisionLimit(limit)
+	for _, n := range add {
+		sorted = append(sorted, n.Number)
+	}
+	if excess := len(sorted) - limit; excess > 0 {
+		for _, n := range sorted[:excess] {
+			if n >= add[0].Number {
+				// A new revision that does not fit is simply not added.
+				add = add[1:]
+				continue
+			}
+			drop = append(drop, n)
+		}
+	}
+	return add, drop
+}
+
+// ListPostRevisions returns the kept revisions of a post, newest first.
+func ListPostRevisions(ctx context.Context, postID int64) ([]PostRevision, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	revisions, err := store().ListPostRevisions(ctx, postID)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number > revisions[j].Number
+	})
+	return revisions, nil
+}
+
+// GetPostRevision returns revision number of the post, or
+// ErrRevisionNotFound.
+func GetPostRevision(ctx context.Context, postID int64, number int) (*PostRevision, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	rev, err := store().GetPostRevision(ctx, postID, number)
+	return rev, storeError(ctx, err)
+}
+
+// RestorePostRevision brings the post back to revision number, as an update
+// by editorID that is recorded as a new revision. The post keeps its
+// current status and schedule, so restoring old text never publishes or
+// unpublishes it. The restored slug and category go through the same
+// checks as in Update. A non-zero version is the version the caller expects
+// the post to have, as in Update; ErrVersionConflict is returned when the
+// post has a different one or changes while it is being restored.
+func RestorePostRevision(ctx context.Context, postID int64, number int, editorID, version int64) (*Post, error) {
+	rev, err := GetPostRevision(ctx, postID, number)
+	if err != nil {
+		return nil, err
+	}
+	current, err := GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := *current
+	p.Slug = rev.Slug
+	p.Title = rev.Title
+	p.Description = rev.Description
+	p.Category = rev.Category
+	p.Tags = rev.Tags
+	p.CoverImageKey = rev.CoverImageKey
+	p.Content = rev.Content
+	p.MembersOnly = rev.MembersOnly
+	p.UpdatedAt = time.Time{}
+	if version != 0 {
+		p.Version = version
+	}
+	if err := p.update(ctx, editorID, number); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
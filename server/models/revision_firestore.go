@@ -0,0 +1,199 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firestoreRevisionDoc is the Firestore representation of a post revision.
+type firestoreRevisionDoc struct {
+	PostID        int64      `firestore:"post_id"`
+	Number        int        `firestore:"number"`
+	EditorID      int64      `firestore:"editor_id"`
+	CreatedAt     time.Time  `firestore:"created_at"`
+	RestoredFrom  int        `firestore:"restored_from"`
+	Slug          string     `firestore:"slug"`
+	Title         string     `firestore:"title"`
+	Description   string     `firestore:"description"`
+	Category      string     `firestore:"category"`
+	Tags          []string   `firestore:"tags"`
+	CoverImageKey string     `firestore:"cover_image_key"`
+	Content       string     `firestore:"content"`
+	Status        string     `firestore:"status"`
+	PublishAt     *time.Time `firestore:"publish_at"`
+	UnpublishAt   *time.Time `firestore:"unpublish_at"`
+	MembersOnly   bool       `firestore:"members_only"`
+}
+
+func newFirestoreRevisionDoc(r PostRevision) firestoreRevisionDoc {
+	return firestoreRevisionDoc{
+		PostID:        r.PostID,
+		Number:        r.Number,
+		EditorID:      r.EditorID,
+		CreatedAt:     r.CreatedAt,
+		RestoredFrom:  r.RestoredFrom,
+		Slug:          r.Slug,
+		Title:         r.Title,
+		Description:   r.Description,
+		Category:      r.Category,
+		Tags:          r.Tags,
+		CoverImageKey: r.CoverImageKey,
+		Content:       r.Content,
+		Status:        r.Status,
+		PublishAt:     r.PublishAt,
+		UnpublishAt:   r.UnpublishAt,
+		MembersOnly:   r.MembersOnly,
+	}
+}
+
+func (d firestoreRevisionDoc) toRevision() PostRevision {
+	return PostRevision{
+		PostID:        d.PostID,
+		Number:        d.Number,
+		EditorID:      d.EditorID,
+		CreatedAt:     d.CreatedAt,
+		RestoredFrom:  d.RestoredFrom,
+		Slug:          d.Slug,
+		Title:         d.Title,
+		Description:   d.Description,
+		Category:      d.Category,
+		Tags:          orEmptyTags(d.Tags),
+		CoverImageKey: d.CoverImageKey,
+		Content:       d.Content,
+		Status:        d.Status,
+		PublishAt:     d.PublishAt,
+		UnpublishAt:   d.UnpublishAt,
+		MembersOnly:   d.MembersOnly,
+	}
+}
+
+func (s *FirestoreStore) revisionRef(postID int64, number int) *firestore.DocumentRef {
+	return s.postRevisionsCollection().Doc(strconv.FormatInt(postID, 10) + "-" + strconv.Itoa(number))
+}
+
+// prepareRevisions reads the post with postID and its revision numbers
+// inside tx, as planRevisions needs them. Like claimSlug it must run before
+// the transaction's first write; the returned function writes the planned
+// revisions and drops the old ones.
+func (s *FirestoreStore) prepareRevisions(tx *firestore.Transaction, postID int64, rev PostRevision, limit int) (func() error, error) {
+	snaps, err := tx.GetAll([]*firestore.DocumentRef{
+		s.postsCollection().Doc(strconv.FormatInt(postID, 10)),
+		s.contentRef(postID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if !snaps[0].Exists() {
+		return nil, ErrPostNotFound
+	}
+	var data firestorePostDoc
+	if err := snaps[0].DataTo(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode post document: %w", err)
+	}
+	var content *firestorePostContentDoc
+	if snaps[1].Exists() {
+		content = &firestorePostContentDoc{}
+		if err := snaps[1].DataTo(content); err != nil {
+			return nil, fmt.Errorf("failed to decode post content document: %w", err)
+		}
+	}
+
+	docs, err := tx.Documents(s.postRevisionsCollection().Where("post_id", "==", postID).Select("number")).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post revisions: %w", err)
+	}
+	numbers := make([]int, 0, len(docs))
+	for _, doc := range docs {
+		var r firestoreRevisionDoc
+		if err := doc.DataTo(&r); err != nil {
+			return nil, fmt.Errorf("failed to decode post revision document: %w", err)
+		}
+		numbers = append(numbers, r.Number)
+	}
+
+	add, drop := planRevisions(numbers, data.toPost(content), rev, limit)
+	return func() error {
+		for _, r := range add {
+			r.PostID = postID
+			if err := tx.Create(s.revisionRef(postID, r.Number), newFirestoreRevisionDoc(r)); err != nil {
+				return fmt.Errorf("failed to save post revision: %w", err)
+			}
+		}
+		for _, n := range drop {
+			if err := tx.Delete(s.revisionRef(postID, n)); err != nil {
+				return fmt.Errorf("failed to delete post revision: %w", err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// ListPostRevisions returns the kept revisions of a post; the caller sorts
+// them.
+func (s *FirestoreStore) ListPostRevisions(ctx context.Context, postID int64) ([]PostRevision, error) {
+	iter := s.postRevisionsCollection().Where("post_id", "==", postID).Documents(ctx)
+	defer iter.Stop()
+
+	var revisions []PostRevision
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate post revisions: %w", err)
+		}
+
+		var data firestoreRevisionDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode post revision document: %w", err)
+		}
+		revisions = append(revisions, data.toRevision())
+	}
+	return revisions, nil
+}
+
+// GetPostRevision fetches post_revisions/<post id>-<number>.
+func (s *FirestoreStore) GetPostRevision(ctx context.Context, postID int64, number int) (*PostRevision, error) {
+	snap, err := s.revisionRef(postID, number).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post revision: %w", err)
+	}
+
+	var data firestoreRevisionDoc
+	if err := snap.DataTo(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode post revision document: %w", err)
+	}
+	r := data.toRevision()
+	return &r, nil
+}
+
+// deleteRevisions removes every revision of a deleted post.
+func (s *FirestoreStore) deleteRevisions(ctx context.Context, postID int64) error {
+	iter := s.postRevisionsCollection().Where("post_id", "==", postID).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to iterate revisions for deletion: %w", err)
+		}
+
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return fmt.Errorf("failed to delete revision document: %w", err)
+		}
+	}
+}
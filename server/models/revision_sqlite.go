@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tags are slugs, which never contain commas, so a revision keeps them in
+// one comma-separated column.
+const sqliteRevisionColumns = `post_id, number, editor_id, created_at, restored_from, slug, title, description, category,
+	tags, cover_image_key, content, status, publish_at, unpublish_at, members_only`
+
+func scanSQLiteRevision(row rowScanner) (PostRevision, error) {
+	var r PostRevision
+	var tags string
+	err := row.Scan(
+		&r.PostID, &r.Number, &r.EditorID, &r.CreatedAt, &r.RestoredFrom, &r.Slug, &r.Title, &r.Description, &r.Category,
+		&tags, &r.CoverImageKey, &r.Content, &r.Status, &r.PublishAt, &r.UnpublishAt, &r.MembersOnly,
+	)
+	r.Tags = []string{}
+	if tags != "" {
+		r.Tags = strings.Split(tags, ",")
+	}
+	return r, err
+}
+
+// recordSQLiteRevision records rev for the post with postID inside tx, before
+// the post row is updated, as planned by planRevisions.
+func recordSQLiteRevision(ctx context.Context, tx *sql.Tx, postID int64, rev PostRevision, limit int) error {
+	before, err := scanSQLitePost(tx.QueryRowContext(ctx, `SELECT `+sqlitePostColumns+` FROM posts WHERE id = ?`, postID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPostNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get post: %w", err)
+	}
+	tags, err := loadSQLitePostTags(ctx, tx, []int64{postID})
+	if err != nil {
+		return err
+	}
+	before.Tags = tags[postID]
+
+	rows, err := tx.QueryContext(ctx, `SELECT number FROM post_revisions WHERE post_id = ?`, postID)
+	if err != nil {
+		return fmt.Errorf("failed to query post revisions: %w", err)
+	}
+	defer rows.Close()
+	var numbers []int
+	for rows.Next() {
+		var n int
+		if err := rows.Scan(&n); err != nil {
+			return fmt.Errorf("failed to decode post revision row: %w", err)
+		}
+		numbers = append(numbers, n)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate post revisions: %w", err)
+	}
+
+	add, drop := planRevisions(numbers, before, rev, limit)
+	for _, r := range add {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO post_revisions (`+sqliteRevisionColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			postID, r.Number, r.EditorID, r.CreatedAt.UTC(), r.RestoredFrom, r.Slug, r.Title, r.Description, r.Category,
+			strings.Join(r.Tags, ","), r.CoverImageKey, r.Content, r.Status, sqliteNullTime(r.PublishAt), sqliteNullTime(r.UnpublishAt), r.MembersOnly,
+		); err != nil {
+			return fmt.Errorf("failed to save post revision: %w", err)
+		}
+	}
+	for _, n := range drop {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_revisions WHERE post_id = ? AND number = ?`, postID, n); err != nil {
+			return fmt.Errorf("failed to delete post revision: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListPostRevisions returns the kept revisions of a post.
+func (s *SQLiteStore) ListPostRevisions(ctx context.Context, postID int64) ([]PostRevision, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteRevisionColumns+` FROM post_revisions WHERE post_id = ?`, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []PostRevision
+	for rows.Next() {
+		r, err := scanSQLiteRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode post revision row: %w", err)
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate post revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetPostRevision fetches one revision of a post.
+func (s *SQLiteStore) GetPostRevision(ctx context.Context, postID int64, number int) (*PostRevision, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqliteRevisionColumns+` FROM post_revisions WHERE post_id = ? AND number = ?`, postID, number)
+	r, err := scanSQLiteRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post revision: %w", err)
+	}
+	return &r, nil
+}
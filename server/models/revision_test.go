@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlanRevisions(t *testing.T) {
+	before := Post{ID: 7, Title: "Before", AuthorID: 3}
+	rev := PostRevision{PostID: 7, Title: "After", EditorID: 4}
+
+	add, drop := planRevisions(nil, before, rev, 0)
+	if len(add) != 2 || add[0].Number != 1 || add[0].Title != "Before" || add[0].EditorID != 3 || add[1].Number != 2 || len(drop) != 0 {
+		t.Fatalf("expected a baseline and the new revision, got %+v, drop %v", add, drop)
+	}
+
+	add, drop = planRevisions([]int{5, 3, 4}, before, rev, 2)
+	if len(add) != 1 || add[0].Number != 6 || !reflect.DeepEqual(drop, []int{3, 4}) {
+		t.Errorf("expected revision 6 and 3 and 4 dropped, got %+v, drop %v", add, drop)
+	}
+
+	add, drop = planRevisions(nil, before, rev, 1)
+	if len(add) != 1 || add[0].Number != 2 || len(drop) != 0 {
+		t.Errorf("expected only the new revision to fit, got %+v, drop %v", add, drop)
+	}
+}
+
+// Updates record revisions that keep to the post's limit, and deleting the
+// post removes them.
+func TestPostRevisionHistory(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+
+		post := &Post{Title: "Version 0", Content: "Body 0", Tags: []string{"go"}, AuthorID: 1, RevisionLimit: 3}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		for i, title := range []string{"Version 1", "Version 2", "Version 3"} {
+			post.Title = title
+			post.Content = "Body " + title
+			post.Slug = ""
+			if err := post.Update(ctx, int64(10+i)); err != nil {
+				t.Fatalf("failed to update post: %v", err)
+			}
+		}
+
+		revisions, err := ListPostRevisions(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("ListPostRevisions failed: %v", err)
+		}
+		var numbers []int
+		for _, r := range revisions {
+			numbers = append(numbers, r.Number)
+		}
+		if !reflect.DeepEqual(numbers, []int{4, 3, 2}) {
+			t.Fatalf("expected the newest three revisions, got %v", numbers)
+		}
+		if r := revisions[0]; r.Title != "Version 3" || r.EditorID != 12 || r.Content != "Body Version 3" || !reflect.DeepEqual(r.Tags, []string{"go"}) {
+			t.Errorf("unexpected newest revision %+v", r)
+		}
+		if _, err := GetPostRevision(ctx, post.ID, 1); !errors.Is(err, ErrRevisionNotFound) {
+			t.Errorf("expected the baseline to be dropped, got %v", err)
+		}
+
+		post.RevisionLimit = MaxRevisionLimit + 1
+		if err := post.Update(ctx, 1); !errors.Is(err, ErrInvalidRevisionLimit) {
+			t.Errorf("expected ErrInvalidRevisionLimit, got %v", err)
+		}
+
+		if err := post.Delete(ctx); err != nil {
+			t.Fatalf("failed to delete post: %v", err)
+		}
+		if revisions, err := ListPostRevisions(ctx, post.ID); err != nil || len(revisions) != 0 {
+			t.Errorf("expected the revisions to be deleted, got %v (%v)", revisions, err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidSchedule is returned for a scheduled post without PublishAt and
+// for an UnpublishAt that is not after PublishAt.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// normalizePostSchedule checks the publishing times of p after its status has
+// been normalized and stores them in UTC. Past times are accepted: the
+// publisher applies them on its next run. A published post with a future
+// PublishAt becomes scheduled, as the editor does, rather than going live
+// early.
+func normalizePostSchedule(p *Post) error {
+	if p.PublishAt != nil {
+		t := p.PublishAt.UTC()
+		p.PublishAt = &t
+	}
+	if p.UnpublishAt != nil {
+		t := p.UnpublishAt.UTC()
+		p.UnpublishAt = &t
+	}
+
+	if p.Status == "published" && p.PublishAt != nil && p.PublishAt.After(time.Now()) {
+		p.Status = "scheduled"
+	}
+
+	if p.Status == "scheduled" && p.PublishAt == nil {
+		return fmt.Errorf("%w: a scheduled post needs publish_at", ErrInvalidSchedule)
+	}
+	if p.Status == "scheduled" && p.UnpublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+		return fmt.Errorf("%w: unpublish_at must be after publish_at", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// applySchedule makes the status changes that are due at now to p, bumping
+// its version when there are any, and reports whether there were. A post
+// whose publish and unpublish times have both passed ends up as a draft.
+func applySchedule(p *Post, now time.Time) bool {
+	changed := false
+	if p.Status == "scheduled" && p.PublishAt != nil && !p.PublishAt.After(now) {
+		p.Status = "published"
+		p.CreatedAt = *p.PublishAt
+		changed = true
+	}
+	if p.Status == "published" && p.UnpublishAt != nil && !p.UnpublishAt.After(now) {
+		p.Status = "draft"
+		p.UnpublishAt = nil
+		changed = true
+	}
+	if changed {
+		p.Version++
+	}
+	return changed
+}
+
+// ApplyPostSchedule publishes every scheduled post whose PublishAt is not
+// after now and turns every published post whose UnpublishAt is not after now
+// back into a draft. It refreshes the search index for the changed posts and
+// returns their IDs.
+func ApplyPostSchedule(ctx context.Context, now time.Time) ([]int64, error) {
+	wctx, cancel := writeContext(ctx)
+	defer cancel()
+	ids, err := store().ApplyPostSchedule(wctx, now.UTC())
+	if err != nil {
+		return nil, storeError(wctx, err)
+	}
+
+	for _, id := range ids {
+		if post, err := GetPostByID(ctx, id); err == nil {
+			indexPost(*post)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Scheduled posts need publish_at, stay out of published listings until
+// ApplyPostSchedule publishes them, and expire at unpublish_at.
+func TestApplyPostSchedule(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		at := func(d time.Duration) *time.Time {
+			v := now.Add(d)
+			return &v
+		}
+
+		if err := (&Post{Title: "No time", Content: "Body", Status: "scheduled"}).Save(ctx); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("expected ErrInvalidSchedule without publish_at, got %v", err)
+		}
+		backwards := &Post{Title: "Backwards", Content: "Body", Status: "scheduled", PublishAt: at(time.Hour), UnpublishAt: at(time.Minute)}
+		if err := backwards.Save(ctx); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("expected ErrInvalidSchedule for unpublish_at before publish_at, got %v", err)
+		}
+
+		scheduled := &Post{Title: "Later", Content: "Body", Status: "scheduled", PublishAt: at(time.Hour), CreatedAt: now.Add(-time.Hour)}
+		expiring := &Post{Title: "Expiring", Content: "Body", UnpublishAt: at(2 * time.Hour), CreatedAt: now.Add(-time.Hour)}
+		for _, p := range []*Post{scheduled, expiring} {
+			if err := p.Save(ctx); err != nil {
+				t.Fatalf("failed to create post: %v", err)
+			}
+		}
+
+		published := func() []int64 {
+			t.Helper()
+			page, err := QueryPosts(ctx, PostFilter{Status: "published"})
+			if err != nil {
+				t.Fatalf("QueryPosts failed: %v", err)
+			}
+			var ids []int64
+			for _, p := range page.Posts {
+				ids = append(ids, p.ID)
+			}
+			return ids
+		}
+
+		apply := func(now time.Time, want []int64) {
+			t.Helper()
+			ids, err := ApplyPostSchedule(ctx, now)
+			if err != nil {
+				t.Fatalf("ApplyPostSchedule failed: %v", err)
+			}
+			if !reflect.DeepEqual(ids, want) {
+				t.Errorf("expected changed posts %v, got %v", want, ids)
+			}
+		}
+
+		apply(now, nil)
+		if got, want := published(), []int64{expiring.ID}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected published posts %v before publish_at, got %v", want, got)
+		}
+
+		apply(now.Add(time.Hour), []int64{scheduled.ID})
+		if got, want := published(), []int64{scheduled.ID, expiring.ID}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected published posts %v newest first, got %v", want, got)
+		}
+
+		apply(now.Add(2*time.Hour), []int64{expiring.ID})
+		if got, want := published(), []int64{scheduled.ID}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected published posts %v after unpublish_at, got %v", want, got)
+		}
+		post, err := GetPostByID(ctx, expiring.ID)
+		if err != nil {
+			t.Fatalf("failed to load post: %v", err)
+		}
+		if post.Status != "draft" || post.UnpublishAt != nil {
+			t.Errorf("expected a draft without unpublish_at, got %q and %v", post.Status, post.UnpublishAt)
+		}
+	})
+}
+
+// A post saved as published with a publish_at still to come is scheduled
+// instead of going live right away.
+func TestPublishedPostWithFuturePublishAtIsScheduled(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		publishAt := time.Now().Add(time.Hour)
+		post := &Post{Title: "Early", Content: "Body", Status: "published", PublishAt: &publishAt}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		if post.Status != "scheduled" {
+			t.Errorf("expected the post to be scheduled, got %q", post.Status)
+		}
+
+		page, err := QueryPosts(ctx, PostFilter{Status: "published"})
+		if err != nil {
+			t.Fatalf("QueryPosts failed: %v", err)
+		}
+		if len(page.Posts) != 0 {
+			t.Errorf("expected no published posts before publish_at, got %d", len(page.Posts))
+		}
+
+		past := time.Now().Add(-time.Hour)
+		post.PublishAt, post.Status = &past, "published"
+		if err := post.Update(ctx, 0); err != nil {
+			t.Fatalf("failed to update post: %v", err)
+		}
+		if post.Status != "published" {
+			t.Errorf("expected a past publish_at to keep the post published, got %q", post.Status)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// maxSlugLength is the maximum length of a slug in bytes.
+	maxSlugLength = 80
+	// slugAttempts is how many numbered variants ("title-2", "title-3", ...)
+	// are tried before a random suffix is used.
+	slugAttempts = 20
+	// FallbackSlug is used for titles without any letters or digits.
+	FallbackSlug = "post"
+)
+
+// PostSlug maps a slug, current or previous, to the post it belongs to.
+type PostSlug struct {
+	Slug   string `json:"slug"`
+	PostID int64  `json:"post_id"`
+}
+
+// Slugify turns s into a URL-friendly slug: lower-case letters and digits
+// separated by single hyphens, with accents removed ("Crème brûlée!" becomes
+// "creme-brulee"). Letters from other scripts are kept. It returns "" when s
+// contains no letters or digits.
+func Slugify(s string) string {
+	var sb strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining accent split off by NFD.
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			hyphen = false
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+	}
+
+	slug := norm.NFC.String(sb.String())
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		// Cut back to the last whole word, and never inside a character.
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+		slug = strings.ToValidUTF8(slug, "")
+	}
+	return slug
+}
+
+// SlugCandidate returns the attempt-th slug to try for base: base itself,
+// then "base-2", "base-3", ... and, from attempt slugAttempts on, base with a
+// random suffix.
+func SlugCandidate(base string, attempt int) string {
+	if attempt == 0 {
+		return base
+	}
+	suffix := fmt.Sprintf("-%d", attempt+1)
+	if attempt >= slugAttempts {
+		b := make([]byte, 4)
+		rand.Read(b)
+		suffix = "-" + hex.EncodeToString(b)
+	}
+	if len(base)+len(suffix) > maxSlugLength {
+		base = strings.TrimRight(strings.ToValidUTF8(base[:maxSlugLength-len(suffix)], ""), "-")
+	}
+	return base + suffix
+}
+
+// assignSlug finds a slug for the post with ID postID (0 for a new post) and
+// calls write with it. Unless explicit is set, a slug held by another post
+// is skipped in favour of the next numbered variant; an explicit slug is
+// used as-is or rejected with ErrSlugTaken. write must return ErrSlugTaken
+// if the slug was claimed in the meantime, in which case the next variant is
+// tried.
+func assignSlug(ctx context.Context, postID int64, base string, explicit bool, write func(slug string) error) error {
+	slug := Slugify(base)
+	if slug == "" {
+		if explicit {
+			return ErrInvalidSlug
+		}
+		slug = FallbackSlug
+	}
+	if explicit {
+		return write(slug)
+	}
+
+	for attempt := 0; attempt <= slugAttempts; attempt++ {
+		candidate := SlugCandidate(slug, attempt)
+
+		owner, err := store().ResolvePostSlug(ctx, candidate)
+		switch {
+		case errors.Is(err, ErrPostNotFound):
+		case err != nil:
+			return err
+		case owner != postID:
+			continue
+		}
+
+		err = write(candidate)
+		if !errors.Is(err, ErrSlugTaken) {
+			return err
+		}
+	}
+	return ErrSlugTaken
+}
+
+// GetPostBySlug returns the post that currently has slug, or previously had
+// it. moved reports the latter, in which case post.Slug is the slug to use
+// instead.
+func GetPostBySlug(ctx context.Context, slug string) (post *Post, moved bool, err error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	postID, err := store().ResolvePostSlug(ctx, slug)
+	if err != nil {
+		return nil, false, storeError(ctx, err)
+	}
+	post, err = store().GetPost(ctx, postID)
+	if err != nil {
+		return nil, false, storeError(ctx, err)
+	}
+	post.render()
+	return post, post.Slug != slug, nil
+}
+
+// ListPostSlugs returns every registered slug, current and previous.
+func ListPostSlugs(ctx context.Context) ([]PostSlug, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	slugs, err := store().ListPostSlugs(ctx)
+	return slugs, storeError(ctx, err)
+}
+
+// ImportPostSlug registers slug as a previous slug of the post with postID,
+// so that it keeps redirecting there. It returns ErrSlugTaken when the slug
+// belongs to another post.
+func ImportPostSlug(ctx context.Context, slug string, postID int64) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().ImportPostSlug(ctx, slug, postID))
+}
+
+// AssignMissingSlugs gives every post without a slug one generated from its
+// title. Posts created before slugs existed need this once; it returns how
+// many posts were updated.
+func AssignMissingSlugs(ctx context.Context) (int, error) {
+	posts, err := GetAllPosts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, p := range posts {
+		if p.Slug != "" {
+			continue
+		}
+		err := func() error {
+			ctx, cancel := writeContext(ctx)
+			defer cancel()
+			return storeError(ctx, assignSlug(ctx, p.ID, p.Title, false, func(slug string) error {
+				p.Slug = slug
+				return store().UpdatePost(ctx, &p, nil)
+			}))
+		}()
+		if err != nil {
+			return updated, fmt.Errorf("post %d: %w", p.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
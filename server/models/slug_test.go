@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Hello, World!":          "hello-world",
+		"  Crème brûlée  ":       "creme-brulee",
+		"Go 1.22 -- what's new?": "go-1-22-what-s-new",
+		"Привет мир":             "привет-мир",
+		"!!!":                    "",
+	}
+	for in, want := range cases {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	long := Slugify(strings.Repeat("word ", 40))
+	if len(long) > maxSlugLength || strings.HasSuffix(long, "-") || strings.HasSuffix(long, "wor") {
+		t.Errorf("expected a long title to be cut at a word boundary, got %q", long)
+	}
+}
+
+// Slugs are unique, follow title changes and keep redirecting from the old
+// slug afterwards.
+func TestPostSlugs(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+
+		first := &Post{Title: "Hello World", Content: "Body"}
+		second := &Post{Title: "Hello, world!", Content: "Body"}
+		untitled := &Post{Title: "???", Content: "Body"}
+		for _, p := range []*Post{first, second, untitled} {
+			if err := p.Save(ctx); err != nil {
+				t.Fatalf("failed to create post: %v", err)
+			}
+		}
+		if first.Slug != "hello-world" || second.Slug != "hello-world-2" || untitled.Slug != FallbackSlug {
+			t.Fatalf("unexpected slugs %q, %q, %q", first.Slug, second.Slug, untitled.Slug)
+		}
+
+		// Updating without a title change keeps the slug.
+		same := &Post{ID: first.ID, Title: first.Title, Content: "Edited"}
+		if err := same.Update(ctx, 0); err != nil {
+			t.Fatalf("failed to update post: %v", err)
+		}
+		if same.Slug != "hello-world" {
+			t.Fatalf("expected the slug to be kept, got %q", same.Slug)
+		}
+
+		renamed := &Post{ID: first.ID, Title: "Goodbye World", Content: "Edited"}
+		if err := renamed.Update(ctx, 0); err != nil {
+			t.Fatalf("failed to rename post: %v", err)
+		}
+		if renamed.Slug != "goodbye-world" {
+			t.Fatalf("expected a new slug from the title, got %q", renamed.Slug)
+		}
+
+		post, moved, err := GetPostBySlug(ctx, "hello-world")
+		if err != nil || !moved || post.ID != first.ID || post.Slug != "goodbye-world" {
+			t.Fatalf("expected the old slug to redirect, got %+v, moved=%v, err=%v", post, moved, err)
+		}
+		post, moved, err = GetPostBySlug(ctx, "goodbye-world")
+		if err != nil || moved || post.ID != first.ID {
+			t.Fatalf("expected the current slug to resolve, got %+v, moved=%v, err=%v", post, moved, err)
+		}
+
+		// A slug in another post's history is not reused for a new post.
+		third := &Post{Title: "Hello World", Content: "Body"}
+		if err := third.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		if third.Slug != "hello-world-3" {
+			t.Fatalf("expected hello-world-3, got %q", third.Slug)
+		}
+
+		// Explicit slugs are normalized and must be free.
+		custom := &Post{ID: second.ID, Title: second.Title, Slug: "My Custom Slug", Content: "Body"}
+		if err := custom.Update(ctx, 0); err != nil {
+			t.Fatalf("failed to set a custom slug: %v", err)
+		}
+		if custom.Slug != "my-custom-slug" {
+			t.Fatalf("expected a normalized slug, got %q", custom.Slug)
+		}
+		taken := &Post{ID: second.ID, Title: second.Title, Slug: "goodbye-world", Content: "Body"}
+		if err := taken.Update(ctx, 0); !errors.Is(err, ErrSlugTaken) {
+			t.Fatalf("expected ErrSlugTaken, got %v", err)
+		}
+		invalid := &Post{Title: "Post", Slug: "!!!", Content: "Body"}
+		if err := invalid.Save(ctx); !errors.Is(err, ErrInvalidSlug) {
+			t.Fatalf("expected ErrInvalidSlug, got %v", err)
+		}
+
+		// Deleting a post frees its slugs.
+		if err := renamed.Delete(ctx); err != nil {
+			t.Fatalf("failed to delete post: %v", err)
+		}
+		if _, _, err := GetPostBySlug(ctx, "hello-world"); !errors.Is(err, ErrPostNotFound) {
+			t.Fatalf("expected ErrPostNotFound after delete, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// PostStore persists blog posts. Implementations are responsible for
+// assigning numeric IDs and for cleaning up a post's reactions, comments and
+// slugs when it is deleted.
+//
+// Every slug a post has had is registered to it, so old links keep
+// resolving. Writes that would give a post a slug registered to another
+// post fail with ErrSlugTaken.
+type PostStore interface {
+	// SavePost stores a new post, assigning p.ID, setting p.Version to 1 and
+	// resetting the aggregate counters to zero, and registers p.Slug.
+	SavePost(ctx context.Context, p *Post) error
+	// ListPosts returns all posts ordered by creation time (newest first).
+	ListPosts(ctx context.Context) ([]Post, error)
+	// QueryPosts returns at most f.Limit post summaries matching f, in the
+	// same order as ListPosts with the ID as a tie-breaker, starting strictly
+	// after f.After when it is set. It must not read post content.
+	QueryPosts(ctx context.Context, f PostFilter) ([]PostSummary, error)
+	// GetPost returns the full post, or ErrPostNotFound when no post has the
+	// given ID.
+	GetPost(ctx context.Context, id int64) (*Post, error)
+	// UpdatePost overwrites the editable fields of an existing post and
+	// registers p.Slug, keeping the previous slugs registered.
+	//
+	// Unless p.Version is zero, it must be the stored version, or nothing is
+	// written and ErrVersionConflict is returned; the check and the write
+	// happen in one transaction. The stored version goes up by one and is
+	// set in p.Version.
+	//
+	// Unless rev is nil, it is recorded in the same transaction as the
+	// post's newest revision, laid out by planRevisions: the store reads
+	// the post's revision numbers and the post as it was, adds the planned
+	// revisions and drops the ones that exceed p.RevisionLimit.
+	UpdatePost(ctx context.Context, p *Post, rev *PostRevision) error
+	// DeletePost removes a post together with its reactions, comments,
+	// slugs and revisions.
+	DeletePost(ctx context.Context, id int64) error
+	// ResolvePostSlug returns the ID of the post that has or had slug, or
+	// ErrPostNotFound.
+	ResolvePostSlug(ctx context.Context, slug string) (int64, error)
+	// ListPostSlugs returns every registered slug.
+	ListPostSlugs(ctx context.Context) ([]PostSlug, error)
+	// ApplyPostSchedule publishes every scheduled post whose PublishAt is
+	// not after now, setting its CreatedAt to PublishAt so it lists as new,
+	// and turns every published post whose UnpublishAt is not after now into
+	// a draft, clearing UnpublishAt. Each post is checked and changed
+	// atomically, so a concurrent edit is never overwritten, and its version
+	// goes up by one. It returns the IDs of the changed posts.
+	ApplyPostSchedule(ctx context.Context, now time.Time) ([]int64, error)
+}
+
+// TagStore aggregates and rewrites post tags across all posts. Tags are
+// stored with the post (see PostStore) and are already normalized.
+type TagStore interface {
+	// ListTags returns every tag on at least one post, in any order.
+	ListTags(ctx context.Context) ([]TagCount, error)
+	// ReplaceTags replaces each tag in from with to on every post that has
+	// one, removing duplicates, and returns the IDs of the changed posts.
+	// to is never one of from. Post timestamps are left alone; the version
+	// of each changed post goes up by one in the same write.
+	ReplaceTags(ctx context.Context, from []string, to string) ([]int64, error)
+}
+
+// CategoryStore persists managed categories, keyed by slug. Posts refer to
+// a category by name, so renames and deletes have to look at posts too.
+type CategoryStore interface {
+	// CreateCategory stores c, or returns ErrCategoryExists when its slug is
+	// taken.
+	CreateCategory(ctx context.Context, c *Category) error
+	// ListCategories returns every category, in any order.
+	ListCategories(ctx context.Context) ([]Category, error)
+	// GetCategory returns the category, or ErrCategoryNotFound.
+	GetCategory(ctx context.Context, slug string) (*Category, error)
+	// UpdateCategory replaces the category stored under slug with c, keeping
+	// its creation time. When c has a different slug it must not be taken
+	// (ErrCategoryExists). Posts filed under the old name are moved to
+	// c.Name, bumping their versions, and their IDs are returned.
+	UpdateCategory(ctx context.Context, slug string, c *Category) ([]int64, error)
+	// DeleteCategory removes the category, or returns ErrCategoryInUse when a
+	// post is filed under it. The check and the delete are atomic.
+	DeleteCategory(ctx context.Context, slug string) error
+	// CountPostsByCategory returns the number of published posts per
+	// category name.
+	CountPostsByCategory(ctx context.Context) (map[string]int, error)
+}
+
+// MediaStore persists the media library: one record per uploaded image,
+// keyed by its media key. The images themselves are kept by the media
+// package.
+type MediaStore interface {
+	// CreateMedia stores m.
+	CreateMedia(ctx context.Context, m *MediaAsset) error
+	// ListMedia returns every record, in any order.
+	ListMedia(ctx context.Context) ([]MediaAsset, error)
+	// GetMedia returns the record, or ErrMediaNotFound.
+	GetMedia(ctx context.Context, key string) (*MediaAsset, error)
+	// DeleteMedia removes the record, or returns ErrMediaNotFound.
+	DeleteMedia(ctx context.Context, key string) error
+}
+
+// CommentStore persists reader comments and keeps the owning post's
+// comments_count in step with creates and deletes. Writes that take a
+// contentHTML store it as rendered with markdown.CommentVersion.
+type CommentStore interface {
+	CreateComment(ctx context.Context, postID, userID int64, authorName, content, contentHTML string) (*Comment, error)
+	// ListCommentsForPost returns comments ordered oldest first.
+	ListCommentsForPost(ctx context.Context, postID int64) ([]Comment, error)
+	GetComment(ctx context.Context, id string) (*Comment, error)
+	// UpdateCommentContent replaces the content of a comment owned by
+	// userID and bumps its version. A non-zero version must be the stored
+	// one, checked atomically with the write, or ErrVersionConflict is
+	// returned.
+	UpdateCommentContent(ctx context.Context, id string, userID, version int64, newContent, newContentHTML string) (*Comment, error)
+	// SetCommentHTML replaces the rendered HTML of a comment, unless its
+	// content has changed from content in the meantime. It does not touch
+	// UpdatedAt or the version.
+	SetCommentHTML(ctx context.Context, id, content, contentHTML string) error
+	DeleteComment(ctx context.Context, id string, postID int64) error
+	AnonymizeCommentsForUser(ctx context.Context, userID int64) error
+}
+
+// ReactionStore persists like/dislike reactions. SetPostReaction must apply
+// the toggle and the counter update atomically.
+type ReactionStore interface {
+	SetPostReaction(ctx context.Context, userID, postID int64, reaction string) (*PostReactionResult, error)
+}
+
+// UserStore persists user accounts. Implementations enforce unique usernames,
+// make the very first user an admin, and refuse to demote or delete the last
+// remaining admin.
+type UserStore interface {
+	// CreateUser stores a new user with an already hashed password, assigning
+	// u.ID and u.Role.
+	CreateUser(ctx context.Context, u *User, passwordHash string) error
+	ListUsers(ctx context.Context) ([]User, error)
+	GetUser(ctx context.Context, id int64) (*User, error)
+	// GetUserByUsername returns the user together with their password hash.
+	GetUserByUsername(ctx context.Context, username string) (*User, string, error)
+	UpdateUserRole(ctx context.Context, id int64, role string) error
+	// DeleteUser removes the account and anonymizes the user's comments.
+	DeleteUser(ctx context.Context, id int64) error
+}
+
+// ImportStore writes records that already carry their IDs, timestamps and
+// counters, for example when importing a legacy database. Every method is an
+// upsert keyed by the record's ID so re-running an import is idempotent, and
+// implementations advance their ID allocators past imported IDs.
+type ImportStore interface {
+	// ImportUser returns ErrUserAlreadyExists when the username already
+	// belongs to a different ID, or the ID to a different username, so an
+	// import never silently replaces someone else's account.
+	ImportUser(ctx context.Context, u User, passwordHash string) error
+	// ImportPost registers p.Slug like SavePost does.
+	ImportPost(ctx context.Context, p Post) error
+	// ImportPostSlug registers slug for postID without changing the post.
+	ImportPostSlug(ctx context.Context, slug string, postID int64) error
+	// ImportCategory creates or overwrites the category with c.Slug.
+	ImportCategory(ctx context.Context, c Category) error
+	// ImportPostRevision creates or overwrites revision r.Number of post
+	// r.PostID, without dropping revisions beyond the post's limit.
+	ImportPostRevision(ctx context.Context, r PostRevision) error
+	ImportComment(ctx context.Context, c Comment) error
+	ImportReaction(ctx context.Context, userID, postID int64, reaction string) error
+	// DeleteAll removes every user, category, post, slug, revision, comment
+	// and reaction and resets the ID allocators, so that a following import
+	// starts from an empty store.
+	DeleteAll(ctx context.Context) error
+}
+
+// ConsistencyStore gives the consistency checker raw access to every
+// comment and reaction and lets it overwrite counters and drop reactions.
+// None of these methods are used on the request path.
+type ConsistencyStore interface {
+	ListAllComments(ctx context.Context) ([]Comment, error)
+	ListAllReactions(ctx context.Context) ([]PostReaction, error)
+	// SetPostCounters overwrites a post's likes, dislikes and comments counts.
+	SetPostCounters(ctx context.Context, postID int64, counters PostCounters) error
+	DeleteReaction(ctx context.Context, userID, postID int64) error
+}
+
+// Store bundles every storage interface the application needs. Each backend
+// (Firestore, SQLite, in-memory) implements all of them on a single type.
+type Store interface {
+	PostStore
+	RevisionStore
+	TagStore
+	CategoryStore
+	MediaStore
+	CommentStore
+	ReactionStore
+	UserStore
+	ImportStore
+	ConsistencyStore
+}
+
+// activeStore is the backend used by the package-level model functions.
+var activeStore Store
+
+// SetStore selects the storage backend used by the model layer. It is
+// expected to be called once at startup (or at the beginning of a test).
+func SetStore(s Store) {
+	activeStore = s
+}
+
+func store() Store {
+	if activeStore == nil {
+		panic("models: store is not configured; call models.SetStore first")
+	}
+	return activeStore
+}
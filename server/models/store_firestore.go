@@ -0,0 +1,68 @@
+package models
+
+import (
+	"cloud.google.com/go/firestore"
+)
+
+// FirestoreStore implements Store on top of Cloud Firestore. Posts live in the
+// "posts" collection keyed by their numeric ID, while users, comments and
+// reactions live in "users", "post_comments" and "post_reactions".
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore returns a Store backed by the given Firestore client.
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+func (s *FirestoreStore) collection(name string) *firestore.CollectionRef {
+	if s.client == nil {
+		panic("Firestore client is not initialized")
+	}
+	return s.client.Collection(name)
+}
+
+func (s *FirestoreStore) postsCollection() *firestore.CollectionRef {
+	return s.collection("posts")
+}
+
+// postContentsCollection holds post bodies, keyed like posts, so that
+// listings only read the small metadata documents.
+func (s *FirestoreStore) postContentsCollection() *firestore.CollectionRef {
+	return s.collection("post_contents")
+}
+
+// postSlugsCollection maps every current and previous post slug (the
+// document ID) to its post.
+func (s *FirestoreStore) postSlugsCollection() *firestore.CollectionRef {
+	return s.collection("post_slugs")
+}
+
+// postRevisionsCollection holds post revisions, keyed by post ID and
+// revision number ("12-3").
+func (s *FirestoreStore) postRevisionsCollection() *firestore.CollectionRef {
+	return s.collection("post_revisions")
+}
+
+// categoriesCollection holds the managed categories, keyed by slug.
+func (s *FirestoreStore) categoriesCollection() *firestore.CollectionRef {
+	return s.collection("categories")
+}
+
+// mediaCollection holds the media library, keyed by media key.
+func (s *FirestoreStore) mediaCollection() *firestore.CollectionRef {
+	return s.collection("media")
+}
+
+func (s *FirestoreStore) postCommentsCollection() *firestore.CollectionRef {
+	return s.collection("post_comments")
+}
+
+func (s *FirestoreStore) postReactionsCollection() *firestore.CollectionRef {
+	return s.collection("post_reactions")
+}
+
+func (s *FirestoreStore) usersCollection() *firestore.CollectionRef {
+	return s.collection("users")
+}
@@ -0,0 +1,956 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"example.com/blog_backend/markdown"
+)
+
+// MemoryStore is an in-process Store with the same semantics as the Firestore
+// backend. It is meant for tests and local development; nothing survives a
+// restart.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	posts     map[int64]*Post
+	comments  map[string]*Comment
+	reactions map[memoryReactionKey]string
+	users     map[int64]*memoryUser
+	// slugs maps every current and previous post slug to its post.
+	slugs      map[string]int64
+	categories map[string]*Category
+	media      map[string]*MediaAsset
+	// revisions holds the kept revisions of each post, oldest first.
+	revisions map[int64][]PostRevision
+
+	lastPostID    int64
+	lastUserID    int64
+	lastCommentID int64
+}
+
+type memoryReactionKey struct {
+	userID int64
+	postID int64
+}
+
+type memoryUser struct {
+	user         User
+	passwordHash string
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		posts:      make(map[int64]*Post),
+		comments:   make(map[string]*Comment),
+		reactions:  make(map[memoryReactionKey]string),
+		users:      make(map[int64]*memoryUser),
+		slugs:      make(map[string]int64),
+		categories: make(map[string]*Category),
+		media:      make(map[string]*MediaAsset),
+		revisions:  make(map[int64][]PostRevision),
+	}
+}
+
+// claimSlugLocked registers slug for postID unless another post holds it.
+// An empty slug is ignored.
+func (s *MemoryStore) claimSlugLocked(slug string, postID int64) error {
+	if slug == "" {
+		return nil
+	}
+	if owner, ok := s.slugs[slug]; ok && owner != postID {
+		return ErrSlugTaken
+	}
+	s.slugs[slug] = postID
+	return nil
+}
+
+// SavePost stores a copy of p under the next numeric ID.
+func (s *MemoryStore) SavePost(ctx context.Context, p *Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.slugs[p.Slug]; ok {
+		return ErrSlugTaken
+	}
+	s.lastPostID++
+	p.ID = s.lastPostID
+	s.claimSlugLocked(p.Slug, p.ID)
+	p.Version = 1
+	p.LikesCount = 0
+	p.DislikesCount = 0
+	p.CommentsCount = 0
+
+	stored := *p
+	s.posts[p.ID] = &stored
+	return nil
+}
+
+// ListPosts returns all posts ordered by creation time (newest first).
+func (s *MemoryStore) ListPosts(ctx context.Context) ([]Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var posts []Post
+	for _, p := range s.posts {
+		posts = append(posts, *p)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+	return posts, nil
+}
+
+// QueryPosts returns a page of post summaries matching f.
+func (s *MemoryStore) QueryPosts(ctx context.Context, f PostFilter) ([]PostSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var posts []PostSummary
+	for _, p := range s.posts {
+		switch {
+		case f.Category != "" && p.Category != f.Category,
+			f.Tag != "" && !hasTag(p.Tags, f.Tag),
+			f.Status != "" && p.Status != f.Status,
+			f.AuthorID != 0 && p.AuthorID != f.AuthorID,
+			!f.CreatedAfter.IsZero() && p.CreatedAt.Before(f.CreatedAfter),
+			!f.CreatedBefore.IsZero() && !p.CreatedAt.Before(f.CreatedBefore),
+			f.After != nil && !postBefore(*f.After, PostCursor{CreatedAt: p.CreatedAt, ID: p.ID}):
+			continue
+		}
+		posts = append(posts, p.Summary())
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return postBefore(posts[i].cursor(), posts[j].cursor())
+	})
+
+	if len(posts) > f.Limit {
+		posts = posts[:f.Limit]
+	}
+	return posts, nil
+}
+
+// GetPost returns a copy of the post with the given ID.
+func (s *MemoryStore) GetPost(ctx context.Context, id int64) (*Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, ErrPostNotFound
+	}
+	post := *p
+	return &post, nil
+}
+
+// UpdatePost overwrites the editable fields of an existing post and records
+// rev.
+func (s *MemoryStore) UpdatePost(ctx context.Context, p *Post, rev *PostRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.posts[p.ID]
+	if !ok {
+		return ErrPostNotFound
+	}
+	if err := checkVersion(p.Version, stored.Version); err != nil {
+		return err
+	}
+	if err := s.claimSlugLocked(p.Slug, p.ID); err != nil {
+		return err
+	}
+	if rev != nil {
+		s.recordRevisionLocked(*stored, *rev, p.RevisionLimit)
+	}
+	if p.Slug != "" {
+		stored.Slug = p.Slug
+	}
+	stored.Title = p.Title
+	stored.Description = p.Description
+	stored.Category = p.Category
+	stored.Tags = p.Tags
+	stored.CoverImageKey = p.CoverImageKey
+	stored.Status = p.Status
+	stored.PublishAt = p.PublishAt
+	stored.UnpublishAt = p.UnpublishAt
+	stored.MembersOnly = p.MembersOnly
+	stored.RevisionLimit = p.RevisionLimit
+	stored.Content = p.Content
+	stored.Excerpt = p.Excerpt
+	stored.UpdatedAt = p.UpdatedAt
+	stored.Version++
+	p.Version = stored.Version
+	return nil
+}
+
+// recordRevisionLocked adds rev to the revisions of the post that was
+// before until now, keeping at most limit.
+func (s *MemoryStore) recordRevisionLocked(before Post, rev PostRevision, limit int) {
+	kept := s.revisions[before.ID]
+	numbers := make([]int, len(kept))
+	for i, r := range kept {
+		numbers[i] = r.Number
+	}
+	add, drop := planRevisions(numbers, before, rev, limit)
+
+	dropped := make(map[int]bool, len(drop))
+	for _, n := range drop {
+		dropped[n] = true
+	}
+	revisions := make([]PostRevision, 0, len(kept)+len(add))
+	for _, r := range kept {
+		if !dropped[r.Number] {
+			revisions = append(revisions, r)
+		}
+	}
+	s.revisions[before.ID] = append(revisions, add...)
+}
+
+// ListPostRevisions returns copies of the kept revisions of a post.
+func (s *MemoryStore) ListPostRevisions(ctx context.Context, postID int64) ([]PostRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]PostRevision(nil), s.revisions[postID]...), nil
+}
+
+// GetPostRevision returns a copy of one revision.
+func (s *MemoryStore) GetPostRevision(ctx context.Context, postID int64, number int) (*PostRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.revisions[postID] {
+		if r.Number == number {
+			rev := r
+			return &rev, nil
+		}
+	}
+	return nil, ErrRevisionNotFound
+}
+
+// DeletePost removes a post and its reactions and comments.
+func (s *MemoryStore) DeletePost(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[id]; !ok {
+		return ErrPostNotFound
+	}
+	for key := range s.reactions {
+		if key.postID == id {
+			delete(s.reactions, key)
+		}
+	}
+	for commentID, c := range s.comments {
+		if c.PostID == id {
+			delete(s.comments, commentID)
+		}
+	}
+	for slug, postID := range s.slugs {
+		if postID == id {
+			delete(s.slugs, slug)
+		}
+	}
+	delete(s.revisions, id)
+	delete(s.posts, id)
+	return nil
+}
+
+// ResolvePostSlug returns the post that has or had slug.
+func (s *MemoryStore) ResolvePostSlug(ctx context.Context, slug string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	postID, ok := s.slugs[slug]
+	if !ok {
+		return 0, ErrPostNotFound
+	}
+	return postID, nil
+}
+
+// ListPostSlugs returns every registered slug, ordered by slug.
+func (s *MemoryStore) ListPostSlugs(ctx context.Context) ([]PostSlug, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slugs := make([]PostSlug, 0, len(s.slugs))
+	for slug, postID := range s.slugs {
+		slugs = append(slugs, PostSlug{Slug: slug, PostID: postID})
+	}
+	sort.Slice(slugs, func(i, j int) bool { return slugs[i].Slug < slugs[j].Slug })
+	return slugs, nil
+}
+
+// ApplyPostSchedule publishes and unpublishes the posts that are due.
+func (s *MemoryStore) ApplyPostSchedule(ctx context.Context, now time.Time) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changed []int64
+	for id, p := range s.posts {
+		if applySchedule(p, now) {
+			changed = append(changed, id)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i] < changed[j] })
+	return changed, nil
+}
+
+// hasTag reports whether tags contains tag.
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTags counts the posts carrying each tag.
+func (s *MemoryStore) ListTags(ctx context.Context) ([]TagCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]*TagCount)
+	for _, p := range s.posts {
+		for _, tag := range p.Tags {
+			c, ok := counts[tag]
+			if !ok {
+				c = &TagCount{Tag: tag}
+				counts[tag] = c
+			}
+			c.Total++
+			if p.Status == "published" {
+				c.Count++
+			}
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for _, c := range counts {
+		tags = append(tags, *c)
+	}
+	return tags, nil
+}
+
+// ReplaceTags rewrites the tags of every post under the store lock. Posts
+// get a new tag slice, so copies handed out earlier are not affected.
+func (s *MemoryStore) ReplaceTags(ctx context.Context, from []string, to string) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replace := make(map[string]bool, len(from))
+	for _, tag := range from {
+		replace[tag] = true
+	}
+
+	var changed []int64
+	for id, p := range s.posts {
+		if tags, ok := replaceTags(p.Tags, replace, to); ok {
+			p.Tags = tags
+			p.Version++
+			changed = append(changed, id)
+		}
+	}
+	return changed, nil
+}
+
+// CreateCategory stores a copy of c under its slug.
+func (s *MemoryStore) CreateCategory(ctx context.Context, c *Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.categories[c.Slug]; ok {
+		return ErrCategoryExists
+	}
+	stored := *c
+	s.categories[c.Slug] = &stored
+	return nil
+}
+
+// ListCategories returns copies of every category.
+func (s *MemoryStore) ListCategories(ctx context.Context) ([]Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categories := make([]Category, 0, len(s.categories))
+	for _, c := range s.categories {
+		categories = append(categories, *c)
+	}
+	return categories, nil
+}
+
+// GetCategory returns a copy of the category with the given slug.
+func (s *MemoryStore) GetCategory(ctx context.Context, slug string) (*Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.categories[slug]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	category := *c
+	return &category, nil
+}
+
+// UpdateCategory replaces the category and renames its posts under the
+// store lock.
+func (s *MemoryStore) UpdateCategory(ctx context.Context, slug string, c *Category) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.categories[slug]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	if _, taken := s.categories[c.Slug]; taken && c.Slug != slug {
+		return nil, ErrCategoryExists
+	}
+
+	c.CreatedAt = old.CreatedAt
+	stored := *c
+	delete(s.categories, slug)
+	s.categories[c.Slug] = &stored
+
+	var changed []int64
+	if old.Name != c.Name {
+		for id, p := range s.posts {
+			if p.Category == old.Name {
+				p.Category = c.Name
+				p.Version++
+				changed = append(changed, id)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// DeleteCategory removes the category unless a post is filed under it.
+func (s *MemoryStore) DeleteCategory(ctx context.Context, slug string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.categories[slug]
+	if !ok {
+		return ErrCategoryNotFound
+	}
+	for _, p := range s.posts {
+		if p.Category == c.Name {
+			return ErrCategoryInUse
+		}
+	}
+	delete(s.categories, slug)
+	return nil
+}
+
+// CountPostsByCategory counts published posts per category name.
+func (s *MemoryStore) CountPostsByCategory(ctx context.Context) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, p := range s.posts {
+		if p.Status == "published" && p.Category != "" {
+			counts[p.Category]++
+		}
+	}
+	return counts, nil
+}
+
+// CreateMedia stores a copy of m under its key.
+func (s *MemoryStore) CreateMedia(ctx context.Context, m *MediaAsset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *m
+	s.media[m.Key] = &stored
+	return nil
+}
+
+// ListMedia returns copies of every media record.
+func (s *MemoryStore) ListMedia(ctx context.Context) ([]MediaAsset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assets := make([]MediaAsset, 0, len(s.media))
+	for _, m := range s.media {
+		assets = append(assets, *m)
+	}
+	return assets, nil
+}
+
+// GetMedia returns a copy of the media record with the given key.
+func (s *MemoryStore) GetMedia(ctx context.Context, key string) (*MediaAsset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[key]
+	if !ok {
+		return nil, ErrMediaNotFound
+	}
+	asset := *m
+	return &asset, nil
+}
+
+// DeleteMedia removes the media record with the given key.
+func (s *MemoryStore) DeleteMedia(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.media[key]; !ok {
+		return ErrMediaNotFound
+	}
+	delete(s.media, key)
+	return nil
+}
+
+// CreateComment stores a new comment and increments the post's
+// comments_count.
+func (s *MemoryStore) CreateComment(ctx context.Context, postID, userID int64, authorName, content, contentHTML string) (*Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.lastCommentID++
+	comment := &Comment{
+		ID:            strconv.FormatInt(s.lastCommentID, 10),
+		PostID:        postID,
+		UserID:        userID,
+		AuthorName:    authorName,
+		Content:       content,
+		ContentHTML:   contentHTML,
+		RenderVersion: markdown.CommentVersion,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.comments[comment.ID] = comment
+
+	if p, ok := s.posts[postID]; ok {
+		p.CommentsCount++
+	}
+
+	created := *comment
+	return &created, nil
+}
+
+// ListCommentsForPost returns the post's comments, oldest first.
+func (s *MemoryStore) ListCommentsForPost(ctx context.Context, postID int64) ([]Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var comments []Comment
+	for _, c := range s.comments {
+		if c.PostID == postID {
+			comments = append(comments, *c)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+// GetComment returns a copy of the comment with the given ID.
+func (s *MemoryStore) GetComment(ctx context.Context, id string) (*Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return nil, ErrCommentNotFound
+	}
+	comment := *c
+	return &comment, nil
+}
+
+// UpdateCommentContent replaces the content of a comment owned by userID.
+func (s *MemoryStore) UpdateCommentContent(ctx context.Context, id string, userID, version int64, newContent, newContentHTML string) (*Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return nil, ErrCommentNotFound
+	}
+	if c.UserID != userID {
+		return nil, ErrUnauthorizedCommentAction
+	}
+	if err := checkVersion(version, c.Version); err != nil {
+		return nil, err
+	}
+	c.Content = newContent
+	c.ContentHTML = newContentHTML
+	c.RenderVersion = markdown.CommentVersion
+	c.UpdatedAt = time.Now()
+	c.Version++
+
+	comment := *c
+	return &comment, nil
+}
+
+// SetCommentHTML replaces the rendered HTML of a comment whose content is
+// still content.
+func (s *MemoryStore) SetCommentHTML(ctx context.Context, id, content, contentHTML string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return ErrCommentNotFound
+	}
+	if c.Content == content {
+		c.ContentHTML = contentHTML
+		c.RenderVersion = markdown.CommentVersion
+	}
+	return nil
+}
+
+// DeleteComment removes a comment and decrements the post's comments_count.
+func (s *MemoryStore) DeleteComment(ctx context.Context, id string, postID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[id]; !ok {
+		return ErrCommentNotFound
+	}
+	delete(s.comments, id)
+
+	if p, ok := s.posts[postID]; ok {
+		p.CommentsCount--
+	}
+	return nil
+}
+
+// AnonymizeCommentsForUser detaches every comment owned by the given user
+// from their account.
+func (s *MemoryStore) AnonymizeCommentsForUser(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.anonymizeCommentsLocked(userID)
+	return nil
+}
+
+func (s *MemoryStore) anonymizeCommentsLocked(userID int64) {
+	for _, c := range s.comments {
+		if c.UserID == userID {
+			c.UserID = 0
+			c.AuthorName = deletedUserAuthorName
+		}
+	}
+}
+
+// SetPostReaction applies a reaction toggle and the matching counter update
+// under the store lock.
+func (s *MemoryStore) SetPostReaction(ctx context.Context, userID, postID int64, reaction string) (*PostReactionResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[postID]
+	if !ok {
+		return nil, ErrPostNotFound
+	}
+
+	key := memoryReactionKey{userID: userID, postID: postID}
+	result := &PostReactionResult{
+		LikesCount:    p.LikesCount,
+		DislikesCount: p.DislikesCount,
+	}
+	applyReaction(s.reactions[key], reaction, result)
+
+	if result.UserReaction == "" {
+		delete(s.reactions, key)
+	} else {
+		s.reactions[key] = result.UserReaction
+	}
+	p.LikesCount = result.LikesCount
+	p.DislikesCount = result.DislikesCount
+
+	return result, nil
+}
+
+// CreateUser stores a new user, making the first user an admin.
+func (s *MemoryStore) CreateUser(ctx context.Context, u *User, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.users {
+		if existing.user.Username == u.Username {
+			return ErrUserAlreadyExists
+		}
+	}
+
+	role := "user"
+	if s.countAdminsLocked() == 0 {
+		role = "admin"
+	}
+
+	s.lastUserID++
+	u.ID = s.lastUserID
+	u.Role = role
+
+	s.users[u.ID] = &memoryUser{
+		user: User{
+			ID:       u.ID,
+			Username: u.Username,
+			Role:     role,
+		},
+		passwordHash: passwordHash,
+	}
+	return nil
+}
+
+// ListUsers returns all users ordered by ID, without password hashes.
+func (s *MemoryStore) ListUsers(ctx context.Context) ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []User
+	for _, entry := range s.users {
+		users = append(users, entry.user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	return users, nil
+}
+
+// GetUser returns the user with the given ID.
+func (s *MemoryStore) GetUser(ctx context.Context, id int64) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	user := entry.user
+	return &user, nil
+}
+
+// GetUserByUsername returns the user with the given username together with
+// their password hash.
+func (s *MemoryStore) GetUserByUsername(ctx context.Context, username string) (*User, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.users {
+		if entry.user.Username == username {
+			user := entry.user
+			return &user, entry.passwordHash, nil
+		}
+	}
+	return nil, "", ErrUserNotFound
+}
+
+// UpdateUserRole changes a user's role, refusing to demote the last admin.
+func (s *MemoryStore) UpdateUserRole(ctx context.Context, id int64, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if entry.user.Role == "admin" && role != "admin" && s.countAdminsLocked() <= 1 {
+		return ErrCannotDemoteLastAdmin
+	}
+	entry.user.Role = role
+	return nil
+}
+
+// DeleteUser removes a user and anonymizes their comments, refusing to delete
+// the last admin.
+func (s *MemoryStore) DeleteUser(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if entry.user.Role == "admin" && s.countAdminsLocked() <= 1 {
+		return ErrCannotDemoteLastAdmin
+	}
+
+	s.anonymizeCommentsLocked(id)
+	delete(s.users, id)
+	return nil
+}
+
+func (s *MemoryStore) countAdminsLocked() int {
+	count := 0
+	for _, entry := range s.users {
+		if entry.user.Role == "admin" {
+			count++
+		}
+	}
+	return count
+}
+
+// ImportUser creates or overwrites the user with u.ID.
+func (s *MemoryStore) ImportUser(ctx context.Context, u User, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, entry := range s.users {
+		if (entry.user.Username == u.Username) != (id == u.ID) {
+			return ErrUserAlreadyExists
+		}
+	}
+
+	s.users[u.ID] = &memoryUser{
+		user:         User{ID: u.ID, Username: u.Username, Role: u.Role},
+		passwordHash: passwordHash,
+	}
+	if u.ID > s.lastUserID {
+		s.lastUserID = u.ID
+	}
+	return nil
+}
+
+// ImportPost creates or overwrites the post with p.ID.
+func (s *MemoryStore) ImportPost(ctx context.Context, p Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.claimSlugLocked(p.Slug, p.ID); err != nil {
+		return err
+	}
+	stored := p
+	s.posts[p.ID] = &stored
+	if p.ID > s.lastPostID {
+		s.lastPostID = p.ID
+	}
+	return nil
+}
+
+// ImportPostSlug registers slug for postID.
+func (s *MemoryStore) ImportPostSlug(ctx context.Context, slug string, postID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.claimSlugLocked(slug, postID)
+}
+
+// ImportCategory creates or overwrites the category with c.Slug.
+func (s *MemoryStore) ImportCategory(ctx context.Context, c Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := c
+	s.categories[c.Slug] = &stored
+	return nil
+}
+
+// ImportPostRevision creates or overwrites revision r.Number of post
+// r.PostID.
+func (s *MemoryStore) ImportPostRevision(ctx context.Context, r PostRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.revisions[r.PostID]
+	for i := range kept {
+		if kept[i].Number == r.Number {
+			kept[i] = r
+			return nil
+		}
+	}
+	s.revisions[r.PostID] = append(kept, r)
+	return nil
+}
+
+// ImportComment creates or overwrites the comment with c.ID.
+func (s *MemoryStore) ImportComment(ctx context.Context, c Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := c
+	s.comments[c.ID] = &stored
+	if n, err := strconv.ParseInt(c.ID, 10, 64); err == nil && n > s.lastCommentID {
+		s.lastCommentID = n
+	}
+	return nil
+}
+
+// ImportReaction records a reaction without touching the post counters.
+func (s *MemoryStore) ImportReaction(ctx context.Context, userID, postID int64, reaction string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reactions[memoryReactionKey{userID: userID, postID: postID}] = reaction
+	return nil
+}
+
+// ListAllComments returns every stored comment, oldest first.
+func (s *MemoryStore) ListAllComments(ctx context.Context) ([]Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comments := make([]Comment, 0, len(s.comments))
+	for _, c := range s.comments {
+		comments = append(comments, *c)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+// ListAllReactions returns every stored reaction.
+func (s *MemoryStore) ListAllReactions(ctx context.Context) ([]PostReaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reactions := make([]PostReaction, 0, len(s.reactions))
+	for key, reaction := range s.reactions {
+		reactions = append(reactions, PostReaction{UserID: key.userID, PostID: key.postID, Reaction: reaction})
+	}
+	return reactions, nil
+}
+
+// SetPostCounters overwrites the aggregate counters of a post.
+func (s *MemoryStore) SetPostCounters(ctx context.Context, postID int64, counters PostCounters) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[postID]
+	if !ok {
+		return ErrPostNotFound
+	}
+	p.LikesCount = counters.Likes
+	p.DislikesCount = counters.Dislikes
+	p.CommentsCount = counters.Comments
+	return nil
+}
+
+// DeleteReaction removes a reaction without touching the post counters.
+func (s *MemoryStore) DeleteReaction(ctx context.Context, userID, postID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reactions, memoryReactionKey{userID: userID, postID: postID})
+	return nil
+}
+
+// DeleteAll empties the store and resets its ID allocators.
+func (s *MemoryStore) DeleteAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.posts = make(map[int64]*Post)
+	s.comments = make(map[string]*Comment)
+	s.reactions = make(map[memoryReactionKey]string)
+	s.users = make(map[int64]*memoryUser)
+	s.slugs = make(map[string]int64)
+	s.categories = make(map[string]*Category)
+	s.revisions = make(map[int64][]PostRevision)
+	s.lastPostID = 0
+	s.lastUserID = 0
+	s.lastCommentID = 0
+	return nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SQLiteStore implements Store on top of a SQLite database created by
+// db.OpenSQLite. Multi-step writes (reaction toggles, comment counters, user
+// creation) run inside a single SQL transaction.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore returns a Store backed by the given SQLite handle.
+func NewSQLiteStore(conn *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: conn}
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// withTx runs fn inside a transaction, committing on success and rolling
+// back on error.
+func (s *SQLiteStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
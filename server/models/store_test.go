@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"example.com/blog_backend/db"
+)
+
+// testStores lists every backend the model tests run against. Firestore is
+// added by init only when FIRESTORE_EMULATOR_HOST points at an emulator.
+var testStores = map[string]func(t *testing.T) Store{
+	"memory": func(t *testing.T) Store {
+		return NewMemoryStore()
+	},
+	"sqlite": func(t *testing.T) Store {
+		conn, err := db.OpenSQLite(":memory:")
+		if err != nil {
+			t.Fatalf("failed to open SQLite database: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return NewSQLiteStore(conn)
+	},
+}
+
+func init() {
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		return
+	}
+
+	testStores["firestore"] = func(t *testing.T) Store {
+		// A fresh project ID per test keeps emulator data isolated.
+		projectID := fmt.Sprintf("blog-test-%d", time.Now().UnixNano())
+		client, err := firestore.NewClient(context.Background(), projectID)
+		if err != nil {
+			t.Fatalf("failed to create Firestore emulator client: %v", err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return NewFirestoreStore(client)
+	}
+}
+
+// forEachStore runs fn as a subtest once per backend, with that backend
+// installed as the active store.
+func forEachStore(t *testing.T, fn func(t *testing.T)) {
+	for name, newStore := range testStores {
+		t.Run(name, func(t *testing.T) {
+			SetStore(newStore(t))
+			fn(t)
+		})
+	}
+}
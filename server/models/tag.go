@@ -0,0 +1,207 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	// maxTagLength is the maximum length of a normalized tag in bytes.
+	maxTagLength = 40
+	// MaxPostTags is how many tags a single post may carry.
+	MaxPostTags = 10
+	// MaxMergeSources caps how many tags one MergeTags call folds together.
+	MaxMergeSources = 10
+)
+
+var (
+	// ErrInvalidTag is returned for tags without letters or digits, tags
+	// longer than maxTagLength and posts with more than MaxPostTags tags.
+	ErrInvalidTag = errors.New("invalid tag")
+
+	// ErrTagNotFound is returned when no post carries the tag to rename.
+	ErrTagNotFound = errors.New("tag not found")
+
+	// ErrTagExists is returned when a tag would be renamed to one that is
+	// already in use; MergeTags combines such tags instead.
+	ErrTagExists = errors.New("tag already exists")
+)
+
+// TagCount is one entry of the tag listing. Count is the number of published
+// posts with the tag and Total also counts drafts.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+	Total int    `json:"total,omitempty"`
+}
+
+// NormalizeTag returns the canonical form of a tag: the same lower-case,
+// hyphenated form Slugify produces, so "Go Lang" and "go-lang" are one tag.
+func NormalizeTag(tag string) (string, error) {
+	normalized := Slugify(tag)
+	if normalized == "" || len(normalized) > maxTagLength {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	return normalized, nil
+}
+
+// NormalizeTags normalizes every tag, drops duplicates and sorts the result.
+// It never returns nil, so a post always stores an explicit (possibly empty)
+// tag list.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	if len(normalized) > MaxPostTags {
+		return nil, fmt.Errorf("%w: a post can have at most %d tags", ErrInvalidTag, MaxPostTags)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// orEmptyTags returns tags, or an empty list for a post without tags, so the
+// API always returns a JSON array.
+func orEmptyTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// replaceTags returns tags with every tag in from replaced by to, sorted and
+// without duplicates, and whether anything changed. Every store uses it so
+// renames and merges behave the same on all backends.
+func replaceTags(tags []string, from map[string]bool, to string) ([]string, bool) {
+	changed := false
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if from[tag] {
+			tag = to
+			changed = true
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	sort.Strings(out)
+	return out, changed
+}
+
+// sortTagCounts orders a tag listing by published post count, most used
+// first, then by name.
+func sortTagCounts(tags []TagCount) {
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+}
+
+// ListTags returns every tag in use with its post counts, most used first.
+func ListTags(ctx context.Context) ([]TagCount, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	tags, err := store().ListTags(ctx)
+	if err != nil {
+		return nil, storeError(ctx, err)
+	}
+	sortTagCounts(tags)
+	return tags, nil
+}
+
+// RenameTag renames tag from to to on every post and returns how many posts
+// changed. It fails with ErrTagNotFound when no post has from and with
+// ErrTagExists when to is already in use; use MergeTags to combine tags.
+func RenameTag(ctx context.Context, from, to string) (int, error) {
+	from, err := NormalizeTag(from)
+	if err != nil {
+		return 0, err
+	}
+	to, err = NormalizeTag(to)
+	if err != nil {
+		return 0, err
+	}
+
+	tags, err := ListTags(ctx)
+	if err != nil {
+		return 0, err
+	}
+	found := false
+	for _, t := range tags {
+		switch t.Tag {
+		case from:
+			found = true
+		case to:
+			return 0, ErrTagExists
+		}
+	}
+	if !found {
+		return 0, ErrTagNotFound
+	}
+	if from == to {
+		return 0, nil
+	}
+
+	return replaceStoredTags(ctx, []string{from}, to)
+}
+
+// MergeTags replaces every tag in sources with target on every post, so
+// posts end up with target once, and returns how many posts changed. target
+// may be new or already in use, and may be one of sources.
+func MergeTags(ctx context.Context, sources []string, target string) (int, error) {
+	target, err := NormalizeTag(target)
+	if err != nil {
+		return 0, err
+	}
+	if len(sources) > MaxMergeSources {
+		return 0, fmt.Errorf("%w: at most %d tags can be merged at once", ErrInvalidTag, MaxMergeSources)
+	}
+
+	var from []string
+	for _, source := range sources {
+		tag, err := NormalizeTag(source)
+		if err != nil {
+			return 0, err
+		}
+		if tag != target {
+			from = append(from, tag)
+		}
+	}
+	if len(from) == 0 {
+		return 0, nil
+	}
+
+	return replaceStoredTags(ctx, from, target)
+}
+
+// replaceStoredTags runs TagStore.ReplaceTags and refreshes the search index
+// for the posts it changed.
+func replaceStoredTags(ctx context.Context, from []string, to string) (int, error) {
+	wctx, cancel := writeContext(ctx)
+	defer cancel()
+	ids, err := store().ReplaceTags(wctx, from, to)
+	if err != nil {
+		return 0, storeError(wctx, err)
+	}
+
+	for _, id := range ids {
+		if post, err := GetPostByID(ctx, id); err == nil {
+			indexPost(*post)
+		}
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// ListTags counts tags by reading the tags and status fields of every post.
+// Firestore has no grouped aggregation, so this scans the posts collection
+// without loading the rest of each document.
+func (s *FirestoreStore) ListTags(ctx context.Context) ([]TagCount, error) {
+	iter := s.postsCollection().Select("tags", "status").Documents(ctx)
+	defer iter.Stop()
+
+	counts := make(map[string]*TagCount)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate posts: %w", err)
+		}
+
+		var data firestorePostDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode post document: %w", err)
+		}
+		for _, tag := range data.Tags {
+			c, ok := counts[tag]
+			if !ok {
+				c = &TagCount{Tag: tag}
+				counts[tag] = c
+			}
+			c.Total++
+			if data.Status == "published" {
+				c.Count++
+			}
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for _, c := range counts {
+		tags = append(tags, *c)
+	}
+	return tags, nil
+}
+
+// ReplaceTags finds the posts carrying any tag in from with an
+// array-contains-any query (which accepts up to 30 values, more than
+// MaxMergeSources) and rewrites each post's tags in its own transaction, so
+// a concurrent post update is never overwritten with stale tags.
+func (s *FirestoreStore) ReplaceTags(ctx context.Context, from []string, to string) ([]int64, error) {
+	values := make([]interface{}, len(from))
+	replace := make(map[string]bool, len(from))
+	for i, tag := range from {
+		values[i] = tag
+		replace[tag] = true
+	}
+
+	iter := s.postsCollection().Where("tags", "array-contains-any", values).Select().Documents(ctx)
+	defer iter.Stop()
+
+	var refs []*firestore.DocumentRef
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query tagged posts: %w", err)
+		}
+		refs = append(refs, doc.Ref)
+	}
+
+	var changed []int64
+	for _, ref := range refs {
+		var postID int64
+		updated := false
+		err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			snap, err := tx.Get(ref)
+			if err != nil {
+				return err
+			}
+			var data firestorePostDoc
+			if err := snap.DataTo(&data); err != nil {
+				return fmt.Errorf("failed to decode post document: %w", err)
+			}
+
+			tags, ok := replaceTags(data.Tags, replace, to)
+			postID, updated = data.ID, ok
+			if !ok {
+				return nil
+			}
+			return tx.Update(ref, []firestore.Update{
+				{Path: "tags", Value: tags},
+				{Path: "version", Value: max(data.Version, 1) + 1},
+			})
+		})
+		if err != nil {
+			return changed, fmt.Errorf("failed to replace tags of post %s: %w", ref.ID, err)
+		}
+		if updated {
+			changed = append(changed, postID)
+		}
+	}
+	return changed, nil
+}
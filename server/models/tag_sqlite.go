@@ -0,0 +1,148 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// sqliteQueryer is satisfied by both *sql.DB and *sql.Tx.
+type sqliteQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// sqlitePlaceholders returns "?, ?, ..." with n placeholders.
+func sqlitePlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// setSQLitePostTags replaces the rows in post_tags for postID with tags.
+func setSQLitePostTags(ctx context.Context, tx *sql.Tx, postID int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_tags WHERE post_id = ?`, postID); err != nil {
+		return fmt.Errorf("failed to clear post tags: %w", err)
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO post_tags (post_id, tag) VALUES (?, ?)`, postID, tag); err != nil {
+			return fmt.Errorf("failed to save post tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadSQLitePostTags returns the sorted tags of the posts with the given IDs,
+// or of every post when ids is nil. Posts without tags are left out of the
+// map.
+func loadSQLitePostTags(ctx context.Context, q sqliteQueryer, ids []int64) (map[int64][]string, error) {
+	query := `SELECT post_id, tag FROM post_tags`
+	var args []interface{}
+	if ids != nil {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		query += ` WHERE post_id IN (` + sqlitePlaceholders(len(ids)) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY tag`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[int64][]string)
+	for rows.Next() {
+		var postID int64
+		var tag string
+		if err := rows.Scan(&postID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to decode post tag row: %w", err)
+		}
+		tags[postID] = append(tags[postID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate post tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ListTags counts the posts carrying each tag.
+func (s *SQLiteStore) ListTags(ctx context.Context) ([]TagCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.tag, SUM(p.status = 'published'), COUNT(*)
+		FROM post_tags t JOIN posts p ON p.id = t.post_id
+		GROUP BY t.tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var t TagCount
+		if err := rows.Scan(&t.Tag, &t.Count, &t.Total); err != nil {
+			return nil, fmt.Errorf("failed to decode tag row: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ReplaceTags moves every post_tags row for a tag in from to to in one
+// transaction; the primary key drops the duplicates for posts that already
+// had to.
+func (s *SQLiteStore) ReplaceTags(ctx context.Context, from []string, to string) ([]int64, error) {
+	in := sqlitePlaceholders(len(from))
+	args := make([]interface{}, len(from))
+	for i, tag := range from {
+		args[i] = tag
+	}
+
+	var changed []int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT DISTINCT post_id FROM post_tags WHERE tag IN (`+in+`) ORDER BY post_id`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query tagged posts: %w", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to decode tagged post row: %w", err)
+			}
+			changed = append(changed, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate tagged posts: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO post_tags (post_id, tag)
+			SELECT DISTINCT post_id, ? FROM post_tags WHERE tag IN (`+in+`)`,
+			append([]interface{}{to}, args...)...,
+		); err != nil {
+			return fmt.Errorf("failed to add replacement tag: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE posts SET version = version + 1
+			WHERE id IN (SELECT DISTINCT post_id FROM post_tags WHERE tag IN (`+in+`))`,
+			args...,
+		); err != nil {
+			return fmt.Errorf("failed to bump tagged post versions: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM post_tags WHERE tag IN (`+in+`)`, args...); err != nil {
+			return fmt.Errorf("failed to remove replaced tags: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{"Go Lang", "go-lang", "  Databases ", "SQL"})
+	if err != nil {
+		t.Fatalf("NormalizeTags failed: %v", err)
+	}
+	if want := []string{"databases", "go-lang", "sql"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+
+	if _, err := NormalizeTags([]string{"ok", "!!!"}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag for a tag without letters, got %v", err)
+	}
+	many := make([]string, MaxPostTags+1)
+	for i := range many {
+		many[i] = string(rune('a' + i))
+	}
+	if _, err := NormalizeTags(many); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag for too many tags, got %v", err)
+	}
+}
+
+// Tags filter listings, are counted per tag, and can be renamed and merged
+// across all posts.
+func TestPostTags(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+
+		posts := []*Post{
+			{Title: "One", Content: "Body", Tags: []string{"Go", "databases"}},
+			{Title: "Two", Content: "Body", Tags: []string{"golang", "sql"}},
+			{Title: "Three", Content: "Body", Tags: []string{"go"}, Status: "draft"},
+			{Title: "Four", Content: "Body"},
+		}
+		for _, p := range posts {
+			if err := p.Save(ctx); err != nil {
+				t.Fatalf("failed to create post: %v", err)
+			}
+		}
+
+		tagged := func(tag string) []int64 {
+			t.Helper()
+			page, err := QueryPosts(ctx, PostFilter{Tag: tag})
+			if err != nil {
+				t.Fatalf("QueryPosts failed: %v", err)
+			}
+			var ids []int64
+			for _, p := range page.Posts {
+				ids = append(ids, p.ID)
+			}
+			return ids
+		}
+		if got := tagged("go"); !reflect.DeepEqual(got, []int64{3, 1}) {
+			t.Fatalf("expected posts 3 and 1 tagged go, got %v", got)
+		}
+
+		tags, err := ListTags(ctx)
+		if err != nil {
+			t.Fatalf("ListTags failed: %v", err)
+		}
+		want := []TagCount{
+			{Tag: "databases", Count: 1, Total: 1},
+			{Tag: "go", Count: 1, Total: 2},
+			{Tag: "golang", Count: 1, Total: 1},
+			{Tag: "sql", Count: 1, Total: 1},
+		}
+		if !reflect.DeepEqual(tags, want) {
+			t.Fatalf("expected %+v, got %+v", want, tags)
+		}
+
+		if _, err := RenameTag(ctx, "sql", "databases"); !errors.Is(err, ErrTagExists) {
+			t.Fatalf("expected ErrTagExists, got %v", err)
+		}
+		if _, err := RenameTag(ctx, "missing", "other"); !errors.Is(err, ErrTagNotFound) {
+			t.Fatalf("expected ErrTagNotFound, got %v", err)
+		}
+		if n, err := RenameTag(ctx, "SQL", "Relational"); err != nil || n != 1 {
+			t.Fatalf("expected one renamed post, got %d, %v", n, err)
+		}
+
+		if n, err := MergeTags(ctx, []string{"golang", "go"}, "go"); err != nil || n != 1 {
+			t.Fatalf("expected one merged post, got %d, %v", n, err)
+		}
+		if got := tagged("go"); !reflect.DeepEqual(got, []int64{3, 2, 1}) {
+			t.Fatalf("expected posts 3, 2 and 1 tagged go after the merge, got %v", got)
+		}
+
+		post, err := GetPostByID(ctx, 2)
+		if err != nil {
+			t.Fatalf("failed to load post: %v", err)
+		}
+		if want := []string{"go", "relational"}; !reflect.DeepEqual(post.Tags, want) {
+			t.Fatalf("expected tags %v, got %v", want, post.Tags)
+		}
+
+		// Update replaces the tag list; an empty list clears it.
+		post.Tags = nil
+		if err := post.Update(ctx, post.AuthorID); err != nil {
+			t.Fatalf("failed to update post: %v", err)
+		}
+		if got := tagged("relational"); len(got) != 0 {
+			t.Fatalf("expected no post tagged relational, got %v", got)
+		}
+		untagged, err := GetPostByID(ctx, 4)
+		if err != nil {
+			t.Fatalf("failed to load post: %v", err)
+		}
+		if untagged.Tags == nil || len(untagged.Tags) != 0 {
+			t.Fatalf("expected an empty tag list, got %#v", untagged.Tags)
+		}
+	})
+}
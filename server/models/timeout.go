@@ -0,0 +1,78 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Timeouts bounds how long a single model operation may wait on the store.
+// The deadline of the caller's context (for example a disconnecting HTTP
+// client) still applies on top of these. A zero duration disables that
+// timeout.
+type Timeouts struct {
+	// Read applies to lookups and listings.
+	Read time.Duration
+	// Write applies to creates, updates, deletes and reaction toggles.
+	Write time.Duration
+}
+
+// DefaultTimeouts is used until SetTimeouts is called.
+var DefaultTimeouts = Timeouts{
+	Read:  5 * time.Second,
+	Write: 10 * time.Second,
+}
+
+var timeouts = DefaultTimeouts
+
+// SetTimeouts changes the per-operation timeouts used by the package-level
+// model functions. It is expected to be called once at startup.
+func SetTimeouts(t Timeouts) {
+	timeouts = t
+}
+
+func readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, timeouts.Read)
+}
+
+func writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, timeouts.Write)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// storeError translates deadline and cancellation failures from any backend
+// into ErrTimeout or ErrUnavailable so callers can tell them apart from real
+// failures. Other errors are returned unchanged.
+func storeError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrTimeout) || errors.Is(err, ErrUnavailable) {
+		return err
+	}
+
+	code := status.Code(err)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), code == codes.DeadlineExceeded:
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	case errors.Is(err, context.Canceled), code == codes.Canceled, code == codes.Unavailable:
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	// Some drivers report an interrupted call with their own error type;
+	// fall back to the context's state.
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	case context.Canceled:
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
@@ -5,10 +5,6 @@ import (
 	"errors"
 	"fmt"
 
-	"cloud.google.com/go/firestore"
-	"google.golang.org/api/iterator"
-
-	"example.com/blog_backend/db"
 	"example.com/blog_backend/utils"
 )
 
@@ -42,318 +38,80 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 )
 
-// firestoreUserDoc is the Firestore representation of a user document.
-type firestoreUserDoc struct {
-	ID           int64  `firestore:"id"`
-	Username     string `firestore:"username"`
-	PasswordHash string `firestore:"password_hash"`
-	Role         string `firestore:"role"`
-}
-
-func usersCollection() *firestore.CollectionRef {
-	if db.FirestoreClient == nil {
-		panic("Firestore client is not initialized")
-	}
-	return db.FirestoreClient.Collection("users")
-}
-
-// nextUserID returns the next numeric user ID by looking at the existing
-// maximum id value in Firestore. If there are no users yet, it returns 1.
-func nextUserID(ctx context.Context) (int64, error) {
-	col := usersCollection()
-	iter := col.OrderBy("id", firestore.Desc).Limit(1).Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
-		return 1, nil
-	}
-	if err != nil {
-		return 0, fmt.Errorf("failed to get last user id: %w", err)
-	}
-
-	var data firestoreUserDoc
-	if err := doc.DataTo(&data); err != nil {
-		return 0, fmt.Errorf("failed to decode last user document: %w", err)
-	}
-
-	return data.ID + 1, nil
-}
-
-// Save creates a new user in Firestore. It preserves the original behavior
-// where the very first user becomes an admin and subsequent users are
-// regular users by default.
-func (u *User) Save() error {
-	ctx := context.Background()
-	col := usersCollection()
-
-	// Ensure the username is unique.
-	dupIter := col.Where("username", "==", u.Username).Limit(1).Documents(ctx)
-	defer dupIter.Stop()
-
-	if _, err := dupIter.Next(); err != iterator.Done {
-		if err == nil {
-			return ErrUserAlreadyExists
-		}
-		return fmt.Errorf("failed to check for existing user: %w", err)
-	}
-
-	// Determine role: first user ever becomes admin, others default to user.
-	role := "user"
-	adminIter := col.Where("role", "==", "admin").Limit(1).Documents(ctx)
-	defer adminIter.Stop()
-
-	if _, err := adminIter.Next(); err == iterator.Done {
-		role = "admin"
-	} else if err != nil {
-		return fmt.Errorf("failed to check for existing admin: %w", err)
-	}
-
-	// Get the next numeric ID.
-	nextID, err := nextUserID(ctx)
-	if err != nil {
-		return err
-	}
-
+// Save creates a new user. The very first user becomes an admin and
+// subsequent users are regular users by default.
+func (u *User) Save(ctx context.Context) error {
 	// Hash the password before storing.
 	passwordHash, err := utils.HashPassword(u.Password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	doc := firestoreUserDoc{
-		ID:           nextID,
-		Username:     u.Username,
-		PasswordHash: passwordHash,
-		Role:         role,
-	}
-
-	// Use an auto-generated document ID; we rely on the stored numeric ID
-	// field for relationships and JWTs.
-	_, _, err = col.Add(ctx, doc)
-	if err != nil {
-		return fmt.Errorf("failed to create user in Firestore: %w", err)
-	}
-
-	u.ID = nextID
-	u.Role = role
-	return nil
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().CreateUser(ctx, u, passwordHash))
 }
 
 // GetAllUsers returns all users without exposing password hashes.
-func GetAllUsers() ([]User, error) {
-	ctx := context.Background()
-	col := usersCollection()
-	iter := col.Documents(ctx)
-	defer iter.Stop()
-
-	var users []User
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to iterate users: %w", err)
-		}
-
-		var data firestoreUserDoc
-		if err := doc.DataTo(&data); err != nil {
-			return nil, fmt.Errorf("failed to decode user document: %w", err)
-		}
-
-		users = append(users, User{
-			ID:       data.ID,
-			Username: data.Username,
-			Role:     data.Role,
-		})
-	}
-
-	return users, nil
+func GetAllUsers(ctx context.Context) ([]User, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	users, err := store().ListUsers(ctx)
+	return users, storeError(ctx, err)
 }
 
-// ValidateCredentials validates a username/password combination against
-// Firestore and populates the User struct with ID and Role on success.
-func (u *User) ValidateCredentials() error {
-	ctx := context.Background()
-	col := usersCollection()
-
-	iter := col.Where("username", "==", u.Username).Limit(1).Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
-		return ErrUserNotFound
-	}
+// ValidateCredentials validates a username/password combination and populates
+// the User struct with ID and Role on success.
+func (u *User) ValidateCredentials(ctx context.Context) error {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	stored, passwordHash, err := store().GetUserByUsername(ctx, u.Username)
 	if err != nil {
-		return fmt.Errorf("failed to query user by username: %w", err)
-	}
-
-	var data firestoreUserDoc
-	if err := doc.DataTo(&data); err != nil {
-		return fmt.Errorf("failed to decode user document: %w", err)
+		return storeError(ctx, err)
 	}
 
-	if !utils.CheckPasswordHash(u.Password, data.PasswordHash) {
+	if !utils.CheckPasswordHash(u.Password, passwordHash) {
 		return ErrInvalidCredentials
 	}
 
-	u.ID = data.ID
-	u.Role = data.Role
+	u.ID = stored.ID
+	u.Role = stored.Role
 	return nil
 }
 
-// UpdateUserRole updates a user's role while preserving the original
-// semantics, including preventing demotion of the last admin.
-func UpdateUserRole(userID int64, newRole string) error {
+// UpdateUserRole updates a user's role, preventing demotion of the last admin.
+func UpdateUserRole(ctx context.Context, userID int64, newRole string) error {
 	if newRole != "admin" && newRole != "editor" && newRole != "user" {
 		return ErrInvalidRole
 	}
 
-	ctx := context.Background()
-	col := usersCollection()
-
-	// Find the user document by id field.
-	iter := col.Where("id", "==", userID).Limit(1).Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
-		return ErrUserNotFound
-	}
-	if err != nil {
-		return fmt.Errorf("failed to query user by id: %w", err)
-	}
-
-	var data firestoreUserDoc
-	if err := doc.DataTo(&data); err != nil {
-		return fmt.Errorf("failed to decode user document: %w", err)
-	}
-
-	// If this user is currently an admin and we're demoting them, ensure they
-	// are not the last remaining admin.
-	if data.Role == "admin" && newRole != "admin" {
-		adminIter := col.Where("role", "==", "admin").Documents(ctx)
-		defer adminIter.Stop()
-
-		adminCount := 0
-		for {
-			_, err := adminIter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return fmt.Errorf("failed to count admins: %w", err)
-			}
-			adminCount++
-		}
-
-		if adminCount <= 1 {
-			return ErrCannotDemoteLastAdmin
-		}
-	}
-
-	_, err = doc.Ref.Update(ctx, []firestore.Update{
-		{Path: "role", Value: newRole},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to update user role: %w", err)
-	}
-
-	return nil
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().UpdateUserRole(ctx, userID, newRole))
 }
 
-// DeleteUser removes a user document from Firestore. If the user is an admin,
-// this function ensures they are not the last remaining admin, reusing the
-// same safety semantics as UpdateUserRole.
-func DeleteUser(userID int64) error {
-	ctx := context.Background()
-	col := usersCollection()
-
-	// Find the user document by id field.
-	iter := col.Where("id", "==", userID).Limit(1).Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
-		return ErrUserNotFound
-	}
-	if err != nil {
-		return fmt.Errorf("failed to query user by id: %w", err)
-	}
-
-	var data firestoreUserDoc
-	if err := doc.DataTo(&data); err != nil {
-		return fmt.Errorf("failed to decode user document: %w", err)
-	}
-
-	// If this user is currently an admin, ensure they are not the last
-	// remaining admin before deleting.
-	if data.Role == "admin" {
-		adminIter := col.Where("role", "==", "admin").Documents(ctx)
-		defer adminIter.Stop()
-
-		adminCount := 0
-		for {
-			_, err := adminIter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return fmt.Errorf("failed to count admins: %w", err)
-			}
-			adminCount++
-		}
-
-		if adminCount <= 1 {
-			return ErrCannotDemoteLastAdmin
-		}
-	}
-
-	// Anonymize any comments authored by this user so their content remains
-	// but no longer links back to their account.
-	if err := AnonymizeCommentsForUser(userID); err != nil {
-		return fmt.Errorf("failed to anonymize user comments: %w", err)
-	}
-
-	if _, err := doc.Ref.Delete(ctx); err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
-	}
-
-	return nil
+// DeleteUser removes a user account and anonymizes their comments. Deleting
+// the last remaining admin is refused with ErrCannotDemoteLastAdmin.
+func DeleteUser(ctx context.Context, userID int64) error {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+	return storeError(ctx, store().DeleteUser(ctx, userID))
 }
 
 // FindOrCreateUserByEmail finds a user by email (used as username) or creates
 // a new one if it does not exist. This is used for Google login.
-func FindOrCreateUserByEmail(email, googleSub string) (*User, error) {
-	ctx := context.Background()
-	col := usersCollection()
-
-	iter := col.Where("username", "==", email).Limit(1).Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err != nil && err != iterator.Done {
-		return nil, fmt.Errorf("failed to query user by email: %w", err)
-	}
-
+func FindOrCreateUserByEmail(ctx context.Context, email, googleSub string) (*User, error) {
+	existing, err := GetUserByUsername(ctx, email)
 	if err == nil {
-		// Existing user.
-		var data firestoreUserDoc
-		if err := doc.DataTo(&data); err != nil {
-			return nil, fmt.Errorf("failed to decode user document: %w", err)
-		}
-
-		return &User{
-			ID:       data.ID,
-			Username: data.Username,
-			Role:     data.Role,
-		}, nil
+		return existing, nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to query user by email: %w", err)
 	}
 
 	// No existing user; create one. We still need a password for the hashing
-// and storage pipeline, but it will not actually be used for authentication
-// when logging in via Google.
+	// and storage pipeline, but it will not actually be used for authentication
+	// when logging in via Google.
 	placeholderPassword := "google:placeholder"
 	if googleSub != "" {
 		placeholderPassword = "google:" + googleSub
@@ -364,11 +122,11 @@ func FindOrCreateUserByEmail(email, googleSub string) (*User, error) {
 		Password: placeholderPassword,
 	}
 
-	if err := newUser.Save(); err != nil {
+	if err := newUser.Save(ctx); err != nil {
 		// If another process created the user concurrently, fall back to
 		// reading it.
 		if errors.Is(err, ErrUserAlreadyExists) {
-			return FindOrCreateUserByEmail(email, "")
+			return FindOrCreateUserByEmail(ctx, email, "")
 		}
 		return nil, err
 	}
@@ -376,30 +134,18 @@ func FindOrCreateUserByEmail(email, googleSub string) (*User, error) {
 	return newUser, nil
 }
 
-	// GetUserByID looks up a user by their numeric ID.
-	func GetUserByID(userID int64) (*User, error) {
-		ctx := context.Background()
-		col := usersCollection()
-
-		iter := col.Where("id", "==", userID).Limit(1).Documents(ctx)
-		defer iter.Stop()
-
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			return nil, ErrUserNotFound
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to query user by id: %w", err)
-		}
-
-		var data firestoreUserDoc
-		if err := doc.DataTo(&data); err != nil {
-			return nil, fmt.Errorf("failed to decode user document: %w", err)
-		}
+// GetUserByUsername looks up a user by username.
+func GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	user, _, err := store().GetUserByUsername(ctx, username)
+	return user, storeError(ctx, err)
+}
 
-		return &User{
-			ID:       data.ID,
-			Username: data.Username,
-			Role:     data.Role,
-		}, nil
-	}
+// GetUserByID looks up a user by their numeric ID.
+func GetUserByID(ctx context.Context, userID int64) (*User, error) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+	user, err := store().GetUser(ctx, userID)
+	return user, storeError(ctx, err)
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// firestoreUserDoc is the Firestore representation of a user document.
+type firestoreUserDoc struct {
+	ID           int64  `firestore:"id"`
+	Username     string `firestore:"username"`
+	PasswordHash string `firestore:"password_hash"`
+	Role         string `firestore:"role"`
+}
+
+func (d firestoreUserDoc) toUser() User {
+	return User{
+		ID:       d.ID,
+		Username: d.Username,
+		Role:     d.Role,
+	}
+}
+
+// findUserDoc returns the user document whose "field" equals value.
+func (s *FirestoreStore) findUserDoc(ctx context.Context, field string, value interface{}) (*firestore.DocumentSnapshot, firestoreUserDoc, error) {
+	iter := s.usersCollection().Where(field, "==", value).Limit(1).Documents(ctx)
+	defer iter.Stop()
+
+	var data firestoreUserDoc
+	doc, err := iter.Next()
+	if err == iterator.Done {
+		return nil, data, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, data, fmt.Errorf("failed to query user by %s: %w", field, err)
+	}
+
+	if err := doc.DataTo(&data); err != nil {
+		return nil, data, fmt.Errorf("failed to decode user document: %w", err)
+	}
+	return doc, data, nil
+}
+
+// countAdmins returns how many users currently hold the admin role.
+func (s *FirestoreStore) countAdmins(ctx context.Context) (int, error) {
+	adminIter := s.usersCollection().Where("role", "==", "admin").Documents(ctx)
+	defer adminIter.Stop()
+
+	adminCount := 0
+	for {
+		_, err := adminIter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to count admins: %w", err)
+		}
+		adminCount++
+	}
+	return adminCount, nil
+}
+
+// CreateUser creates a new user in Firestore. The very first user becomes an
+// admin and subsequent users are regular users by default.
+//
+// The uniqueness check, the admin check and the ID allocation run in one
+// transaction. Because every CreateUser transaction also writes the users
+// counter document, concurrent sign-ups are serialized by Firestore, so two
+// racing first users cannot both become admin or share a username.
+func (s *FirestoreStore) CreateUser(ctx context.Context, u *User, passwordHash string) error {
+	col := s.usersCollection()
+
+	var newID int64
+	var newRole string
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// Ensure the username is unique.
+		dupIter := tx.Documents(col.Where("username", "==", u.Username).Limit(1))
+		_, err := dupIter.Next()
+		dupIter.Stop()
+		if err != iterator.Done {
+			if err == nil {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to check for existing user: %w", err)
+		}
+
+		// Determine role: first user ever becomes admin, others default to user.
+		role := "user"
+		adminIter := tx.Documents(col.Where("role", "==", "admin").Limit(1))
+		_, err = adminIter.Next()
+		adminIter.Stop()
+		if err == iterator.Done {
+			role = "admin"
+		} else if err != nil {
+			return fmt.Errorf("failed to check for existing admin: %w", err)
+		}
+
+		nextID, err := s.allocateID(tx, col)
+		if err != nil {
+			return err
+		}
+
+		doc := firestoreUserDoc{
+			ID:           nextID,
+			Username:     u.Username,
+			PasswordHash: passwordHash,
+			Role:         role,
+		}
+
+		// Use an auto-generated document ID; we rely on the stored numeric ID
+		// field for relationships and JWTs.
+		if err := tx.Create(col.NewDoc(), doc); err != nil {
+			return fmt.Errorf("failed to create user in Firestore: %w", err)
+		}
+
+		newID = nextID
+		newRole = role
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	u.ID = newID
+	u.Role = newRole
+	return nil
+}
+
+// ListUsers returns all users without exposing password hashes.
+func (s *FirestoreStore) ListUsers(ctx context.Context) ([]User, error) {
+	iter := s.usersCollection().Documents(ctx)
+	defer iter.Stop()
+
+	var users []User
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate users: %w", err)
+		}
+
+		var data firestoreUserDoc
+		if err := doc.DataTo(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode user document: %w", err)
+		}
+
+		users = append(users, data.toUser())
+	}
+
+	return users, nil
+}
+
+// GetUser looks up a user by their numeric ID.
+func (s *FirestoreStore) GetUser(ctx context.Context, id int64) (*User, error) {
+	_, data, err := s.findUserDoc(ctx, "id", id)
+	if err != nil {
+		return nil, err
+	}
+	user := data.toUser()
+	return &user, nil
+}
+
+// GetUserByUsername looks up a user by username and also returns the stored
+// password hash.
+func (s *FirestoreStore) GetUserByUsername(ctx context.Context, username string) (*User, string, error) {
+	_, data, err := s.findUserDoc(ctx, "username", username)
+	if err != nil {
+		return nil, "", err
+	}
+	user := data.toUser()
+	return &user, data.PasswordHash, nil
+}
+
+// UpdateUserRole updates a user's role, refusing to demote the last admin.
+func (s *FirestoreStore) UpdateUserRole(ctx context.Context, id int64, role string) error {
+	doc, data, err := s.findUserDoc(ctx, "id", id)
+	if err != nil {
+		return err
+	}
+
+	// If this user is currently an admin and we're demoting them, ensure they
+	// are not the last remaining admin.
+	if data.Role == "admin" && role != "admin" {
+		adminCount, err := s.countAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		if adminCount <= 1 {
+			return ErrCannotDemoteLastAdmin
+		}
+	}
+
+	_, err = doc.Ref.Update(ctx, []firestore.Update{
+		{Path: "role", Value: role},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUser removes a user document from Firestore. If the user is an admin,
+// this function ensures they are not the last remaining admin, reusing the
+// same safety semantics as UpdateUserRole.
+func (s *FirestoreStore) DeleteUser(ctx context.Context, id int64) error {
+	doc, data, err := s.findUserDoc(ctx, "id", id)
+	if err != nil {
+		return err
+	}
+
+	// If this user is currently an admin, ensure they are not the last
+	// remaining admin before deleting.
+	if data.Role == "admin" {
+		adminCount, err := s.countAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		if adminCount <= 1 {
+			return ErrCannotDemoteLastAdmin
+		}
+	}
+
+	// Anonymize any comments authored by this user so their content remains
+	// but no longer links back to their account.
+	if err := s.AnonymizeCommentsForUser(ctx, id); err != nil {
+		return fmt.Errorf("failed to anonymize user comments: %w", err)
+	}
+
+	if _, err := doc.Ref.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateUser inserts a new user. The uniqueness check, the "first user
+// becomes admin" decision and the insert share one transaction.
+func (s *SQLiteStore) CreateUser(ctx context.Context, u *User, passwordHash string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE username = ?`, u.Username).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing user: %w", err)
+		}
+		if exists > 0 {
+			return ErrUserAlreadyExists
+		}
+
+		adminCount, err := sqliteCountAdmins(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Determine role: first user ever becomes admin, others default to user.
+		role := "user"
+		if adminCount == 0 {
+			role = "admin"
+		}
+
+		result, err := tx.ExecContext(ctx, `INSERT INTO users (username, password_hash, role) VALUES (?, ?, ?)`, u.Username, passwordHash, role)
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to read new user id: %w", err)
+		}
+
+		u.ID = id
+		u.Role = role
+		return nil
+	})
+}
+
+// ListUsers returns all users ordered by ID, without password hashes.
+func (s *SQLiteStore) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, username, role FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Role); err != nil {
+			return nil, fmt.Errorf("failed to decode user row: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetUser looks up a user by their numeric ID.
+func (s *SQLiteStore) GetUser(ctx context.Context, id int64) (*User, error) {
+	var u User
+	err := s.db.QueryRowContext(ctx, `SELECT id, username, role FROM users WHERE id = ?`, id).Scan(&u.ID, &u.Username, &u.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user by id: %w", err)
+	}
+	return &u, nil
+}
+
+// GetUserByUsername looks up a user by username and also returns the stored
+// password hash.
+func (s *SQLiteStore) GetUserByUsername(ctx context.Context, username string) (*User, string, error) {
+	var u User
+	var passwordHash string
+	err := s.db.QueryRowContext(ctx, `SELECT id, username, role, password_hash FROM users WHERE username = ?`, username).
+		Scan(&u.ID, &u.Username, &u.Role, &passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrUserNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query user by username: %w", err)
+	}
+	return &u, passwordHash, nil
+}
+
+// UpdateUserRole updates a user's role, refusing to demote the last admin.
+func (s *SQLiteStore) UpdateUserRole(ctx context.Context, id int64, role string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		currentRole, err := sqliteUserRole(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if currentRole == "admin" && role != "admin" {
+			adminCount, err := sqliteCountAdmins(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if adminCount <= 1 {
+				return ErrCannotDemoteLastAdmin
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET role = ? WHERE id = ?`, role, id); err != nil {
+			return fmt.Errorf("failed to update user role: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteUser removes a user and anonymizes their comments, refusing to delete
+// the last admin.
+func (s *SQLiteStore) DeleteUser(ctx context.Context, id int64) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		currentRole, err := sqliteUserRole(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if currentRole == "admin" {
+			adminCount, err := sqliteCountAdmins(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if adminCount <= 1 {
+				return ErrCannotDemoteLastAdmin
+			}
+		}
+
+		// Anonymize any comments authored by this user so their content
+		// remains but no longer links back to their account.
+		if _, err := tx.ExecContext(ctx, `UPDATE post_comments SET user_id = 0, author_name = ? WHERE user_id = ?`, deletedUserAuthorName, id); err != nil {
+			return fmt.Errorf("failed to anonymize user comments: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		return nil
+	})
+}
+
+func sqliteUserRole(ctx context.Context, tx *sql.Tx, id int64) (string, error) {
+	var role string
+	err := tx.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ?`, id).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query user by id: %w", err)
+	}
+	return role, nil
+}
+
+func sqliteCountAdmins(ctx context.Context, tx *sql.Tx) (int, error) {
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = 'admin'`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count admins: %w", err)
+	}
+	return count, nil
+}
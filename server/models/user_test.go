@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// The first user to sign up becomes an admin, and the last remaining admin
+// can neither be demoted nor deleted.
+func TestUserRolesProtectLastAdmin(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		admin := &User{Username: "first", Password: "testpassword"}
+		if err := admin.Save(ctx); err != nil {
+			t.Fatalf("failed to create first user: %v", err)
+		}
+		if admin.Role != "admin" {
+			t.Fatalf("expected first user to be admin, got %q", admin.Role)
+		}
+
+		reader := &User{Username: "second", Password: "testpassword"}
+		if err := reader.Save(ctx); err != nil {
+			t.Fatalf("failed to create second user: %v", err)
+		}
+		if reader.Role != "user" {
+			t.Fatalf("expected second user to be a regular user, got %q", reader.Role)
+		}
+
+		if err := UpdateUserRole(ctx, admin.ID, "user"); !errors.Is(err, ErrCannotDemoteLastAdmin) {
+			t.Fatalf("expected ErrCannotDemoteLastAdmin when demoting last admin, got %v", err)
+		}
+		if err := DeleteUser(ctx, admin.ID); !errors.Is(err, ErrCannotDemoteLastAdmin) {
+			t.Fatalf("expected ErrCannotDemoteLastAdmin when deleting last admin, got %v", err)
+		}
+
+		// Once a second admin exists the first one can step down.
+		if err := UpdateUserRole(ctx, reader.ID, "admin"); err != nil {
+			t.Fatalf("failed to promote second user: %v", err)
+		}
+		if err := UpdateUserRole(ctx, admin.ID, "editor"); err != nil {
+			t.Fatalf("failed to demote first admin: %v", err)
+		}
+
+		login := &User{Username: "second", Password: "testpassword"}
+		if err := login.ValidateCredentials(ctx); err != nil {
+			t.Fatalf("expected valid credentials, got %v", err)
+		}
+		if login.ID != reader.ID || login.Role != "admin" {
+			t.Fatalf("unexpected user after login: %+v", login)
+		}
+
+		login.Password = "wrong"
+		if err := login.ValidateCredentials(ctx); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+}
+
+// Deleting a user keeps their comments but detaches them from the account.
+func TestDeleteUserAnonymizesComments(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		admin := &User{Username: "admin", Password: "testpassword"}
+		if err := admin.Save(ctx); err != nil {
+			t.Fatalf("failed to create admin: %v", err)
+		}
+		reader := &User{Username: "reader", Password: "testpassword"}
+		if err := reader.Save(ctx); err != nil {
+			t.Fatalf("failed to create reader: %v", err)
+		}
+
+		post := &Post{Title: "Post", Content: "Body", AuthorID: admin.ID}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		comment, err := CreateComment(ctx, post.ID, reader.ID, reader.Username, "Nice post")
+		if err != nil {
+			t.Fatalf("failed to create comment: %v", err)
+		}
+
+		if err := DeleteUser(ctx, reader.ID); err != nil {
+			t.Fatalf("failed to delete reader: %v", err)
+		}
+		if _, err := GetUserByID(ctx, reader.ID); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("expected ErrUserNotFound after delete, got %v", err)
+		}
+
+		got, err := GetCommentByID(ctx, comment.ID)
+		if err != nil {
+			t.Fatalf("failed to reload comment: %v", err)
+		}
+		if got.UserID != 0 || got.AuthorName != "Deleted user" || got.Content != "Nice post" {
+			t.Fatalf("expected anonymized comment with content intact, got %+v", got)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Every update bumps a post's version, and an update that expects a version
+// the post no longer has is refused without writing anything.
+func TestPostVersionConflict(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		post := &Post{Title: "Versioned", Content: "Body", AuthorID: 1}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		if post.Version != 1 {
+			t.Fatalf("expected a new post at version 1, got %d", post.Version)
+		}
+
+		first, second := *post, *post
+		first.Content = "First"
+		if err := first.Update(ctx, 1); err != nil {
+			t.Fatalf("failed to update post: %v", err)
+		}
+		if first.Version != 2 {
+			t.Errorf("expected version 2 after the update, got %d", first.Version)
+		}
+
+		second.Content = "Second"
+		if err := second.Update(ctx, 1); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("expected ErrVersionConflict for a stale version, got %v", err)
+		}
+		stored, err := GetPostByID(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("failed to load post: %v", err)
+		}
+		if stored.Content != "First" || stored.Version != 2 {
+			t.Errorf("expected the first update to survive, got %q at version %d", stored.Content, stored.Version)
+		}
+		if revisions, err := ListPostRevisions(ctx, post.ID); err != nil || len(revisions) != 2 {
+			t.Errorf("expected no revision for the refused update, got %d (%v)", len(revisions), err)
+		}
+
+		second.Version = 0
+		if err := second.Update(ctx, 1); err != nil || second.Version != 3 {
+			t.Errorf("expected an unconditional update to version 3, got %d (%v)", second.Version, err)
+		}
+	})
+}
+
+// Scheduled publishing, tag renames and category renames change the stored
+// post, so each of them bumps its version too.
+func TestPostVersionBumpedByBulkChanges(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		if err := (&Category{Name: "News"}).Save(ctx); err != nil {
+			t.Fatalf("failed to create category: %v", err)
+		}
+		publishAt := time.Now().Add(time.Hour)
+		post := &Post{Title: "Later", Content: "Body", Category: "News", Tags: []string{"go"}, Status: "scheduled", PublishAt: &publishAt}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+
+		version := func() int64 {
+			t.Helper()
+			stored, err := GetPostByID(ctx, post.ID)
+			if err != nil {
+				t.Fatalf("failed to load post: %v", err)
+			}
+			return stored.Version
+		}
+
+		if _, err := ApplyPostSchedule(ctx, publishAt); err != nil {
+			t.Fatalf("failed to publish scheduled post: %v", err)
+		}
+		if v := version(); v != 2 {
+			t.Errorf("expected version 2 after publishing, got %d", v)
+		}
+		if _, err := RenameTag(ctx, "go", "golang"); err != nil {
+			t.Fatalf("failed to rename tag: %v", err)
+		}
+		if v := version(); v != 3 {
+			t.Errorf("expected version 3 after the tag rename, got %d", v)
+		}
+		if err := (&Category{Name: "Updates"}).Update(ctx, "news"); err != nil {
+			t.Fatalf("failed to rename category: %v", err)
+		}
+		if v := version(); v != 4 {
+			t.Errorf("expected version 4 after the category rename, got %d", v)
+		}
+	})
+}
+
+// Comment edits are versioned the same way.
+func TestCommentVersionConflict(t *testing.T) {
+	forEachStore(t, func(t *testing.T) {
+		ctx := context.Background()
+		post := &Post{Title: "Comments", Content: "Body"}
+		if err := post.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		comment, err := CreateComment(ctx, post.ID, 1, "reader", "Hi")
+		if err != nil {
+			t.Fatalf("failed to create comment: %v", err)
+		}
+		if comment.Version != 1 {
+			t.Fatalf("expected a new comment at version 1, got %d", comment.Version)
+		}
+
+		updated, err := UpdateCommentContent(ctx, comment.ID, 1, 1, "Hello")
+		if err != nil {
+			t.Fatalf("failed to update comment: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Errorf("expected version 2 after the update, got %d", updated.Version)
+		}
+
+		if _, err := UpdateCommentContent(ctx, comment.ID, 1, 1, "Hey"); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("expected ErrVersionConflict for a stale version, got %v", err)
+		}
+		stored, err := GetCommentByID(ctx, comment.ID)
+		if err != nil {
+			t.Fatalf("failed to load comment: %v", err)
+		}
+		if stored.Content != "Hello" || stored.Version != 2 {
+			t.Errorf("expected the first update to survive, got %q at version %d", stored.Content, stored.Version)
+		}
+	})
+}
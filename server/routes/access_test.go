@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"example.com/blog_backend/models"
+	"example.com/blog_backend/search"
+	"example.com/blog_backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// In public read mode visitors read published posts without a token, while
+// writes and members-only posts still need a login.
+func TestPublicReadMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	SetPublicRead(true)
+	defer SetPublicRead(false)
+	router := gin.New()
+	RegisterRoutes(router)
+	ctx := context.Background()
+
+	public := &models.Post{Title: "Public", Content: "Everyone can read this"}
+	members := &models.Post{Title: "Members", Content: "Only for members", MembersOnly: true}
+	for _, p := range []*models.Post{public, members} {
+		if err := p.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+	if _, err := search.Init(ctx); err != nil {
+		t.Fatalf("failed to build search index: %v", err)
+	}
+	token, err := utils.GenerateJWTToken("reader", 7, "user")
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	postPath := func(p *models.Post) string { return "/posts/" + strconv.FormatInt(p.ID, 10) }
+
+	w := request(http.MethodGet, "/posts", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected anonymous listing to succeed, got %d", w.Code)
+	}
+	var page models.PostPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	for _, p := range page.Posts {
+		if p.MembersOnly && p.Excerpt != "" {
+			t.Errorf("expected no excerpt for members-only post %d, got %q", p.ID, p.Excerpt)
+		}
+	}
+	if len(page.Posts) != 2 {
+		t.Errorf("expected both posts to be listed, got %d", len(page.Posts))
+	}
+
+	for path, want := range map[string]int{
+		postPath(public):                 http.StatusOK,
+		postPath(public) + "/comments":   http.StatusOK,
+		postPath(members):                http.StatusUnauthorized,
+		postPath(members) + "/comments":  http.StatusUnauthorized,
+		"/posts/by-slug/" + members.Slug: http.StatusUnauthorized,
+	} {
+		if w := request(http.MethodGet, path, "", ""); w.Code != want {
+			t.Errorf("GET %s anonymously: expected %d, got %d", path, want, w.Code)
+		}
+	}
+	if w := request(http.MethodGet, "/posts/search?q=only", "", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Members") {
+		t.Errorf("expected anonymous search to leave out members-only posts, got %d %s", w.Code, w.Body.String())
+	}
+	if w := request(http.MethodGet, "/posts/search?q=only", token, ""); !strings.Contains(w.Body.String(), "Members") {
+		t.Errorf("expected signed-in search to find members-only posts, got %s", w.Body.String())
+	}
+
+	// An invalid token reads anonymously instead of failing.
+	if w := request(http.MethodGet, postPath(public), "not-a-token", ""); w.Code != http.StatusOK {
+		t.Errorf("expected an invalid token to read anonymously, got %d", w.Code)
+	}
+
+	if w := request(http.MethodGet, postPath(members), token, ""); w.Code != http.StatusOK {
+		t.Errorf("expected a signed-in reader to read the members-only post, got %d", w.Code)
+	}
+
+	for _, write := range []struct{ method, path, body string }{
+		{http.MethodPost, postPath(public) + "/comments", `{"content":"Hi"}`},
+		{http.MethodPost, postPath(public) + "/react", `{"reaction":"like"}`},
+		{http.MethodPost, "/posts", `{"title":"New","content":"Body"}`},
+	} {
+		if w := request(write.method, write.path, "", write.body); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s anonymously: expected 401, got %d", write.method, write.path, w.Code)
+		}
+	}
+}
+
+// Without public read mode every read still needs a login.
+func TestReadsNeedLoginByDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	router := gin.New()
+	RegisterRoutes(router)
+
+	for _, path := range []string{"/posts", "/posts/1", "/tags", "/categories"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s anonymously: expected 401, got %d", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"example.com/blog_backend/backup"
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// checkConsistency reports mismatched post counters and orphaned reactions
+// and comments without changing anything.
+func checkConsistency(context *gin.Context) {
+	report, err := models.CheckConsistency(context.Request.Context(), false)
+	if err != nil {
+		if respondStoreTimeout(context, err) {
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not check consistency", "error": err.Error()})
+		return
+	}
+	context.JSON(http.StatusOK, report)
+}
+
+// repairConsistency runs the same checks as checkConsistency and fixes what
+// it finds. The response lists the problems that were repaired.
+func repairConsistency(context *gin.Context) {
+	report, err := models.CheckConsistency(context.Request.Context(), true)
+	if err != nil {
+		if respondStoreTimeout(context, err) {
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not repair consistency", "error": err.Error()})
+		return
+	}
+	context.JSON(http.StatusOK, report)
+}
+
+// exportBackup streams an NDJSON backup archive of every user, post, comment
+// and reaction as a file download.
+func exportBackup(context *gin.Context) {
+	filename := fmt.Sprintf("blog-backup-%s.ndjson", time.Now().UTC().Format("20060102-150405"))
+	context.Header("Content-Type", "application/x-ndjson")
+	context.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// Export reads everything before it writes the first line, so a storage
+	// failure can still be reported with a proper status code.
+	if _, err := backup.Export(context.Request.Context(), context.Writer); err != nil {
+		if context.Writer.Written() {
+			log.Printf("exportBackup: export failed after streaming started: %v", err)
+			return
+		}
+
+		context.Writer.Header().Del("Content-Type")
+		context.Writer.Header().Del("Content-Disposition")
+		if respondStoreTimeout(context, err) {
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not export backup", "error": err.Error()})
+	}
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// getCategories lists every category in display order with the number of
+// published posts filed under it.
+func getCategories(c *gin.Context) {
+	categories, err := models.ListCategoriesWithCounts(c.Request.Context())
+	if err != nil {
+		if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+		}
+		return
+	}
+	if categories == nil {
+		categories = []models.CategoryWithCount{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// respondCategoryError answers the client errors of the category model
+// functions and reports whether it wrote a response.
+func respondCategoryError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrInvalidCategory):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Category not found"})
+	case errors.Is(err, models.ErrCategoryExists):
+		c.JSON(http.StatusConflict, gin.H{"message": "A category with this name already exists"})
+	case errors.Is(err, models.ErrCategoryInUse):
+		c.JSON(http.StatusConflict, gin.H{"message": "Category is still used by posts. Move them to another category first."})
+	default:
+		return respondStoreTimeout(c, err)
+	}
+	return true
+}
+
+// createCategory creates a category. Its slug is derived from the name.
+func createCategory(c *gin.Context) {
+	var category models.Category
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request body"})
+		return
+	}
+
+	if err := category.Save(c.Request.Context()); err != nil {
+		if !respondCategoryError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Category created", "category": category})
+}
+
+// updateCategory replaces the name, description and sort order of a
+// category. Renaming it moves its posts to the new name.
+func updateCategory(c *gin.Context) {
+	ctx := c.Request.Context()
+	existing, err := models.GetCategory(ctx, c.Param("slug"))
+	if err != nil {
+		if !respondCategoryError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve category"})
+		}
+		return
+	}
+
+	var category models.Category
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request body"})
+		return
+	}
+	category.CreatedAt = existing.CreatedAt
+
+	if err := category.Update(ctx, existing.Slug); err != nil {
+		if !respondCategoryError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category updated", "category": category})
+}
+
+// deleteCategory removes a category that no post is filed under.
+func deleteCategory(c *gin.Context) {
+	if err := models.DeleteCategory(c.Request.Context(), c.Param("slug")); err != nil {
+		if !respondCategoryError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+}
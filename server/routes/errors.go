@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// respondStoreTimeout answers 504 when a storage operation ran out of time
+// and 503 when the backend could not be reached or the request was cancelled.
+// It reports whether a response was written, so handlers can fall through to
+// their own 500 otherwise.
+func respondStoreTimeout(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrTimeout):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"message": "The request timed out. Try again later."})
+	case errors.Is(err, models.ErrUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "The service is temporarily unavailable. Try again later."})
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/blog_backend/feed"
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// feedLength is the number of posts a feed lists.
+const feedLength = 20
+
+// feedFormat pairs a feed renderer with its content type.
+type feedFormat struct {
+	render      func(feed.Feed) ([]byte, error)
+	contentType string
+}
+
+var (
+	rssFeed  = feedFormat{render: feed.Feed.RSS, contentType: feed.RSSContentType}
+	atomFeed = feedFormat{render: feed.Feed.Atom, contentType: feed.AtomContentType}
+)
+
+// getFeed serves the latest published posts of the whole blog.
+func getFeed(format feedFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serveFeed(c, format, models.PostFilter{}, feed.Feed{
+			Title:       site.Title,
+			Description: "Latest posts from " + site.Title,
+			Link:        siteURL(c) + "/blog.html",
+		}, time.Time{})
+	}
+}
+
+// getCategoryFeed serves the latest published posts filed under the category
+// in the :slug parameter.
+func getCategoryFeed(format feedFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		category, err := models.GetCategory(c.Request.Context(), c.Param("slug"))
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrCategoryNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"message": "Category not found"})
+			case !respondStoreTimeout(c, err):
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve category"})
+			}
+			return
+		}
+
+		description := category.Description
+		if description == "" {
+			description = "Latest posts in " + category.Name
+		}
+		serveFeed(c, format, models.PostFilter{Category: category.Name}, feed.Feed{
+			Title:       site.Title + ": " + category.Name,
+			Description: description,
+			Link:        siteURL(c) + "/blog.html?category=" + url.QueryEscape(category.Name),
+		}, category.UpdatedAt)
+	}
+}
+
+// getAuthorFeed serves the latest published posts of the user in the :id
+// parameter.
+func getAuthorFeed(format feedFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse user id."})
+			return
+		}
+		user, err := models.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrUserNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+			case !respondStoreTimeout(c, err):
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+			}
+			return
+		}
+
+		name := feedAuthorName(user)
+		serveFeed(c, format, models.PostFilter{AuthorID: user.ID}, feed.Feed{
+			Title:       site.Title + ": " + name,
+			Description: "Latest posts by " + name,
+			Link:        siteURL(c) + "/blog.html",
+			Author:      name,
+		}, time.Time{})
+	}
+}
+
+// feedAuthorName returns the name a public feed shows for u. Usernames of
+// Google accounts are email addresses, which are not published.
+func feedAuthorName(u *models.User) string {
+	if strings.Contains(u.Username, "@") {
+		return "Author #" + strconv.FormatInt(u.ID, 10)
+	}
+	return u.Username
+}
+
+// serveFeed lists the latest published posts matching filter into f and
+// writes it in the given format. The feed counts as modified when its posts
+// last changed (see models.PostSummary.LastModified), or at modified if that
+// is later.
+func serveFeed(c *gin.Context, format feedFormat, filter models.PostFilter, f feed.Feed, modified time.Time) {
+	// Feeds are public, so drafts and scheduled posts are never listed.
+	filter.Status = "published"
+	filter.Limit = feedLength
+	page, err := models.QueryPosts(c.Request.Context(), filter)
+	if err != nil {
+		if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+		}
+		return
+	}
+
+	if f.Author == "" {
+		f.Author = site.Title
+	}
+	f.Self = apiURL(c) + c.Request.URL.Path
+	host := ""
+	if u, err := url.Parse(siteURL(c)); err == nil {
+		host = u.Hostname()
+	}
+	for _, p := range page.Posts {
+		if p.LastModified().After(modified) {
+			modified = p.LastModified()
+		}
+		summary := p.Description
+		if summary == "" {
+			summary = p.Excerpt
+		}
+		if p.MembersOnly {
+			// Feeds are anonymous; announce the post without its text.
+			summary = ""
+		}
+		var categories []string
+		if p.Category != "" {
+			categories = append(categories, p.Category)
+		}
+		categories = append(categories, p.Tags...)
+
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:         postTagURI(host, p.ID, p.CreatedAt),
+			Title:      p.Title,
+			Link:       postURL(c, p.Slug),
+			Summary:    summary,
+			Categories: categories,
+			Published:  p.CreatedAt,
+			Updated:    p.LastModified(),
+		})
+	}
+	f.Updated = modified
+
+	body, err := format.render(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render feed"})
+		return
+	}
+	serveCacheable(c, format.contentType, body, modified)
+}
+
+// postTagURI returns a tag URI (RFC 4151) that identifies a post in feeds
+// for good, unlike its URL, which follows the slug.
+func postTagURI(host string, id int64, created time.Time) string {
+	return "tag:" + host + "," + created.UTC().Format("2006-01-02") + ":post-" + strconv.FormatInt(id, 10)
+}
+
+// serveCacheable writes body with an ETag derived from it and, unless
+// modified is zero, a Last-Modified header, and answers 304 Not Modified
+// when the request's validators show the client already has it.
+func serveCacheable(c *gin.Context, contentType string, body []byte, modified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=300")
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(c.Request, etag, modified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when the request
+// has no If-None-Match, as RFC 9110 orders them.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	// HTTP dates have whole seconds.
+	return !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,194 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func newFeedRouter() *gin.Engine {
+	router := gin.New()
+	router.GET("/feed.rss", getFeed(rssFeed))
+	router.GET("/feed.atom", getFeed(atomFeed))
+	router.GET("/categories/:slug/feed.rss", getCategoryFeed(rssFeed))
+	router.GET("/authors/:id/feed.atom", getAuthorFeed(atomFeed))
+	return router
+}
+
+// Feeds are public, list only published posts and link them to the
+// frontend.
+func TestFeedsListPublishedPosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	SetSite(Site{Title: "My Blog", URL: "https://blog.example.com/"})
+	defer SetSite(Site{})
+	ctx := context.Background()
+
+	if err := (&models.Category{Name: "Go"}).Save(ctx); err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+	author := &models.User{Username: "someone@example.com", Password: "secret123"}
+	if err := author.Save(ctx); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	publishAt := time.Now().Add(time.Hour)
+	for _, p := range []*models.Post{
+		{Title: "Go post", Content: "Body", Category: "Go", AuthorID: author.ID},
+		{Title: "Other post", Content: "Body"},
+		{Title: "Secret draft", Content: "Body", Category: "Go", Status: "draft", AuthorID: author.ID},
+		{Title: "Future post", Content: "Body", Status: "scheduled", PublishAt: &publishAt},
+	} {
+		if err := p.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		newFeedRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/feed.rss")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/rss+xml") {
+		t.Fatalf("expected an RSS document, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<title>My Blog</title>", "Go post", "Other post", "https://blog.example.com/blog.html?post=go-post"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the feed:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{"Secret draft", "Future post"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("expected %q to be left out of the feed:\n%s", unwanted, body)
+		}
+	}
+
+	if w := get("/feed.atom"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<feed xmlns="http://www.w3.org/2005/Atom">`) {
+		t.Errorf("expected an Atom document, got %d:\n%s", w.Code, w.Body.String())
+	}
+
+	w = get("/categories/go/feed.rss")
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, "Go post") || strings.Contains(body, "Other post") || strings.Contains(body, "Secret draft") {
+		t.Errorf("expected only the published Go post, got %d:\n%s", w.Code, body)
+	}
+	if w := get("/categories/missing/feed.rss"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown category, got %d", w.Code)
+	}
+
+	w = get("/authors/" + strconv.FormatInt(author.ID, 10) + "/feed.atom")
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, "Go post") || strings.Contains(body, "Other post") {
+		t.Errorf("expected only the author's published post, got %d:\n%s", w.Code, body)
+	}
+	if strings.Contains(w.Body.String(), "someone@example.com") {
+		t.Errorf("expected the author's email address to stay private:\n%s", w.Body.String())
+	}
+	if w := get("/authors/999/feed.atom"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown author, got %d", w.Code)
+	}
+}
+
+// With the site and API addresses configured, feed links do not depend on
+// the Host header, so a forged one cannot end up in a cached feed.
+func TestFeedLinksIgnoreHostHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	SetSite(Site{URL: "https://blog.example.com", APIURL: "https://api.example.com/"})
+	defer SetSite(Site{})
+
+	if err := (&models.Post{Title: "First", Content: "Body"}).Save(context.Background()); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	for _, path := range []string{"/feed.rss", "/feed.atom"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = "evil.example"
+		newFeedRouter().ServeHTTP(w, req)
+		body := w.Body.String()
+		if w.Code != http.StatusOK || !strings.Contains(body, "https://api.example.com"+path) {
+			t.Errorf("%s: expected a self link to the configured API address, got %d:\n%s", path, w.Code, body)
+		}
+		if strings.Contains(body, "evil.example") {
+			t.Errorf("%s: expected the Host header to be ignored:\n%s", path, body)
+		}
+	}
+}
+
+// Feed readers can poll with If-None-Match or If-Modified-Since and get 304
+// until a post changes.
+func TestFeedConditionalRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	post := &models.Post{Title: "First", Content: "Body"}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/feed.rss", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		newFeedRouter().ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("", "")
+	etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("expected 200 with validators, got %d, ETag %q, Last-Modified %q", first.Code, etag, lastModified)
+	}
+
+	if w := get("If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected 304 for a matching ETag, got %d", w.Code)
+	}
+	if w := get("If-None-Match", `"stale"`); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a stale ETag, got %d", w.Code)
+	}
+	if w := get("If-Modified-Since", lastModified); w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for an unchanged feed, got %d", w.Code)
+	}
+
+	earlier := post.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)
+	if w := get("If-Modified-Since", earlier); w.Code != http.StatusOK {
+		t.Errorf("expected 200 when the feed changed since, got %d", w.Code)
+	}
+
+	post.Title = "First, edited"
+	if err := post.Update(ctx, post.AuthorID); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+	if w := get("If-None-Match", etag); w.Code != http.StatusOK {
+		t.Errorf("expected 200 after an edit, got %d", w.Code)
+	}
+
+	// A scheduled post going live moves Last-Modified forward even though it
+	// was last edited before.
+	lastModified = get("", "").Header().Get("Last-Modified")
+	publishAt := time.Now().Add(2 * time.Second)
+	scheduled := &models.Post{Title: "Later", Content: "Body", Status: "scheduled", PublishAt: &publishAt}
+	if err := scheduled.Save(ctx); err != nil {
+		t.Fatalf("failed to create scheduled post: %v", err)
+	}
+	if _, err := models.ApplyPostSchedule(ctx, publishAt); err != nil {
+		t.Fatalf("failed to publish scheduled post: %v", err)
+	}
+	w := get("If-Modified-Since", lastModified)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Later") {
+		t.Errorf("expected 200 with the newly published post, got %d", w.Code)
+	}
+}
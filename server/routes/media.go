@@ -0,0 +1,232 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/blog_backend/media"
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// uploadOverhead leaves room for the multipart framing around an upload of
+// the maximum size.
+const uploadOverhead = 1 << 20
+
+// defaultSweepAge is how old an unreferenced upload has to be before the
+// sweep reports it, so images for a post still being written are spared.
+const defaultSweepAge = 24 * time.Hour
+
+// uploadMedia stores the image in the multipart field "file", records it in
+// the media library and returns its description, whose key posts use as
+// cover_image_key.
+func uploadMedia(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, media.MaxSize()+uploadOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "The image is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Attach an image in the file field"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Could not read the uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	img, err := media.Save(c.Request.Context(), file)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "The image is too large"})
+		case errors.Is(err, media.ErrUnsupportedType):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "Upload a JPEG, PNG, GIF or WebP image"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store the image"})
+		}
+		return
+	}
+
+	asset := &models.MediaAsset{
+		Key:         img.Key,
+		Filename:    header.Filename,
+		ContentType: img.ContentType,
+		Size:        img.Size,
+		Width:       img.Width,
+		Height:      img.Height,
+		UploaderID:  c.GetInt64("userId"),
+	}
+	if err := asset.Save(c.Request.Context()); err != nil {
+		_ = media.Delete(c.Request.Context(), img.Key)
+		if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record the image"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Image uploaded successfully", "media": img})
+}
+
+// getMedia serves an uploaded image. Keys are never reused, so responses can
+// be cached for good.
+func getMedia(c *gin.Context) {
+	key := c.Param("key")
+	etag := `"` + key + `"`
+	if notModified(c.Request, etag, time.Time{}) {
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	obj, err := media.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, media.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Media not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve media"})
+		return
+	}
+	defer obj.Body.Close()
+
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, obj.Body, map[string]string{
+		"ETag":                   etag,
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// coverImage returns the description of an uploaded cover, or nil for the
+// built-in covers and uploads without one.
+func coverImage(ctx context.Context, key string) *media.Image {
+	img, err := media.Describe(ctx, key)
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// listMedia returns the media library, newest first, as {"media": [...]}.
+// Each asset lists the posts using it in used_by.
+//
+// Query parameters: q (matches the file name or key) and uploader_id.
+func listMedia(c *gin.Context) {
+	filter := models.MediaFilter{Query: c.Query("q")}
+	if v := c.Query("uploader_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid uploader_id"})
+			return
+		}
+		filter.UploaderID = id
+	}
+
+	assets, err := models.ListMedia(c.Request.Context(), filter)
+	if err != nil {
+		if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve media"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"media": assets})
+}
+
+// deleteMedia removes an upload from the media library and storage. An
+// upload that posts still use answers 409 with their IDs in used_by, unless
+// the request has force=true; those posts then show no image.
+func deleteMedia(c *gin.Context) {
+	key := c.Param("key")
+	force := c.Query("force") == "true"
+
+	if err := models.DeleteMedia(c.Request.Context(), key, force); err != nil {
+		switch {
+		case errors.Is(err, models.ErrMediaNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Media not found"})
+		case errors.Is(err, models.ErrMediaInUse):
+			asset, _ := models.GetMedia(c.Request.Context(), key)
+			var usedBy []int64
+			if asset != nil {
+				usedBy = asset.UsedBy
+			}
+			c.JSON(http.StatusConflict, gin.H{"message": "The image is still used by posts. Pass force=true to delete it anyway.", "used_by": usedBy})
+		case !respondStoreTimeout(c, err):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media"})
+		}
+		return
+	}
+	if err := media.Delete(c.Request.Context(), key); err != nil && !errors.Is(err, media.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Removed from the library, but failed to delete the image files"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted"})
+}
+
+// unreferencedMedia lists the uploads that no post uses and that are older
+// than the older_than query parameter (a Go duration, default 24h), as
+// {"media": [...]}.
+func unreferencedMedia(c *gin.Context) {
+	assets, ok := findUnreferencedMedia(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"media": assets})
+}
+
+// sweepMedia deletes what unreferencedMedia lists and returns the deleted
+// uploads as {"deleted": [...]}. Uploads that fail to delete are listed in
+// "failed" and can be swept again.
+func sweepMedia(c *gin.Context) {
+	assets, ok := findUnreferencedMedia(c)
+	if !ok {
+		return
+	}
+
+	deleted, failed := []string{}, []string{}
+	for _, asset := range assets {
+		// Deleting without force checks the references once more, in case a
+		// post started using the upload since it was listed.
+		err := models.DeleteMedia(c.Request.Context(), asset.Key, false)
+		if errors.Is(err, models.ErrMediaInUse) || errors.Is(err, models.ErrMediaNotFound) {
+			continue
+		}
+		if err == nil {
+			err = media.Delete(c.Request.Context(), asset.Key)
+		}
+		if err != nil && !errors.Is(err, media.ErrNotFound) {
+			failed = append(failed, asset.Key)
+			continue
+		}
+		deleted = append(deleted, asset.Key)
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted, "failed": failed})
+}
+
+func findUnreferencedMedia(c *gin.Context) ([]models.MediaAsset, bool) {
+	age := defaultSweepAge
+	if v := c.Query("older_than"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid older_than. Use a duration such as 24h."})
+			return nil, false
+		}
+		age = d
+	}
+
+	assets, err := models.UnreferencedMedia(c.Request.Context(), time.Now().Add(-age))
+	if err != nil {
+		if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve media"})
+		}
+		return nil, false
+	}
+	return assets, true
+}
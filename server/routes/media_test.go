@@ -0,0 +1,193 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"example.com/blog_backend/blob"
+	"example.com/blog_backend/media"
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func uploadRequest(t *testing.T, field string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, "cover.png")
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/media", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+// Uploaded images are sniffed, processed and then served publicly under their
+// key with long-lived cache headers. Posts using one as cover describe its
+// variants.
+func TestUploadAndServeMedia(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	media.SetStorage(blob.NewMemoryStore())
+
+	router := gin.New()
+	router.POST("/media", withRole(1, "editor"), uploadMedia)
+	router.GET("/media/:key", getMedia)
+	router.GET("/posts/:id", withRole(2, "user"), getPost)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 400, 200))); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, "file", img.Bytes()))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Media media.Image `json:"media"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Media.ContentType != "image/png" || resp.Media.URL != "/media/"+resp.Media.Key || len(resp.Media.Variants) != 1 {
+		t.Errorf("unexpected upload %+v", resp.Media)
+	}
+
+	w = getPath(router, resp.Media.URL)
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("expected the image back, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "image/png" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	if w := getPath(router, resp.Media.Variants[0].URL); w.Code != http.StatusOK {
+		t.Errorf("expected the 320w variant, got %d", w.Code)
+	}
+
+	post := &models.Post{Title: "Covered", Content: "Body", CoverImageKey: resp.Media.Key}
+	if err := post.Save(context.Background()); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	w = getPath(router, "/posts/"+strconv.FormatInt(post.ID, 10))
+	var got models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.CoverImage == nil {
+		t.Fatalf("expected the post to describe its cover, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.CoverImage.Width != 400 || got.CoverImage.Variants[0].Width != 320 || got.CoverImage.Placeholder == "" {
+		t.Errorf("unexpected cover image %+v", got.CoverImage)
+	}
+
+	if w := getPath(router, "/media/0123456789abcdef0123456789abcdef.png"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown key, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, "file", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>")))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for an SVG, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, "image", img.Bytes()))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a file field, got %d", w.Code)
+	}
+}
+
+// The media library lists uploads with the posts using them, refuses to
+// delete one in use unless forced, and sweeps the unused ones.
+func TestMediaLibrary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	media.SetStorage(blob.NewMemoryStore())
+
+	router := gin.New()
+	router.GET("/media/:key", getMedia)
+	admin := router.Group("/", withRole(1, "admin"))
+	admin.POST("/media", uploadMedia)
+	admin.GET("/media", listMedia)
+	admin.DELETE("/media/:key", deleteMedia)
+	admin.POST("/admin/media/sweep", sweepMedia)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	upload := func() string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, uploadRequest(t, "file", img.Bytes()))
+		var resp struct {
+			Media media.Image `json:"media"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusCreated {
+			t.Fatalf("upload failed with %d: %s", w.Code, w.Body.String())
+		}
+		return resp.Media.Key
+	}
+	used, unused := upload(), upload()
+
+	post := &models.Post{Title: "Covered", Content: "Body", CoverImageKey: used}
+	if err := post.Save(context.Background()); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	w := getPath(router, "/media?q=cover.png")
+	var list struct {
+		Media []models.MediaAsset `json:"media"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Media) != 2 {
+		t.Fatalf("expected both uploads listed, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, asset := range list.Media {
+		if asset.UploaderID != 1 || asset.Filename != "cover.png" || asset.Size == 0 {
+			t.Errorf("unexpected asset %+v", asset)
+		}
+		if asset.Key == used && (len(asset.UsedBy) != 1 || asset.UsedBy[0] != post.ID) {
+			t.Errorf("expected %s to be used by post %d, got %v", used, post.ID, asset.UsedBy)
+		}
+	}
+
+	del := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+		return w
+	}
+	if w := del("/media/" + used); w.Code != http.StatusConflict || !bytes.Contains(w.Body.Bytes(), []byte(`"used_by":[`+strconv.FormatInt(post.ID, 10)+`]`)) {
+		t.Errorf("expected 409 with the using post, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := del("/media/" + used + "?force=true"); w.Code != http.StatusOK {
+		t.Errorf("expected a forced delete to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := getPath(router, "/media/"+used); w.Code != http.StatusNotFound {
+		t.Errorf("expected the deleted image to be gone, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/media/sweep", nil))
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(unused)) {
+		t.Errorf("expected a fresh upload to be spared, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/media/sweep?older_than=0s", nil))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"deleted":["`+unused+`"]`)) {
+		t.Errorf("expected the unused upload to be swept, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := getPath(router, "/media/"+unused); w.Code != http.StatusNotFound {
+		t.Errorf("expected the swept image to be gone, got %d", w.Code)
+	}
+}
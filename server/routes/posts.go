@@ -2,41 +2,196 @@ package routes
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"example.com/blog_backend/models"
+	"example.com/blog_backend/search"
 	"github.com/gin-gonic/gin"
 )
 
-	// getPosts returns blog posts. This handler is meant to be used behind
-	// authentication so that users log in before reading your blogs.
+	// getPosts returns one page of post summaries, newest first, as
+	// {"posts": [...], "next_cursor": "..."}. Summaries carry an excerpt
+	// instead of the content; GET /posts/:id returns the full post. This
+	// handler is meant to be used behind authentication so that users log in
+	// before reading your blogs.
+	//
+	// Query parameters: limit (1-100, default 20), cursor (the next_cursor of
+	// the previous page), category, status, author_id, created_after and
+	// created_before (RFC 3339 timestamps or YYYY-MM-DD dates).
 	//
 	// Non-privileged users (regular readers) will only see published posts,
-	// while admins and editors can see both published posts and drafts.
+	// while admins and editors also see drafts and scheduled posts.
 func getPosts(context *gin.Context) {
-	posts, err := models.GetAllPosts()
+	filter, err := parsePostFilter(context)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+		context.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
 		roleValue, _ := context.Get("role")
 		role, _ := roleValue.(string)
 	
-		// If the caller is not an admin or editor, hide draft posts.
+		// If the caller is not an admin or editor, hide draft and scheduled posts.
 		if role != "admin" && role != "editor" {
-		filtered := make([]models.Post, 0, len(posts))
-		for _, p := range posts {
-			if p.Status == "" || p.Status == "published" {
-				filtered = append(filtered, p)
+		if filter.Status != "" && filter.Status != "published" {
+			context.JSON(http.StatusOK, models.PostPage{Posts: []models.PostSummary{}})
+			return
+		}
+		filter.Status = "published"
+	}
+
+	page, err := models.QueryPosts(context.Request.Context(), filter)
+	if err != nil {
+		if respondStoreTimeout(context, err) {
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+		return
+	}
+
+	for i := range page.Posts {
+		page.Posts[i].CoverImage = coverImage(context.Request.Context(), page.Posts[i].CoverImageKey)
+	}
+
+	// Anonymous readers see that members-only posts exist, but not their
+	// excerpts.
+	if !signedIn(context) {
+		for i := range page.Posts {
+			if page.Posts[i].MembersOnly {
+				page.Posts[i].Excerpt = ""
 			}
 		}
-		posts = filtered
 	}
 
-	context.JSON(http.StatusOK, posts)
+	context.JSON(http.StatusOK, page)
+}
+
+// parsePostFilter reads the listing query parameters of GET /posts. Errors
+// are meant to be shown to the client.
+func parsePostFilter(c *gin.Context) (models.PostFilter, error) {
+	filter := models.PostFilter{
+		Category: c.Query("category"),
+		Status:   c.Query("status"),
+	}
+
+	if filter.Status != "" && filter.Status != "draft" && filter.Status != "scheduled" && filter.Status != "published" {
+		return filter, errors.New("Invalid status. Use 'draft', 'scheduled' or 'published'.")
+	}
+
+	if v := c.Query("tag"); v != "" {
+		tag, err := models.NormalizeTag(v)
+		if err != nil {
+			return filter, errors.New("Invalid tag")
+		}
+		filter.Tag = tag
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > models.MaxPostPageSize {
+			return filter, fmt.Errorf("Invalid limit. Use a number between 1 and %d.", models.MaxPostPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := models.DecodePostCursor(v)
+		if err != nil {
+			return filter, errors.New("Invalid cursor")
+		}
+		filter.After = cursor
+	}
+
+	if v := c.Query("author_id"); v != "" {
+		authorID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || authorID <= 0 {
+			return filter, errors.New("Invalid author_id")
+		}
+		filter.AuthorID = authorID
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		v := c.Query(bound.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", v)
+		}
+		if err != nil {
+			return filter, fmt.Errorf("Invalid %s. Use an RFC 3339 timestamp or a YYYY-MM-DD date.", bound.name)
+		}
+		*bound.target = t
+	}
+
+	return filter, nil
+}
+
+// searchPosts runs a full-text search over post titles, descriptions,
+// categories and content and returns {"results": [...], "total": n}, best
+// match first. Each result carries an HTML-escaped snippet with the matched
+// words wrapped in <mark>.
+//
+// Query parameters: q (required; supports "quoted phrases" and prefix*
+// words), limit (1-100, default 20) and offset. As with getPosts, readers
+// only find published posts.
+func searchPosts(c *gin.Context) {
+	opts := search.Options{}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > search.MaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid limit. Use a number between 1 and %d.", search.MaxLimit)})
+			return
+		}
+		opts.Limit = limit
+	}
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid offset"})
+			return
+		}
+		opts.Offset = offset
+	}
+
+	roleValue, _ := c.Get("role")
+	role, _ := roleValue.(string)
+	opts.IncludeDrafts = role == "admin" || role == "editor"
+	opts.ExcludeMembersOnly = !signedIn(c)
+
+	results, err := search.Search(c.Query("q"), opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrEmptyQuery):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Search query is required"})
+		case errors.Is(err, search.ErrQueryTooLong):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Search query has too many terms"})
+		case errors.Is(err, search.ErrNotReady):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"message": "Search is not available yet. Try again later."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not search posts"})
+		}
+		return
+	}
+
+	for i := range results.Results {
+		results.Results[i].CoverImage = coverImage(c.Request.Context(), results.Results[i].CoverImageKey)
+	}
+
+	c.JSON(http.StatusOK, results)
 }
 
 // reactToPost allows an authenticated user to like or dislike a post. A user
@@ -50,15 +205,20 @@ func reactToPost(c *gin.Context) {
 		return
 	}
 
-	// Ensure the post exists first so we can return a 404 if needed.
-	if _, err := models.GetPostByID(postID); err != nil {
+	// Ensure the post exists and the user may read it, so we can return a 404
+	// if needed.
+	post, err := models.GetPostByID(c.Request.Context(), postID)
+	if err != nil {
 		if errors.Is(err, models.ErrPostNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
-		} else {
+		} else if !respondStoreTimeout(c, err) {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not verify post"})
 		}
 		return
 	}
+	if respondPostHidden(c, post) {
+		return
+	}
 
 	var body struct {
 		Reaction string `json:"reaction"`
@@ -70,7 +230,7 @@ func reactToPost(c *gin.Context) {
 
 	userID := c.GetInt64("userId")
 
-	result, err := models.SetPostReaction(userID, postID, body.Reaction)
+	result, err := models.SetPostReaction(c.Request.Context(), userID, postID, body.Reaction)
 	if err != nil {
 		if errors.Is(err, models.ErrInvalidReaction) {
 			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid reaction. Use 'like' or 'dislike'."})
@@ -80,6 +240,9 @@ func reactToPost(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
 			return
 		}
+		if respondStoreTimeout(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update reaction. Try again later."})
 		return
 	}
@@ -99,27 +262,76 @@ func getPost(context *gin.Context) {
 		return
 	}
 
-	post, err := models.GetPostByID(postID)
+	post, err := models.GetPostByID(context.Request.Context(), postID)
 	if err != nil {
 		if errors.Is(err, models.ErrPostNotFound) {
 			context.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
-		} else {
+		} else if !respondStoreTimeout(context, err) {
 			context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
 		}
 		return
 	}
 
-		// Non-privileged users should not be able to retrieve draft posts, even by ID.
-		roleValue, _ := context.Get("role")
-		role, _ := roleValue.(string)
-		if role != "admin" && role != "editor" && post.Status == "draft" {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+	// Non-privileged users should not be able to retrieve unpublished posts, even by ID.
+	if respondPostHidden(context, post) {
 		return
 	}
 
+	post.CoverImage = coverImage(context.Request.Context(), post.CoverImageKey)
+	context.Header("ETag", versionETag(post.Version))
 	context.JSON(http.StatusOK, post)
 }
 
+// getPostBySlug returns a single blog post by its slug. A slug the post had
+// before a title or slug change answers 301 with the current URL in the
+// Location header and {"message", "id", "slug"} in the body, so clients can
+// follow it like any redirect.
+func getPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	post, moved, err := models.GetPostBySlug(c.Request.Context(), slug)
+	if err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		} else if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+		}
+		return
+	}
+
+	// Same visibility rule as getPost.
+	if respondPostHidden(c, post) {
+		return
+	}
+
+	if moved {
+		c.Header("Location", "/posts/by-slug/"+url.PathEscape(post.Slug))
+		c.JSON(http.StatusMovedPermanently, gin.H{"message": "Post moved", "id": post.ID, "slug": post.Slug})
+		return
+	}
+
+	post.CoverImage = coverImage(c.Request.Context(), post.CoverImageKey)
+	c.Header("ETag", versionETag(post.Version))
+	c.JSON(http.StatusOK, post)
+}
+
+// respondPostInputError answers 400 or 409 for slug, tag, category,
+// schedule and revision limit errors from Post.Save and Post.Update and reports whether it
+// wrote a response.
+func respondPostInputError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrInvalidTag), errors.Is(err, models.ErrUnknownCategory), errors.Is(err, models.ErrInvalidSchedule),
+		errors.Is(err, models.ErrInvalidRevisionLimit):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrInvalidSlug):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid slug. Use letters, digits and hyphens."})
+	case errors.Is(err, models.ErrSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"message": "This slug is already used by another post"})
+	default:
+		return false
+	}
+	return true
+}
+
 // createPost allows an authenticated user to create a new blog post.
 func createPost(context *gin.Context) {
 	var post models.Post
@@ -131,16 +343,23 @@ func createPost(context *gin.Context) {
 	authorID := context.GetInt64("userId")
 	post.AuthorID = authorID
 
-	if err := post.Save(); err != nil {
+	if err := post.Save(context.Request.Context()); err != nil {
+		if respondPostInputError(context, err) || respondStoreTimeout(context, err) {
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create post. Try again later."})
 		return
 	}
 
+	post.CoverImage = coverImage(context.Request.Context(), post.CoverImageKey)
 	context.JSON(http.StatusCreated, gin.H{"message": "Post created successfully", "post": post})
 }
 
 // updatePost allows admins to update any post, and editors to update only
 // their own posts. Regular readers cannot update posts.
+//
+// With an If-Match header (the ETag from getPost), the update only happens
+// while the post is still at that version, and answers 412 otherwise.
 func updatePost(context *gin.Context) {
 	postID, err := strconv.ParseInt(context.Param("id"), 10, 64)
 	if err != nil {
@@ -149,11 +368,11 @@ func updatePost(context *gin.Context) {
 	}
 
 		userID := context.GetInt64("userId")
-		post, err := models.GetPostByID(postID)
+		post, err := models.GetPostByID(context.Request.Context(), postID)
 		if err != nil {
 			if errors.Is(err, models.ErrPostNotFound) {
 				context.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
-			} else {
+			} else if !respondStoreTimeout(context, err) {
 				context.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch post"})
 			}
 			return
@@ -179,14 +398,25 @@ func updatePost(context *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(context, post.Version)
+	if !ok {
+		return
+	}
+
 	updatedPost.ID = postID
 	updatedPost.AuthorID = post.AuthorID
+	updatedPost.Version = version
 
-	if err := updatedPost.Update(); err != nil {
+	if err := updatedPost.Update(context.Request.Context(), userID); err != nil {
+		if respondPostInputError(context, err) || respondVersionConflict(context, err) || respondStoreTimeout(context, err) {
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update post"})
 		return
 	}
 
+	updatedPost.CoverImage = coverImage(context.Request.Context(), updatedPost.CoverImageKey)
+	context.Header("ETag", versionETag(updatedPost.Version))
 	context.JSON(http.StatusOK, gin.H{"message": "Post updated successfully", "post": updatedPost})
 }
 
@@ -200,11 +430,11 @@ func deletePost(context *gin.Context) {
 	}
 
 		userID := context.GetInt64("userId")
-		post, err := models.GetPostByID(postID)
+		post, err := models.GetPostByID(context.Request.Context(), postID)
 		if err != nil {
 			if errors.Is(err, models.ErrPostNotFound) {
 				context.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
-			} else {
+			} else if !respondStoreTimeout(context, err) {
 				context.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch post"})
 			}
 			return
@@ -224,7 +454,10 @@ func deletePost(context *gin.Context) {
 			return
 		}
 
-	if err := post.Delete(); err != nil {
+	if err := post.Delete(context.Request.Context()); err != nil {
+		if respondStoreTimeout(context, err) {
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete the post"})
 		return
 	}
@@ -243,17 +476,29 @@ func deletePost(context *gin.Context) {
 		}
 
 		// Ensure the post exists so we can return a sensible 404.
-		if _, err := models.GetPostByID(postID); err != nil {
+		post, err := models.GetPostByID(c.Request.Context(), postID)
+		if err != nil {
 			if errors.Is(err, models.ErrPostNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
 				return
 			}
+			if respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not load post comments"})
 			return
 		}
 
-		comments, err := models.GetCommentsForPost(postID)
+		// Comments are visible to whoever may read the post.
+		if respondPostHidden(c, post) {
+			return
+		}
+
+		comments, err := models.GetCommentsForPost(c.Request.Context(), postID)
 		if err != nil {
+			if respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not load post comments"})
 			return
 		}
@@ -270,15 +515,23 @@ func deletePost(context *gin.Context) {
 			return
 		}
 
-		// Ensure the post exists before creating a comment.
-		if _, err := models.GetPostByID(postID); err != nil {
+		// Ensure the post exists and the user may read it before creating a
+		// comment.
+		post, err := models.GetPostByID(c.Request.Context(), postID)
+		if err != nil {
 			if errors.Is(err, models.ErrPostNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
 				return
 			}
+			if respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create comment"})
 			return
 		}
+		if respondPostHidden(c, post) {
+			return
+		}
 
 		var body struct {
 			Content string `json:"content"`
@@ -299,18 +552,24 @@ func deletePost(context *gin.Context) {
 		}
 
 		userID := c.GetInt64("userId")
-		user, err := models.GetUserByID(userID)
+		user, err := models.GetUserByID(c.Request.Context(), userID)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
 				c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
 				return
 			}
+			if respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create comment"})
 			return
 		}
 
-		comment, err := models.CreateComment(postID, userID, user.Username, content)
+		comment, err := models.CreateComment(c.Request.Context(), postID, userID, user.Username, content)
 		if err != nil {
+			if respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create comment"})
 			return
 		}
@@ -319,7 +578,8 @@ func deletePost(context *gin.Context) {
 	}
 
 	// updatePostComment updates the content of a comment owned by the
-	// authenticated user.
+	// authenticated user. Like updatePost it honours If-Match, with the
+	// comment's version as its ETag.
 	func updatePostComment(c *gin.Context) {
 		commentID := c.Param("commentId")
 		if commentID == "" {
@@ -345,8 +605,30 @@ func deletePost(context *gin.Context) {
 			return
 		}
 
+		comment, err := models.GetCommentByID(c.Request.Context(), commentID)
+		if err != nil {
+			if errors.Is(err, models.ErrCommentNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"message": "Comment not found"})
+				return
+			}
+			if respondStoreTimeout(c, err) {
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update comment"})
+			return
+		}
+
 		userID := c.GetInt64("userId")
-		updated, err := models.UpdateCommentContent(commentID, userID, content)
+		if comment.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"message": "You are not allowed to edit this comment"})
+			return
+		}
+		version, ok := ifMatchVersion(c, comment.Version)
+		if !ok {
+			return
+		}
+
+		updated, err := models.UpdateCommentContent(c.Request.Context(), commentID, userID, version, content)
 		if err != nil {
 			if errors.Is(err, models.ErrCommentNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"message": "Comment not found"})
@@ -356,10 +638,14 @@ func deletePost(context *gin.Context) {
 				c.JSON(http.StatusForbidden, gin.H{"message": "You are not allowed to edit this comment"})
 				return
 			}
+			if respondVersionConflict(c, err) || respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update comment"})
 			return
 		}
 
+		c.Header("ETag", versionETag(updated.Version))
 		c.JSON(http.StatusOK, gin.H{"message": "Comment updated successfully", "comment": updated})
 	}
 
@@ -372,12 +658,15 @@ func deletePost(context *gin.Context) {
 			return
 		}
 
-		comment, err := models.GetCommentByID(commentID)
+		comment, err := models.GetCommentByID(c.Request.Context(), commentID)
 		if err != nil {
 			if errors.Is(err, models.ErrCommentNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"message": "Comment not found"})
 				return
 			}
+			if respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not delete comment"})
 			return
 		}
@@ -391,11 +680,14 @@ func deletePost(context *gin.Context) {
 			return
 		}
 
-		if err := models.DeleteComment(commentID, comment.PostID); err != nil {
+		if err := models.DeleteComment(c.Request.Context(), commentID, comment.PostID); err != nil {
 			if errors.Is(err, models.ErrCommentNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"message": "Comment not found"})
 				return
 			}
+			if respondStoreTimeout(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not delete comment"})
 			return
 		}
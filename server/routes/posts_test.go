@@ -0,0 +1,283 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/blog_backend/models"
+	"example.com/blog_backend/search"
+	"github.com/gin-gonic/gin"
+)
+
+// withRole returns a middleware that stands in for Authenticate by setting the
+// caller's userId and role directly.
+func withRole(userID int64, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userId", userID)
+		c.Set("role", role)
+		c.Next()
+	}
+}
+
+// Readers only see published posts, while editors and admins also see drafts
+// both in the listing and when fetching a post by ID.
+func TestGetPostsHidesDraftsFromReaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+
+	published := &models.Post{Title: "Published", Content: "Hello"}
+	if err := published.Save(context.Background()); err != nil {
+		t.Fatalf("failed to create published post: %v", err)
+	}
+	draft := &models.Post{Title: "Draft", Content: "Work in progress", Status: "draft"}
+	if err := draft.Save(context.Background()); err != nil {
+		t.Fatalf("failed to create draft post: %v", err)
+	}
+
+	cases := []struct {
+		role      string
+		wantPosts int
+		draftCode int
+	}{
+		{role: "user", wantPosts: 1, draftCode: http.StatusNotFound},
+		{role: "editor", wantPosts: 2, draftCode: http.StatusOK},
+		{role: "admin", wantPosts: 2, draftCode: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		router := gin.New()
+		router.Use(withRole(1, tc.role))
+		router.GET("/posts", getPosts)
+		router.GET("/posts/:id", getPost)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d; body=%s", tc.role, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var page models.PostPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: failed to decode posts: %v", tc.role, err)
+		}
+		if len(page.Posts) != tc.wantPosts {
+			t.Fatalf("%s: expected %d posts, got %d", tc.role, tc.wantPosts, len(page.Posts))
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts?status=draft", nil))
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: failed to decode draft listing: %v", tc.role, err)
+		}
+		if wantDrafts := tc.wantPosts - 1; len(page.Posts) != wantDrafts {
+			t.Fatalf("%s: expected %d drafts, got %d", tc.role, wantDrafts, len(page.Posts))
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/2", nil))
+		if w.Code != tc.draftCode {
+			t.Fatalf("%s: expected status %d for draft, got %d", tc.role, tc.draftCode, w.Code)
+		}
+	}
+}
+
+// Malformed listing parameters are rejected with 400.
+func TestGetPostsRejectsInvalidParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+
+	router := gin.New()
+	router.Use(withRole(1, "admin"))
+	router.GET("/posts", getPosts)
+
+	for _, query := range []string{
+		"limit=0",
+		"limit=500",
+		"cursor=not-a-cursor",
+		"status=archived",
+		"author_id=abc",
+		"created_after=yesterday",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// blockingStore is a MemoryStore whose post listing never answers before the
+// caller's context is done, like a hung Firestore call.
+type blockingStore struct {
+	*models.MemoryStore
+}
+
+func (s blockingStore) QueryPosts(ctx context.Context, f models.PostFilter) ([]models.PostSummary, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// A storage call that outlives its timeout answers 504 instead of hanging.
+func TestGetPostsTimesOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(blockingStore{models.NewMemoryStore()})
+	models.SetTimeouts(models.Timeouts{Read: 10 * time.Millisecond, Write: 10 * time.Millisecond})
+	t.Cleanup(func() { models.SetTimeouts(models.DefaultTimeouts) })
+
+	router := gin.New()
+	router.Use(withRole(1, "user"))
+	router.GET("/posts", getPosts)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status %d, got %d; body=%s", http.StatusGatewayTimeout, w.Code, w.Body.String())
+	}
+
+	// A client that has already gone away gets 503.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts", nil).WithContext(ctx))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d for a cancelled request, got %d; body=%s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+	}
+}
+
+// Search applies the same draft visibility as the listing and rejects empty
+// queries.
+func TestSearchPostsHidesDraftsFromReaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	if _, err := search.Init(context.Background()); err != nil {
+		t.Fatalf("failed to build search index: %v", err)
+	}
+	defer search.SetIndex(nil)
+
+	for _, p := range []*models.Post{
+		{Title: "Release notes", Content: "The release is out."},
+		{Title: "Next release", Content: "Planning the next release.", Status: "draft"},
+	} {
+		if err := p.Save(context.Background()); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	for role, want := range map[string]int{"user": 1, "editor": 2} {
+		router := gin.New()
+		router.Use(withRole(1, role))
+		router.GET("/posts/search", searchPosts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/search?q=release", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d; body=%s", role, http.StatusOK, w.Code, w.Body.String())
+		}
+		var results search.Results
+		if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+			t.Fatalf("%s: failed to decode results: %v", role, err)
+		}
+		if results.Total != want || len(results.Results) != want {
+			t.Errorf("%s: expected %d results, got %+v", role, want, results)
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/search?q=+", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d for an empty query, got %d", role, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestGetPostBySlugRedirectsOldSlugs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	post := &models.Post{Title: "First title", Content: "Body"}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	renamed := &models.Post{ID: post.ID, Title: "Second title", Content: "Body"}
+	if err := renamed.Update(ctx, 0); err != nil {
+		t.Fatalf("failed to rename post: %v", err)
+	}
+	draft := &models.Post{Title: "Hidden", Content: "Body", Status: "draft"}
+	if err := draft.Save(ctx); err != nil {
+		t.Fatalf("failed to create draft: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(withRole(1, "user"))
+	router.GET("/posts/by-slug/:slug", getPostBySlug)
+
+	cases := []struct {
+		slug     string
+		want     int
+		location string
+	}{
+		{"second-title", http.StatusOK, ""},
+		{"first-title", http.StatusMovedPermanently, "/posts/by-slug/second-title"},
+		{"hidden", http.StatusNotFound, ""},
+		{"missing", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/by-slug/"+tc.slug, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s: expected status %d, got %d; body=%s", tc.slug, tc.want, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Location"); got != tc.location {
+			t.Errorf("%s: expected Location %q, got %q", tc.slug, tc.location, got)
+		}
+	}
+}
+
+// Readers cannot comment on or react to a post they may not read; they get
+// the same 404 as when fetching it.
+func TestCommentsAndReactionsHideDraftsFromReaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	published := &models.Post{Title: "Published", Content: "Hello"}
+	if err := published.Save(ctx); err != nil {
+		t.Fatalf("failed to create published post: %v", err)
+	}
+	draft := &models.Post{Title: "Draft", Content: "Work in progress", Status: "draft"}
+	if err := draft.Save(ctx); err != nil {
+		t.Fatalf("failed to create draft post: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(withRole(2, "user"))
+	router.POST("/posts/:id/react", reactToPost)
+	router.POST("/posts/:id/comments", createPostComment)
+
+	post := func(path, body string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w.Code
+	}
+
+	draftPath := "/posts/" + strconv.FormatInt(draft.ID, 10)
+	if code := post(draftPath+"/react", `{"reaction":"like"}`); code != http.StatusNotFound {
+		t.Errorf("expected 404 for a reaction to a draft, got %d", code)
+	}
+	if code := post(draftPath+"/comments", `{"content":"Hi"}`); code != http.StatusNotFound {
+		t.Errorf("expected 404 for a comment on a draft, got %d", code)
+	}
+	if stored, err := models.GetPostByID(ctx, draft.ID); err != nil || stored.LikesCount != 0 || stored.CommentsCount != 0 {
+		t.Errorf("expected the draft to stay untouched, got %+v (%v)", stored, err)
+	}
+
+	if code := post("/posts/"+strconv.FormatInt(published.ID, 10)+"/react", `{"reaction":"like"}`); code != http.StatusOK {
+		t.Errorf("expected 200 for a reaction to a published post, got %d", code)
+	}
+}
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// versionETag returns the entity tag of a post or comment version. It
+// names the version of the editable fields, not the exact response body
+// (reaction and comment counters change without a new version), so it is
+// only compared for If-Match and never answers If-None-Match.
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion evaluates the If-Match header of a request that changes a
+// post or comment currently at version current, as RFC 9110 describes it.
+// It returns the version the store has to find when it writes: current when
+// the header names it, or zero when there is no header or it is "*". When
+// the header only names other versions it answers 412 and returns false.
+// Weak tags never match.
+func ifMatchVersion(c *gin.Context, current int64) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	etag := versionETag(current)
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimSpace(candidate) == etag {
+			return current, true
+		}
+	}
+	respondVersionConflict(c, models.ErrVersionConflict)
+	return 0, false
+}
+
+// respondVersionConflict answers 412 when an update lost the race against
+// another one (models.ErrVersionConflict) and reports whether it wrote a
+// response.
+func respondVersionConflict(c *gin.Context, err error) bool {
+	if !errors.Is(err, models.ErrVersionConflict) {
+		return false
+	}
+	c.JSON(http.StatusPreconditionFailed, gin.H{"message": "This was changed by someone else since you loaded it. Reload it and try again."})
+	return true
+}
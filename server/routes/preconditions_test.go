@@ -0,0 +1,198 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /posts/:id returns the post's version as its ETag, and updates that
+// send it back in If-Match only succeed while the post is still at that
+// version.
+func TestUpdatePostIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	post := &models.Post{Title: "Shared", Content: "Body", AuthorID: 1}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/posts/:id", withRole(1, "editor"), getPost)
+	router.PUT("/posts/:id", withRole(1, "editor"), updatePost)
+	path := "/posts/" + strconv.FormatInt(post.ID, 10)
+
+	put := func(ifMatch, content string) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(gin.H{"title": "Shared", "content": content})
+		req := httptest.NewRequest(http.MethodPut, path, bytes.NewReader(data))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("expected ETag \"1\", got %d with %q", w.Code, etag)
+	}
+
+	w = put(etag, "First")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected the update to succeed with ETag \"2\", got %d with %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	for _, ifMatch := range []string{etag, `W/"2"`, `"7", "8"`} {
+		if w := put(ifMatch, "Second"); w.Code != http.StatusPreconditionFailed {
+			t.Errorf("If-Match %s: expected 412, got %d: %s", ifMatch, w.Code, w.Body.String())
+		}
+	}
+	if stored, err := models.GetPostByID(ctx, post.ID); err != nil || stored.Content != "First" {
+		t.Fatalf("expected the stale updates to be refused, got %+v (%v)", stored, err)
+	}
+
+	for _, ifMatch := range []string{`"1", "2"`, "*", ""} {
+		if w := put(ifMatch, "Third"); w.Code != http.StatusOK {
+			t.Errorf("If-Match %q: expected 200, got %d: %s", ifMatch, w.Code, w.Body.String())
+		}
+	}
+}
+
+// A scheduled post going live changes its version, so an edit based on the
+// post as it was before is refused.
+func TestUpdatePostIfMatchAfterScheduledPublish(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	publishAt := time.Now().Add(time.Hour)
+	post := &models.Post{Title: "Later", Content: "Body", AuthorID: 1, Status: "scheduled", PublishAt: &publishAt}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/posts/:id", withRole(1, "editor"), getPost)
+	router.PUT("/posts/:id", withRole(1, "editor"), updatePost)
+	path := "/posts/" + strconv.FormatInt(post.ID, 10)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected an ETag, got %d with %q", w.Code, etag)
+	}
+
+	if _, err := models.ApplyPostSchedule(ctx, publishAt); err != nil {
+		t.Fatalf("failed to publish scheduled post: %v", err)
+	}
+
+	data, _ := json.Marshal(gin.H{"title": "Later", "content": "Edited", "status": "scheduled", "publish_at": publishAt})
+	req := httptest.NewRequest(http.MethodPut, path, bytes.NewReader(data))
+	req.Header.Set("If-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for an edit from before publishing, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, err := models.GetPostByID(ctx, post.ID); err != nil || stored.Status != "published" || stored.Content != "Body" {
+		t.Errorf("expected the post to stay published and unedited, got %+v (%v)", stored, err)
+	}
+}
+
+// Restoring a revision honours If-Match like an update, so a client with a
+// stale copy cannot overwrite a newer edit by restoring.
+func TestRestorePostRevisionIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	post := &models.Post{Title: "Shared", Content: "Original", AuthorID: 1}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	post.Content = "Newer"
+	if err := post.Update(ctx, 1); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/posts/:id/revisions/:number/restore", withRole(1, "editor"), restorePostRevision)
+	path := "/posts/" + strconv.FormatInt(post.ID, 10) + "/revisions/1/restore"
+
+	restore := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := restore(`"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a stale version, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, err := models.GetPostByID(ctx, post.ID); err != nil || stored.Content != "Newer" {
+		t.Fatalf("expected the stale restore to be refused, got %+v (%v)", stored, err)
+	}
+
+	w := restore(`"2"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("expected the restore to succeed with ETag \"3\", got %d with %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if stored, err := models.GetPostByID(ctx, post.ID); err != nil || stored.Content != "Original" {
+		t.Errorf("expected the post to be back at revision 1, got %+v (%v)", stored, err)
+	}
+}
+
+// Comment edits honour If-Match with the comment's version.
+func TestUpdateCommentIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	post := &models.Post{Title: "Comments", Content: "Body"}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	comment, err := models.CreateComment(ctx, post.ID, 2, "reader", "Hi")
+	if err != nil {
+		t.Fatalf("failed to create comment: %v", err)
+	}
+
+	router := gin.New()
+	router.PUT("/posts/:id/comments/:commentId", withRole(2, "user"), updatePostComment)
+	path := "/posts/" + strconv.FormatInt(post.ID, 10) + "/comments/" + comment.ID
+
+	put := func(ifMatch, content string) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(gin.H{"content": content})
+		req := httptest.NewRequest(http.MethodPut, path, bytes.NewReader(data))
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put(`"1"`, "Hello")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected the edit to succeed with ETag \"2\", got %d with %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if w := put(`"1"`, "Hey"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for a stale version, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, err := models.GetCommentByID(ctx, comment.ID); err != nil || stored.Content != "Hello" {
+		t.Errorf("expected the stale edit to be refused, got %+v (%v)", stored, err)
+	}
+}
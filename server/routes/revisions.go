@@ -0,0 +1,215 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"example.com/blog_backend/diff"
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// revisionChange is a metadata field that differs between two revisions.
+type revisionChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// revisionPost loads the post in the :id parameter, answering 400 or 404
+// and returning false when there is none.
+func revisionPost(c *gin.Context) (*models.Post, bool) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse post ID"})
+		return nil, false
+	}
+	post, err := models.GetPostByID(c.Request.Context(), postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		case !respondStoreTimeout(c, err):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch post"})
+		}
+		return nil, false
+	}
+	return post, true
+}
+
+// parseRevisionNumber parses a revision number, answering 400 and returning
+// false when it is not one.
+func parseRevisionNumber(c *gin.Context, number string) (int, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse revision number"})
+		return 0, false
+	}
+	return n, true
+}
+
+// loadRevision returns revision number (given as text) of the post,
+// answering 400 or 404 and returning false when there is none.
+func loadRevision(c *gin.Context, postID int64, number string) (*models.PostRevision, bool) {
+	n, ok := parseRevisionNumber(c, number)
+	if !ok {
+		return nil, false
+	}
+	rev, err := models.GetPostRevision(c.Request.Context(), postID, n)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Revision not found"})
+		case !respondStoreTimeout(c, err):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch revision"})
+		}
+		return nil, false
+	}
+	return rev, true
+}
+
+// getPostRevisions lists the kept revisions of a post, newest first and
+// without their content, along with how many the post keeps.
+func getPostRevisions(c *gin.Context) {
+	post, ok := revisionPost(c)
+	if !ok {
+		return
+	}
+	revisions, err := models.ListPostRevisions(c.Request.Context(), post.ID)
+	if err != nil {
+		if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch revisions"})
+		}
+		return
+	}
+
+	for i := range revisions {
+		revisions[i].Content = ""
+	}
+	if revisions == nil {
+		revisions = []models.PostRevision{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"revisions":      revisions,
+		"revision_limit": models.EffectiveRevisionLimit(post.RevisionLimit),
+	})
+}
+
+// getPostRevision returns one revision, including its content.
+func getPostRevision(c *gin.Context) {
+	post, ok := revisionPost(c)
+	if !ok {
+		return
+	}
+	rev, ok := loadRevision(c, post.ID, c.Param("number"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, rev)
+}
+
+// diffPostRevisions compares the revisions in the from and to query
+// parameters, in either order: the metadata fields that differ and a
+// line-level diff of the content.
+func diffPostRevisions(c *gin.Context) {
+	post, ok := revisionPost(c)
+	if !ok {
+		return
+	}
+	from, ok := loadRevision(c, post.ID, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := loadRevision(c, post.ID, c.Query("to"))
+	if !ok {
+		return
+	}
+
+	lines := diff.Lines(from.Content, to.Content)
+	inserted, deleted := diff.Stats(lines)
+	from.Content, to.Content = "", ""
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"changes":  revisionChanges(from, to),
+		"lines":    lines,
+		"inserted": inserted,
+		"deleted":  deleted,
+	})
+}
+
+// revisionChanges lists the metadata fields that differ from one revision
+// to another.
+func revisionChanges(from, to *models.PostRevision) []revisionChange {
+	fields := []revisionChange{
+		{"title", from.Title, to.Title},
+		{"slug", from.Slug, to.Slug},
+		{"description", from.Description, to.Description},
+		{"category", from.Category, to.Category},
+		{"tags", from.Tags, to.Tags},
+		{"cover_image_key", from.CoverImageKey, to.CoverImageKey},
+		{"status", from.Status, to.Status},
+		{"publish_at", revisionTime(from.PublishAt), revisionTime(to.PublishAt)},
+		{"unpublish_at", revisionTime(from.UnpublishAt), revisionTime(to.UnpublishAt)},
+		{"members_only", from.MembersOnly, to.MembersOnly},
+	}
+	changes := []revisionChange{}
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.From, f.To) {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+// revisionTime formats an optional schedule time for comparison, or
+// returns nil.
+func revisionTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// restorePostRevision makes an old revision of a post current again, as a
+// new revision. Like updatePost, editors can only restore their own posts,
+// and If-Match is honoured with the post's version as its ETag.
+func restorePostRevision(c *gin.Context) {
+	post, ok := revisionPost(c)
+	if !ok {
+		return
+	}
+	userID := c.GetInt64("userId")
+	if c.GetString("role") == "editor" && post.AuthorID != userID {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "You are not authorized to update this post"})
+		return
+	}
+	number, ok := parseRevisionNumber(c, c.Param("number"))
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c, post.Version)
+	if !ok {
+		return
+	}
+
+	restored, err := models.RestorePostRevision(c.Request.Context(), post.ID, number, userID, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPostNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		case errors.Is(err, models.ErrRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Revision not found"})
+		case !respondPostInputError(c, err) && !respondVersionConflict(c, err) && !respondStoreTimeout(c, err):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not restore revision"})
+		}
+		return
+	}
+
+	restored.CoverImage = coverImage(c.Request.Context(), restored.CoverImageKey)
+	c.Header("ETag", versionETag(restored.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Revision restored successfully", "post": restored})
+}
@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"example.com/blog_backend/diff"
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Every update is kept as a revision. Editors can list and compare them, and
+// restoring an old one records a new revision on top.
+func TestPostRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	post := &models.Post{Title: "Draft", Content: "one\ntwo\nthree", AuthorID: 1}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	router := gin.New()
+	router.PUT("/posts/:id", withRole(1, "editor"), updatePost)
+	router.GET("/posts/:id/revisions", withRole(1, "editor"), getPostRevisions)
+	router.GET("/posts/:id/revisions/diff", withRole(1, "editor"), diffPostRevisions)
+	router.GET("/posts/:id/revisions/:number", withRole(1, "editor"), getPostRevision)
+	router.POST("/posts/:id/revisions/:number/restore", withRole(1, "editor"), restorePostRevision)
+	router.POST("/other/posts/:id/revisions/:number/restore", withRole(2, "editor"), restorePostRevision)
+
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return w
+	}
+	base := "/posts/" + strconv.FormatInt(post.ID, 10)
+
+	w := serve(http.MethodPut, base, gin.H{"title": "Final", "content": "one\n2\nthree", "tags": []string{"go"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the update to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(http.MethodGet, base+"/revisions", nil)
+	var list struct {
+		Revisions     []models.PostRevision `json:"revisions"`
+		RevisionLimit int                   `json:"revision_limit"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the revision list, got %d: %s", w.Code, w.Body.String())
+	}
+	// The post as created is kept as revision 1 on its first update.
+	if len(list.Revisions) != 2 || list.Revisions[0].Number != 2 || list.Revisions[1].Title != "Draft" || list.RevisionLimit != models.DefaultRevisionLimit {
+		t.Fatalf("unexpected revisions %+v", list)
+	}
+	if list.Revisions[0].Content != "" {
+		t.Errorf("expected the list to leave out content")
+	}
+
+	w = serve(http.MethodGet, base+"/revisions/diff?from=1&to=2", nil)
+	var compared struct {
+		Changes  []revisionChange `json:"changes"`
+		Lines    []diff.Line      `json:"lines"`
+		Inserted int              `json:"inserted"`
+		Deleted  int              `json:"deleted"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &compared); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected a diff, got %d: %s", w.Code, w.Body.String())
+	}
+	if compared.Inserted != 1 || compared.Deleted != 1 || len(compared.Lines) != 4 {
+		t.Errorf("unexpected line diff %+v", compared)
+	}
+	var fields []string
+	for _, c := range compared.Changes {
+		fields = append(fields, c.Field)
+	}
+	if len(fields) != 3 || fields[0] != "title" || fields[1] != "slug" || fields[2] != "tags" {
+		t.Errorf("expected title, slug and tags to change, got %v", fields)
+	}
+
+	if w := serve(http.MethodGet, base+"/revisions/9", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing revision, got %d", w.Code)
+	}
+	if w := serve(http.MethodPost, "/other"+base+"/revisions/1/restore", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected another editor's restore to be refused, got %d", w.Code)
+	}
+
+	w = serve(http.MethodPost, base+"/revisions/1/restore", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the restore to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	restored, err := models.GetPostByID(ctx, post.ID)
+	if err != nil || restored.Title != "Draft" || restored.Content != "one\ntwo\nthree" || len(restored.Tags) != 0 {
+		t.Fatalf("expected the post to be back at revision 1, got %+v (%v)", restored, err)
+	}
+
+	w = serve(http.MethodGet, base+"/revisions/3", nil)
+	var rev models.PostRevision
+	if err := json.Unmarshal(w.Body.Bytes(), &rev); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected revision 3, got %d: %s", w.Code, w.Body.String())
+	}
+	if rev.RestoredFrom != 1 || rev.EditorID != 1 || rev.Content != "one\ntwo\nthree" {
+		t.Errorf("unexpected restored revision %+v", rev)
+	}
+}
@@ -12,16 +12,43 @@ func RegisterRoutes(server *gin.Engine) {
 	server.POST("/login/google", googleLogin)
 	server.POST("/login/remember", rememberLogin)
 
-		// All blog post routes are behind authentication so users must log in
-		// before reading or managing your blogs.
+	// Public feeds of published posts.
+	server.GET("/feed.rss", getFeed(rssFeed))
+	server.GET("/feed.atom", getFeed(atomFeed))
+	server.GET("/categories/:slug/feed.rss", getCategoryFeed(rssFeed))
+	server.GET("/categories/:slug/feed.atom", getCategoryFeed(atomFeed))
+	server.GET("/authors/:id/feed.rss", getAuthorFeed(rssFeed))
+	server.GET("/authors/:id/feed.atom", getAuthorFeed(atomFeed))
+
+	// Public crawler files.
+	server.GET("/sitemap.xml", getSitemap)
+	server.GET("/sitemaps/:page", getSitemapPage)
+	server.GET("/robots.txt", getRobots)
+
+	// Uploaded images are public so covers show to every reader.
+	server.GET("/media/:key", getMedia)
+
+		// Reading posts needs a login unless public read mode is on, in which
+		// case a token is optional and visitors read as anonymous readers.
+	readers := server.Group("/")
+	if publicRead {
+		readers.Use(middlewares.OptionalAuthenticate)
+	} else {
+		readers.Use(middlewares.Authenticate)
+	}
+	readers.GET("/posts", getPosts)
+	readers.GET("/posts/search", searchPosts)
+	readers.GET("/posts/by-slug/:slug", getPostBySlug)
+	readers.GET("/posts/:id", getPost)
+	readers.GET("/posts/:id/comments", getPostComments)
+	readers.GET("/tags", getTags)
+	readers.GET("/categories", getCategories)
+
+		// Every other blog route is behind authentication.
 	authenticated := server.Group("/")
 	authenticated.Use(middlewares.Authenticate)
 
-			// Any authenticated user can read posts, react to them, and work with
-			// comments.
-	authenticated.GET("/posts", getPosts)
-	authenticated.GET("/posts/:id", getPost)
-	authenticated.GET("/posts/:id/comments", getPostComments)
+			// Any authenticated user can react to posts and work with comments.
 	authenticated.POST("/posts/:id/comments", createPostComment)
 	authenticated.PUT("/posts/:id/comments/:commentId", updatePostComment)
 	authenticated.DELETE("/posts/:id/comments/:commentId", deletePostComment)
@@ -33,12 +60,29 @@ func RegisterRoutes(server *gin.Engine) {
 			editorOrAdmin.POST("/posts", createPost)
 			editorOrAdmin.PUT("/posts/:id", updatePost)
 			editorOrAdmin.DELETE("/posts/:id", deletePost)
+			editorOrAdmin.GET("/posts/:id/revisions", getPostRevisions)
+			editorOrAdmin.GET("/posts/:id/revisions/diff", diffPostRevisions)
+			editorOrAdmin.GET("/posts/:id/revisions/:number", getPostRevision)
+			editorOrAdmin.POST("/posts/:id/revisions/:number/restore", restorePostRevision)
+			editorOrAdmin.PUT("/tags/:tag", renameTag)
+			editorOrAdmin.POST("/tags/merge", mergeTags)
+			editorOrAdmin.POST("/media", uploadMedia)
 
 			// Only admin users can manage other users.
 			adminOnly := authenticated.Group("/")
 			adminOnly.Use(middlewares.RequireAdmin)
+			adminOnly.POST("/categories", createCategory)
+			adminOnly.PUT("/categories/:slug", updateCategory)
+			adminOnly.DELETE("/categories/:slug", deleteCategory)
 			adminOnly.GET("/users", getUsers)
 			adminOnly.PUT("/users/:id/role", updateUserRole)
 			adminOnly.DELETE("/users/:id", deleteUser)
+			adminOnly.GET("/admin/backup", exportBackup)
+			adminOnly.GET("/admin/fsck", checkConsistency)
+			adminOnly.POST("/admin/fsck/repair", repairConsistency)
+			adminOnly.GET("/media", listMedia)
+			adminOnly.DELETE("/media/:key", deleteMedia)
+			adminOnly.GET("/admin/media/unreferenced", unreferencedMedia)
+			adminOnly.POST("/admin/media/sweep", sweepMedia)
 }
 
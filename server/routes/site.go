@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Site describes the public blog for links that leave the API, such as those
+// in feeds and the sitemap.
+type Site struct {
+	// Title names the blog in feeds.
+	Title string
+	// URL is the address of the blog frontend, without a trailing slash.
+	// When empty, links point at the host serving the request.
+	URL string
+	// APIURL is the public address of this API, without a trailing slash,
+	// for links back to it: a feed's self link, the numbered sitemaps of
+	// the sitemap index and the sitemap named in robots.txt. When empty,
+	// they use the host serving the request, which the client chooses; set
+	// it when responses are cached.
+	APIURL string
+	// Robots replaces the generated robots.txt when set.
+	Robots string
+}
+
+// DefaultSiteTitle is used when Site.Title is empty.
+const DefaultSiteTitle = "Blog"
+
+var site = Site{Title: DefaultSiteTitle}
+
+// SetSite configures the public blog address and title.
+func SetSite(s Site) {
+	s.URL = strings.TrimRight(s.URL, "/")
+	s.APIURL = strings.TrimRight(s.APIURL, "/")
+	if s.Title == "" {
+		s.Title = DefaultSiteTitle
+	}
+	site = s
+}
+
+// siteURL returns the frontend address, falling back to the scheme and host
+// of the request.
+func siteURL(c *gin.Context) string {
+	if site.URL != "" {
+		return site.URL
+	}
+	return requestOrigin(c)
+}
+
+// apiURL returns the public address of the API, falling back to the scheme
+// and host of the request.
+func apiURL(c *gin.Context) string {
+	if site.APIURL != "" {
+		return site.APIURL
+	}
+	return requestOrigin(c)
+}
+
+// requestOrigin returns the scheme and host the client used to reach the
+// API, honouring X-Forwarded-Proto from a proxy in front of it.
+func requestOrigin(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return (&url.URL{Scheme: scheme, Host: c.Request.Host}).String()
+}
+
+// postURL returns the frontend address of the post with the given slug.
+func postURL(c *gin.Context, slug string) string {
+	return siteURL(c) + "/blog.html?post=" + url.QueryEscape(slug)
+}
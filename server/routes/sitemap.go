@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/blog_backend/models"
+	"example.com/blog_backend/sitemap"
+	"github.com/gin-gonic/gin"
+)
+
+// sitemapSize is the number of URLs per sitemap. Once the blog has more,
+// /sitemap.xml becomes an index of /sitemaps/1.xml, /sitemaps/2.xml, ...
+var sitemapSize = sitemap.MaxURLs
+
+// sitemapURLs lists the blog page followed by every published post, newest
+// first. Drafts, scheduled posts and members-only posts, which crawlers
+// cannot read, are never included.
+func sitemapURLs(c *gin.Context) ([]sitemap.URL, error) {
+	posts, err := publishedPosts(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, len(posts)+1)
+	urls = append(urls, sitemap.URL{Loc: siteURL(c) + "/blog.html"})
+	for _, p := range posts {
+		if p.MembersOnly {
+			continue
+		}
+		if p.LastModified().After(urls[0].LastMod) {
+			urls[0].LastMod = p.LastModified()
+		}
+		urls = append(urls, sitemap.URL{Loc: postURL(c, p.Slug), LastMod: p.LastModified()})
+	}
+	return urls, nil
+}
+
+// publishedPosts pages through every published post.
+func publishedPosts(ctx context.Context) ([]models.PostSummary, error) {
+	var posts []models.PostSummary
+	filter := models.PostFilter{Status: "published", Limit: models.MaxPostPageSize}
+	for {
+		page, err := models.QueryPosts(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, page.Posts...)
+		if page.NextCursor == "" {
+			return posts, nil
+		}
+		if filter.After, err = models.DecodePostCursor(page.NextCursor); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// getSitemap serves the sitemap of the blog, or an index of numbered
+// sitemaps when there are more than sitemapSize URLs.
+func getSitemap(c *gin.Context) {
+	urls, err := sitemapURLs(c)
+	if err != nil {
+		respondSitemapError(c, err)
+		return
+	}
+	if len(urls) <= sitemapSize {
+		serveSitemap(c, sitemap.URLSet, urls)
+		return
+	}
+
+	var sitemaps []sitemap.URL
+	for n := 1; (n-1)*sitemapSize < len(urls); n++ {
+		chunk := sitemapChunk(urls, n)
+		entry := sitemap.URL{Loc: apiURL(c) + "/sitemaps/" + strconv.Itoa(n) + ".xml"}
+		for _, u := range chunk {
+			if u.LastMod.After(entry.LastMod) {
+				entry.LastMod = u.LastMod
+			}
+		}
+		sitemaps = append(sitemaps, entry)
+	}
+	serveSitemap(c, sitemap.Index, sitemaps)
+}
+
+// getSitemapPage serves one numbered sitemap of the index, such as
+// /sitemaps/2.xml.
+func getSitemapPage(c *gin.Context) {
+	n, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".xml"))
+	if err != nil || n < 1 || !strings.HasSuffix(c.Param("page"), ".xml") {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Sitemap not found"})
+		return
+	}
+
+	urls, err := sitemapURLs(c)
+	if err != nil {
+		respondSitemapError(c, err)
+		return
+	}
+	chunk := sitemapChunk(urls, n)
+	if len(chunk) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Sitemap not found"})
+		return
+	}
+	serveSitemap(c, sitemap.URLSet, chunk)
+}
+
+// sitemapChunk returns the URLs of the nth numbered sitemap, counting from 1.
+func sitemapChunk(urls []sitemap.URL, n int) []sitemap.URL {
+	start := (n - 1) * sitemapSize
+	if start >= len(urls) {
+		return nil
+	}
+	return urls[start:min(start+sitemapSize, len(urls))]
+}
+
+func serveSitemap(c *gin.Context, render func([]sitemap.URL) ([]byte, error), urls []sitemap.URL) {
+	body, err := render(urls)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render sitemap"})
+		return
+	}
+	var modified time.Time
+	for _, u := range urls {
+		if u.LastMod.After(modified) {
+			modified = u.LastMod
+		}
+	}
+	serveCacheable(c, sitemap.ContentType, body, modified)
+}
+
+func respondSitemapError(c *gin.Context, err error) {
+	if !respondStoreTimeout(c, err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+	}
+}
+
+// getRobots serves robots.txt: Site.Robots when it is set, and otherwise a
+// file that allows everything and names the sitemap.
+func getRobots(c *gin.Context) {
+	body := site.Robots
+	if body == "" {
+		body = "User-agent: *\nDisallow:\n\nSitemap: " + apiURL(c) + "/sitemap.xml\n"
+	}
+	serveCacheable(c, "text/plain; charset=utf-8", []byte(body), time.Time{})
+}
@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func newSitemapRouter() *gin.Engine {
+	router := gin.New()
+	router.GET("/sitemap.xml", getSitemap)
+	router.GET("/sitemaps/:page", getSitemapPage)
+	router.GET("/robots.txt", getRobots)
+	return router
+}
+
+func getPath(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://api.example.com"+path, nil))
+	return w
+}
+
+// The sitemap lists published posts only, and becomes an index of numbered
+// sitemaps once there are more URLs than fit into one.
+func TestSitemap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	SetSite(Site{URL: "https://blog.example.com"})
+	defer SetSite(Site{})
+	ctx := context.Background()
+
+	publishAt := time.Now().Add(time.Hour)
+	for _, p := range []*models.Post{
+		{Title: "One", Content: "Body", CreatedAt: time.Now().Add(-3 * time.Hour)},
+		{Title: "Two", Content: "Body", CreatedAt: time.Now().Add(-2 * time.Hour)},
+		{Title: "Three", Content: "Body", CreatedAt: time.Now().Add(-time.Hour)},
+		{Title: "Draft", Content: "Body", Status: "draft"},
+		{Title: "Later", Content: "Body", Status: "scheduled", PublishAt: &publishAt},
+	} {
+		if err := p.Save(ctx); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+	router := newSitemapRouter()
+
+	w := getPath(router, "/sitemap.xml")
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "<urlset") {
+		t.Fatalf("expected a sitemap, got %d:\n%s", w.Code, body)
+	}
+	for _, want := range []string{"https://blog.example.com/blog.html<", "?post=one", "?post=two", "?post=three", "<lastmod>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the sitemap:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{"?post=draft", "?post=later"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("expected %q to be left out of the sitemap:\n%s", unwanted, body)
+		}
+	}
+
+	defer func(size int) { sitemapSize = size }(sitemapSize)
+	sitemapSize = 3
+
+	w = getPath(router, "/sitemap.xml")
+	body = w.Body.String()
+	if !strings.Contains(body, "<sitemapindex") || !strings.Contains(body, "http://api.example.com/sitemaps/2.xml") || strings.Contains(body, "/sitemaps/3.xml") {
+		t.Fatalf("expected an index of two sitemaps, got:\n%s", body)
+	}
+	if w := getPath(router, "/sitemaps/2.xml"); w.Code != http.StatusOK || strings.Count(w.Body.String(), "<url>") != 1 || !strings.Contains(w.Body.String(), "?post=one") {
+		t.Errorf("expected the oldest post on the second sitemap, got %d:\n%s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/sitemaps/3.xml", "/sitemaps/0.xml", "/sitemaps/one.xml", "/sitemaps/1"} {
+		if w := getPath(router, path); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for %s, got %d", path, w.Code)
+		}
+	}
+
+	// A configured API address replaces the Host header in the index.
+	SetSite(Site{URL: "https://blog.example.com", APIURL: "https://feeds.example.com"})
+	if body := getPath(router, "/sitemap.xml").Body.String(); !strings.Contains(body, "https://feeds.example.com/sitemaps/2.xml") || strings.Contains(body, "api.example.com") {
+		t.Errorf("expected the index to link to the configured API address, got:\n%s", body)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := newSitemapRouter()
+
+	w := getPath(router, "/robots.txt")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Sitemap: http://api.example.com/sitemap.xml") {
+		t.Errorf("expected the generated robots.txt to name the sitemap, got %d:\n%s", w.Code, w.Body.String())
+	}
+
+	SetSite(Site{APIURL: "https://feeds.example.com"})
+	defer SetSite(Site{})
+	if w := getPath(router, "/robots.txt"); !strings.Contains(w.Body.String(), "Sitemap: https://feeds.example.com/sitemap.xml") {
+		t.Errorf("expected robots.txt to name the sitemap at the configured API address, got:\n%s", w.Body.String())
+	}
+
+	SetSite(Site{Robots: "User-agent: *\nDisallow: /\n"})
+	if w := getPath(router, "/robots.txt"); w.Body.String() != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("expected the configured robots.txt, got:\n%s", w.Body.String())
+	}
+}
+
+// A scheduled post that goes live counts as modified when it was published,
+// even though it was last edited before.
+func TestSitemapLastModOfScheduledPost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	post := &models.Post{Title: "Later", Content: "Body", Status: "scheduled", PublishAt: &publishAt}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	if _, err := models.ApplyPostSchedule(ctx, publishAt); err != nil {
+		t.Fatalf("failed to publish scheduled post: %v", err)
+	}
+
+	w := getPath(newSitemapRouter(), "/sitemap.xml")
+	want := "<lastmod>" + publishAt.Format(time.RFC3339) + "</lastmod>"
+	if strings.Count(w.Body.String(), want) != 2 {
+		t.Errorf("expected the blog page and the post at %s, got:\n%s", want, w.Body.String())
+	}
+	if got := w.Header().Get("Last-Modified"); got != publishAt.Format(http.TimeFormat) {
+		t.Errorf("expected Last-Modified %s, got %q", publishAt.Format(http.TimeFormat), got)
+	}
+}
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// getTags lists every tag with the number of published posts carrying it,
+// most used first. Admins and editors also see tags used only on drafts and
+// the total post count per tag.
+func getTags(c *gin.Context) {
+	tags, err := models.ListTags(c.Request.Context())
+	if err != nil {
+		if !respondStoreTimeout(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tags"})
+		}
+		return
+	}
+
+	roleValue, _ := c.Get("role")
+	role, _ := roleValue.(string)
+	if role != "admin" && role != "editor" {
+		visible := make([]models.TagCount, 0, len(tags))
+		for _, t := range tags {
+			if t.Count > 0 {
+				visible = append(visible, models.TagCount{Tag: t.Tag, Count: t.Count})
+			}
+		}
+		tags = visible
+	}
+	if tags == nil {
+		tags = []models.TagCount{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// respondTagError answers the client errors of RenameTag and MergeTags and
+// reports whether it wrote a response.
+func respondTagError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrTagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Tag not found"})
+	case errors.Is(err, models.ErrTagExists):
+		c.JSON(http.StatusConflict, gin.H{"message": "A tag with this name already exists. Merge the tags instead."})
+	default:
+		return respondStoreTimeout(c, err)
+	}
+	return true
+}
+
+// renameTag renames a tag on every post. The body is {"name": "new-name"}.
+func renameTag(c *gin.Context) {
+	var payload struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request body"})
+		return
+	}
+
+	updated, err := models.RenameTag(c.Request.Context(), c.Param("tag"), payload.Name)
+	if err != nil {
+		if !respondTagError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename tag"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag renamed", "updated_posts": updated})
+}
+
+// mergeTags replaces several tags with one on every post. The body is
+// {"sources": ["golang", "go-lang"], "target": "go"}.
+func mergeTags(c *gin.Context) {
+	var payload struct {
+		Sources []string `json:"sources" binding:"required,min=1"`
+		Target  string   `json:"target" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request body"})
+		return
+	}
+
+	updated, err := models.MergeTags(c.Request.Context(), payload.Sources, payload.Target)
+	if err != nil {
+		if !respondTagError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge tags"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tags merged", "updated_posts": updated})
+}
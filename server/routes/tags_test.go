@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"example.com/blog_backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Readers only see tags on published posts; editors also see draft-only tags
+// and the total counts.
+func TestGetTagsHidesDraftOnlyTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	models.SetStore(models.NewMemoryStore())
+
+	for _, p := range []*models.Post{
+		{Title: "Published", Content: "Body", Tags: []string{"go"}},
+		{Title: "Draft", Content: "Body", Tags: []string{"go", "secret"}, Status: "draft"},
+	} {
+		if err := p.Save(context.Background()); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	cases := map[string][]models.TagCount{
+		"user":   {{Tag: "go", Count: 1}},
+		"editor": {{Tag: "go", Count: 1, Total: 2}, {Tag: "secret", Count: 0, Total: 1}},
+	}
+	for role, want := range cases {
+		router := gin.New()
+		router.Use(withRole(1, role))
+		router.GET("/tags", getTags)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tags", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d; body=%s", role, http.StatusOK, w.Code, w.Body.String())
+		}
+		var body struct {
+			Tags []models.TagCount `json:"tags"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: failed to decode tags: %v", role, err)
+		}
+		if !reflect.DeepEqual(body.Tags, want) {
+			t.Errorf("%s: expected %+v, got %+v", role, want, body.Tags)
+		}
+	}
+}
@@ -18,11 +18,14 @@ func signup(context *gin.Context) {
 		return
 	}
 
-	if err := user.Save(); err != nil {
+	if err := user.Save(context.Request.Context()); err != nil {
 		if errors.Is(err, models.ErrUserAlreadyExists) {
 			context.JSON(http.StatusConflict, gin.H{"message": "Username already exists"})
 			return
 		}
+		if respondStoreTimeout(context, err) {
+			return
+		}
 
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create User. Try again later.", "error": err.Error()})
 		return
@@ -32,8 +35,11 @@ func signup(context *gin.Context) {
 }
 
 func getUsers(context *gin.Context) {
-	users, err := models.GetAllUsers()
+	users, err := models.GetAllUsers(context.Request.Context())
 	if err != nil {
+		if respondStoreTimeout(context, err) {
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
 		return
 	}
@@ -59,7 +65,7 @@ func updateUserRole(context *gin.Context) {
 		return
 	}
 
-	if err := models.UpdateUserRole(userID, payload.Role); err != nil {
+	if err := models.UpdateUserRole(context.Request.Context(), userID, payload.Role); err != nil {
 		if errors.Is(err, models.ErrUserNotFound) {
 			context.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
 			return
@@ -72,6 +78,9 @@ func updateUserRole(context *gin.Context) {
 			context.JSON(http.StatusBadRequest, gin.H{"message": "Cannot demote the last admin user"})
 			return
 		}
+		if respondStoreTimeout(context, err) {
+			return
+		}
 
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update user role", "error": err.Error()})
 		return
@@ -91,7 +100,7 @@ func deleteUser(context *gin.Context) {
 		return
 	}
 
-	if err := models.DeleteUser(userID); err != nil {
+	if err := models.DeleteUser(context.Request.Context(), userID); err != nil {
 		if errors.Is(err, models.ErrUserNotFound) {
 			context.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
 			return
@@ -100,6 +109,9 @@ func deleteUser(context *gin.Context) {
 			context.JSON(http.StatusBadRequest, gin.H{"message": "Cannot delete the last admin user"})
 			return
 		}
+		if respondStoreTimeout(context, err) {
+			return
+		}
 
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not delete user", "error": err.Error()})
 		return
@@ -125,7 +137,7 @@ func login(context *gin.Context) {
 		Password: payload.Password,
 	}
 
-	if err := user.ValidateCredentials(); err != nil {
+	if err := user.ValidateCredentials(context.Request.Context()); err != nil {
 		if errors.Is(err, models.ErrUserNotFound) {
 			context.JSON(http.StatusUnauthorized, gin.H{"message": "User does not exist. Please sign up as a new user."})
 			return
@@ -134,6 +146,9 @@ func login(context *gin.Context) {
 			context.JSON(http.StatusUnauthorized, gin.H{"message": "Incorrect password. Please try again."})
 			return
 		}
+		if respondStoreTimeout(context, err) {
+			return
+		}
 		// For any other error coming from credential validation (for example, an
 		// unexpected database error), log the detailed error on the server and
 		// return a 500 with a generic message to avoid leaking internals.
@@ -192,8 +207,11 @@ func googleLogin(context *gin.Context) {
 		return
 	}
 
-	user, err := models.FindOrCreateUserByEmail(email, sub)
+	user, err := models.FindOrCreateUserByEmail(context.Request.Context(), email, sub)
 	if err != nil {
+		if respondStoreTimeout(context, err) {
+			return
+		}
 		// Log the underlying error so we can diagnose Firestore or model issues
 		// without exposing internal details to the client.
 		log.Printf("googleLogin: FindOrCreateUserByEmail(%q) failed: %v", email, err)
@@ -255,12 +273,15 @@ func rememberLogin(context *gin.Context) {
 		return
 	}
 
-	user, err := models.GetUserByID(userID)
+	user, err := models.GetUserByID(context.Request.Context(), userID)
 	if err != nil {
 		if errors.Is(err, models.ErrUserNotFound) {
 			context.JSON(http.StatusUnauthorized, gin.H{"message": "Could not authenticate user"})
 			return
 		}
+		if respondStoreTimeout(context, err) {
+			return
+		}
 
 		log.Printf("rememberLogin: GetUserByID(%d) failed: %v", userID, err)
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not authenticate user"})
@@ -9,7 +9,7 @@ import (
 	"testing"
 	"time"
 
-	"example.com/blog_backend/db"
+	"example.com/blog_backend/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,11 +18,8 @@ import (
 func TestSignupDuplicateUsernameReturnsConflict(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	// Ensure the database is initialised for the test.
-	db.InitDB()
-	if db.FirestoreClient == nil {
-		t.Skip("Firestore client is not initialized; skipping signup duplicate username test")
-	}
+	// Run against a fresh in-memory store so the test needs no Firestore.
+	models.SetStore(models.NewMemoryStore())
 
 	router := gin.Default()
 	router.POST("/signup", signup)
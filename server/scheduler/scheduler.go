@@ -0,0 +1,77 @@
+// Package scheduler runs the background publisher for scheduled posts. At a
+// fixed interval it publishes every scheduled post whose publish_at has
+// passed and turns published posts whose unpublish_at has passed back into
+// drafts (see models.ApplyPostSchedule).
+//
+// The publisher reads the time from a Clock so tests can move time forward
+// without waiting. Several server instances may run a publisher against the
+// same database: every post is checked and changed atomically by the store.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/blog_backend/models"
+)
+
+// DefaultInterval is how often the publisher runs unless configured
+// otherwise.
+const DefaultInterval = time.Minute
+
+// Clock returns the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Publisher applies post schedules.
+type Publisher struct {
+	clock    Clock
+	interval time.Duration
+}
+
+// NewPublisher returns a publisher that reads the time from clock and runs
+// every interval. A non-positive interval uses DefaultInterval.
+func NewPublisher(clock Clock, interval time.Duration) *Publisher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Publisher{clock: clock, interval: interval}
+}
+
+// RunOnce applies every schedule that is due at the clock's current time and
+// returns the IDs of the posts it changed.
+func (p *Publisher) RunOnce(ctx context.Context) ([]int64, error) {
+	return models.ApplyPostSchedule(ctx, p.clock.Now())
+}
+
+// Run calls RunOnce right away and then once per interval until ctx is
+// done. Failures are logged and retried on the next run.
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		ids, err := p.RunOnce(ctx)
+		if err != nil {
+			log.Printf("failed to apply post schedules: %v", err)
+		} else if len(ids) > 0 {
+			log.Printf("applied schedules of %d posts: %v", len(ids), ids)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
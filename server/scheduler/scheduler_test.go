@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/blog_backend/models"
+)
+
+// fakeClock is a Clock that only moves when the test advances it.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// A scheduled post goes live once its publish_at has passed, listed as new,
+// and goes back to being a draft once its unpublish_at has passed.
+func TestPublisherAppliesSchedules(t *testing.T) {
+	models.SetStore(models.NewMemoryStore())
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	publisher := NewPublisher(clock, time.Minute)
+
+	publishAt := clock.Now().Add(time.Hour)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+	post := &models.Post{
+		Title:       "Launch",
+		Content:     "Body",
+		Status:      "scheduled",
+		PublishAt:   &publishAt,
+		UnpublishAt: &unpublishAt,
+		CreatedAt:   clock.Now(),
+	}
+	if err := post.Save(ctx); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	status := func() *models.Post {
+		t.Helper()
+		p, err := models.GetPostByID(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("failed to load post: %v", err)
+		}
+		return p
+	}
+
+	run := func(want []int64) {
+		t.Helper()
+		ids, err := publisher.RunOnce(ctx)
+		if err != nil {
+			t.Fatalf("RunOnce failed: %v", err)
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("expected changed posts %v, got %v", want, ids)
+		}
+	}
+
+	run(nil)
+	if got := status(); got.Status != "scheduled" {
+		t.Fatalf("expected the post to stay scheduled, got %q", got.Status)
+	}
+
+	clock.Advance(time.Hour)
+	run([]int64{post.ID})
+	got := status()
+	if got.Status != "published" {
+		t.Fatalf("expected the post to be published, got %q", got.Status)
+	}
+	if !got.CreatedAt.Equal(publishAt) {
+		t.Errorf("expected created_at to become publish_at %v, got %v", publishAt, got.CreatedAt)
+	}
+
+	clock.Advance(time.Hour)
+	run(nil)
+
+	clock.Advance(24 * time.Hour)
+	run([]int64{post.ID})
+	got = status()
+	if got.Status != "draft" || got.UnpublishAt != nil {
+		t.Errorf("expected an unpublished draft without unpublish_at, got %q and %v", got.Status, got.UnpublishAt)
+	}
+}
+
+// Run applies due schedules right away and stops when its context ends.
+func TestPublisherRunStopsWithContext(t *testing.T) {
+	models.SetStore(models.NewMemoryStore())
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+
+	publishAt := clock.Now().Add(-time.Minute)
+	post := &models.Post{Title: "Overdue", Content: "Body", Status: "scheduled", PublishAt: &publishAt}
+	if err := post.Save(context.Background()); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewPublisher(clock, time.Hour).Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p, err := models.GetPostByID(context.Background(), post.ID)
+		if err != nil {
+			t.Fatalf("failed to load post: %v", err)
+		}
+		if p.Status == "published" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Run did not publish the overdue post")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+}
@@ -0,0 +1,394 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"example.com/blog_backend/models"
+)
+
+// BM25 parameters: k1 controls term-frequency saturation and b how strongly
+// scores are normalized by field length.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// maxPrefixExpansions caps how many indexed words a prefix clause matches.
+const maxPrefixExpansions = 64
+
+type field int
+
+const (
+	fieldTitle field = iota
+	fieldDescription
+	fieldCategory
+	fieldContent
+	numFields
+)
+
+// fieldWeights make a match in the title or description count for more
+// than the same match in the body.
+var fieldWeights = [numFields]float64{3, 2, 2, 1}
+
+// fieldText returns the text indexed for field f. Tags are indexed with the
+// category, as they play the same role.
+func fieldText(p models.Post, f field) string {
+	switch f {
+	case fieldTitle:
+		return p.Title
+	case fieldDescription:
+		return p.Description
+	case fieldCategory:
+		return strings.Join(append([]string{p.Category}, p.Tags...), " ")
+	default:
+		return p.Content
+	}
+}
+
+// positions holds the word positions of one term in each field of a post.
+type positions [numFields][]int
+
+type document struct {
+	post    models.Post
+	lengths [numFields]int
+}
+
+// indexData is the inverted index itself. It is not safe for concurrent use;
+// Index guards it.
+type indexData struct {
+	docs     map[int64]*document
+	postings map[string]map[int64]*positions
+	// terms holds the keys of postings in sorted order for prefix lookups.
+	terms     []string
+	totalLens [numFields]int
+}
+
+func newIndexData() *indexData {
+	return &indexData{
+		docs:     make(map[int64]*document),
+		postings: make(map[string]map[int64]*positions),
+	}
+}
+
+func (d *indexData) add(p models.Post) {
+	if old, ok := d.docs[p.ID]; ok {
+		// Post.Update does not carry the creation time; keep the indexed one.
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = old.post.CreatedAt
+		}
+		d.remove(p.ID)
+	}
+
+	doc := &document{post: p}
+	for f := field(0); f < numFields; f++ {
+		words := terms(fieldText(p, f))
+		doc.lengths[f] = len(words)
+		d.totalLens[f] += len(words)
+
+		for pos, term := range words {
+			docs, ok := d.postings[term]
+			if !ok {
+				docs = make(map[int64]*positions)
+				d.postings[term] = docs
+				i := sort.SearchStrings(d.terms, term)
+				d.terms = append(d.terms, "")
+				copy(d.terms[i+1:], d.terms[i:])
+				d.terms[i] = term
+			}
+			occ, ok := docs[p.ID]
+			if !ok {
+				occ = &positions{}
+				docs[p.ID] = occ
+			}
+			occ[f] = append(occ[f], pos)
+		}
+	}
+	d.docs[p.ID] = doc
+}
+
+func (d *indexData) remove(id int64) {
+	doc, ok := d.docs[id]
+	if !ok {
+		return
+	}
+
+	for f := field(0); f < numFields; f++ {
+		d.totalLens[f] -= doc.lengths[f]
+		for _, term := range terms(fieldText(doc.post, f)) {
+			docs, ok := d.postings[term]
+			if !ok {
+				continue
+			}
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(d.postings, term)
+				i := sort.SearchStrings(d.terms, term)
+				d.terms = append(d.terms[:i], d.terms[i+1:]...)
+			}
+		}
+	}
+	delete(d.docs, id)
+}
+
+// expand returns the indexed words that start with prefix.
+func (d *indexData) expand(prefix string) []string {
+	i := sort.SearchStrings(d.terms, prefix)
+	var out []string
+	for ; i < len(d.terms) && strings.HasPrefix(d.terms[i], prefix); i++ {
+		if len(out) == maxPrefixExpansions {
+			break
+		}
+		out = append(out, d.terms[i])
+	}
+	return out
+}
+
+// match returns, for every post matching c, how often c occurs in each field,
+// and the words that matched (for highlighting).
+func (d *indexData) match(c clause) (map[int64]*[numFields]float64, []string) {
+	freqs := make(map[int64]*[numFields]float64)
+	add := func(id int64, f field, n int) {
+		fr, ok := freqs[id]
+		if !ok {
+			fr = &[numFields]float64{}
+			freqs[id] = fr
+		}
+		fr[f] += float64(n)
+	}
+
+	switch c.kind {
+	case clauseTerm, clausePrefix:
+		words := c.terms
+		if c.kind == clausePrefix {
+			words = d.expand(c.terms[0])
+		}
+		for _, w := range words {
+			for id, occ := range d.postings[w] {
+				for f := field(0); f < numFields; f++ {
+					if n := len(occ[f]); n > 0 {
+						add(id, f, n)
+					}
+				}
+			}
+		}
+		return freqs, words
+
+	default:
+		first := d.postings[c.terms[0]]
+		for id, occ := range first {
+			for f := field(0); f < numFields; f++ {
+				if n := d.phraseCount(id, f, occ[f], c.terms[1:]); n > 0 {
+					add(id, f, n)
+				}
+			}
+		}
+		return freqs, c.terms
+	}
+}
+
+// phraseCount counts the start positions in starts that are followed by
+// rest, word for word, in field f of post id.
+func (d *indexData) phraseCount(id int64, f field, starts []int, rest []string) int {
+	following := make([][]int, len(rest))
+	for i, w := range rest {
+		occ, ok := d.postings[w][id]
+		if !ok || len(occ[f]) == 0 {
+			return 0
+		}
+		following[i] = occ[f]
+	}
+
+	count := 0
+	for _, start := range starts {
+		matched := true
+		for i, list := range following {
+			want := start + i + 1
+			j := sort.SearchInts(list, want)
+			if j == len(list) || list[j] != want {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+		}
+	}
+	return count
+}
+
+// Index is an in-memory inverted index over the title, description, category,
+// tags and content of every post. It implements models.PostIndex so the model
+// layer can keep it current, and is safe for concurrent use.
+type Index struct {
+	mu   sync.RWMutex
+	data *indexData
+	// pending records writes made while Rebuild is loading posts, so they
+	// can be replayed on the freshly built data. It is nil otherwise.
+	pending []func(*indexData)
+
+	rebuildMu sync.Mutex
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{data: newIndexData()}
+}
+
+func (ix *Index) apply(op func(*indexData)) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	op(ix.data)
+	if ix.pending != nil {
+		ix.pending = append(ix.pending, op)
+	}
+}
+
+// IndexPost adds p to the index or replaces the indexed copy.
+func (ix *Index) IndexPost(p models.Post) {
+	ix.apply(func(d *indexData) { d.add(p) })
+}
+
+// RemovePost drops the post with the given ID from the index.
+func (ix *Index) RemovePost(id int64) {
+	ix.apply(func(d *indexData) { d.remove(id) })
+}
+
+// RemoveAllPosts empties the index.
+func (ix *Index) RemoveAllPosts() {
+	ix.apply(func(d *indexData) { *d = *newIndexData() })
+}
+
+// Rebuild replaces the index contents with every post in the active store.
+// Posts written while the store is being read are indexed as well.
+func (ix *Index) Rebuild(ctx context.Context) error {
+	ix.rebuildMu.Lock()
+	defer ix.rebuildMu.Unlock()
+
+	ix.mu.Lock()
+	ix.pending = []func(*indexData){}
+	ix.mu.Unlock()
+
+	posts, err := models.GetAllPosts(ctx)
+	if err != nil {
+		ix.mu.Lock()
+		ix.pending = nil
+		ix.mu.Unlock()
+		return err
+	}
+
+	fresh := newIndexData()
+	for _, p := range posts {
+		fresh.add(p)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, op := range ix.pending {
+		op(fresh)
+	}
+	ix.pending = nil
+	ix.data = fresh
+	return nil
+}
+
+// Search runs q against the index and returns one page of results, best
+// match first.
+func (ix *Index) Search(q string, opts Options) (*Results, error) {
+	clauses, err := parseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	d := ix.data
+
+	var avgLens [numFields]float64
+	if n := len(d.docs); n > 0 {
+		for f := range avgLens {
+			avgLens[f] = float64(d.totalLens[f]) / float64(n)
+		}
+	}
+
+	scores := make(map[int64]float64)
+	highlight := make(map[string]bool)
+	for i, c := range clauses {
+		freqs, words := d.match(c)
+		for _, w := range words {
+			highlight[w] = true
+		}
+
+		df := float64(len(freqs))
+		idf := math.Log(1 + (float64(len(d.docs))-df+0.5)/(df+0.5))
+
+		next := make(map[int64]float64, len(freqs))
+		for id, fr := range freqs {
+			// Every clause must match, so only posts that matched all
+			// previous clauses are kept.
+			if _, ok := scores[id]; !ok && i > 0 {
+				continue
+			}
+			doc := d.docs[id]
+			if doc.post.Status != "published" && !opts.IncludeDrafts {
+				continue
+			}
+			if doc.post.MembersOnly && opts.ExcludeMembersOnly {
+				continue
+			}
+
+			// BM25F: per-field frequencies are length-normalized and
+			// weighted before the usual saturation.
+			tf := 0.0
+			for f := field(0); f < numFields; f++ {
+				if fr[f] == 0 {
+					continue
+				}
+				norm := 1 - b
+				if avgLens[f] > 0 {
+					norm += b * float64(doc.lengths[f]) / avgLens[f]
+				}
+				tf += fieldWeights[f] * fr[f] / norm
+			}
+			next[id] = scores[id] + idf*tf*(k1+1)/(tf+k1)
+		}
+		scores = next
+	}
+
+	hits := make([]Result, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, newResult(d.docs[id].post, score))
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].CreatedAt.Equal(hits[j].CreatedAt) {
+			return hits[i].CreatedAt.After(hits[j].CreatedAt)
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	results := &Results{Total: len(hits), Results: []Result{}}
+	if opts.Offset < len(hits) {
+		hits = hits[opts.Offset:]
+		if len(hits) > limit {
+			hits = hits[:limit]
+		}
+		for i := range hits {
+			hits[i].Snippet = snippet(d.docs[hits[i].ID].post, highlight)
+		}
+		results.Results = hits
+	}
+	return results, nil
+}
@@ -0,0 +1,139 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// maxClauses bounds the number of terms, prefixes and phrases in one query.
+const maxClauses = 16
+
+var (
+	// ErrEmptyQuery is returned when a query contains no searchable words.
+	ErrEmptyQuery = errors.New("search query is empty")
+	// ErrQueryTooLong is returned when a query has more than maxClauses parts.
+	ErrQueryTooLong = errors.New("search query has too many terms")
+)
+
+// token is one normalized word of a text together with its byte offsets in
+// the original text, which snippets use to place highlights.
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lower-cased runs of letters and digits.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+func terms(text string) []string {
+	tokens := tokenize(text)
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.term
+	}
+	return out
+}
+
+type clauseKind int
+
+const (
+	clauseTerm clauseKind = iota
+	clausePrefix
+	clausePhrase
+)
+
+// clause is one part of a query that a document must match. Term and prefix
+// clauses have a single entry in terms; phrases have two or more.
+type clause struct {
+	kind  clauseKind
+	terms []string
+}
+
+// parseQuery splits a query into clauses. Every clause must match:
+//
+//	go channels      both words, anywhere in the post
+//	"error handling" the words next to each other, in that order
+//	conc*            any word starting with "conc"
+//
+// A word that the tokenizer splits apart (such as "e-mail") is treated as a
+// phrase.
+func parseQuery(q string) ([]clause, error) {
+	var clauses []clause
+
+	for q != "" {
+		q = strings.TrimLeftFunc(q, unicode.IsSpace)
+		if q == "" {
+			break
+		}
+
+		if q[0] == '"' {
+			// An unterminated phrase runs to the end of the query.
+			end := strings.IndexByte(q[1:], '"')
+			phrase := q[1:]
+			q = ""
+			if end >= 0 {
+				phrase, q = phrase[:end], phrase[end+1:]
+			}
+			clauses = appendWords(clauses, terms(phrase))
+			continue
+		}
+
+		end := strings.IndexFunc(q, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		word := q
+		q = ""
+		if end >= 0 {
+			word, q = word[:end], word[end:]
+		}
+
+		if strings.HasSuffix(word, "*") {
+			words := terms(word)
+			if len(words) == 0 {
+				continue
+			}
+			clauses = appendWords(clauses, words[:len(words)-1])
+			clauses = append(clauses, clause{kind: clausePrefix, terms: words[len(words)-1:]})
+			continue
+		}
+		clauses = appendWords(clauses, terms(word))
+	}
+
+	if len(clauses) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	if len(clauses) > maxClauses {
+		return nil, ErrQueryTooLong
+	}
+	return clauses, nil
+}
+
+// appendWords adds words as a single term or, for several words, a phrase.
+func appendWords(clauses []clause, words []string) []clause {
+	switch len(words) {
+	case 0:
+		return clauses
+	case 1:
+		return append(clauses, clause{kind: clauseTerm, terms: words})
+	default:
+		return append(clauses, clause{kind: clausePhrase, terms: words})
+	}
+}
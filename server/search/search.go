@@ -0,0 +1,118 @@
+// Package search provides full-text search over blog posts. An in-memory
+// inverted index covers the title, description, category, tags and content
+// of every post and ranks matches with BM25.
+//
+// The index is built from the store at startup and kept current through
+// models.SetPostIndex, so it only sees writes made by this process. When
+// several server instances share one database, each should rebuild
+// periodically (see SEARCH_REBUILD_INTERVAL in main.go).
+package search
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/blog_backend/media"
+	"example.com/blog_backend/models"
+)
+
+const (
+	// DefaultLimit is the page size used when Options.Limit is not set.
+	DefaultLimit = 20
+	// MaxLimit caps Options.Limit.
+	MaxLimit = 100
+)
+
+// ErrNotReady is returned by Search before Init has built an index.
+var ErrNotReady = errors.New("search index is not ready")
+
+// Options controls which posts a search returns.
+type Options struct {
+	// IncludeDrafts also returns draft and scheduled posts; readers only see
+	// published ones.
+	IncludeDrafts bool
+	// ExcludeMembersOnly leaves out members-only posts, for anonymous
+	// readers.
+	ExcludeMembersOnly bool
+	Limit              int
+	Offset             int
+}
+
+// Result is one matching post. Snippet is HTML-escaped text from the post
+// with the matched words wrapped in <mark> elements. CoverImage is left for
+// the API to fill in, as for posts.
+type Result struct {
+	ID            int64        `json:"id"`
+	Title         string       `json:"title"`
+	Slug          string       `json:"slug"`
+	Description   string       `json:"description"`
+	Category      string       `json:"category"`
+	Tags          []string     `json:"tags"`
+	CoverImageKey string       `json:"cover_image_key"`
+	CoverImage    *media.Image `json:"cover_image,omitempty"`
+	Status        string       `json:"status"`
+	MembersOnly   bool         `json:"members_only"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	AuthorID      int64        `json:"author_id"`
+	Score         float64      `json:"score"`
+	Snippet       string       `json:"snippet"`
+}
+
+func newResult(p models.Post, score float64) Result {
+	return Result{
+		ID:            p.ID,
+		Title:         p.Title,
+		Slug:          p.Slug,
+		Description:   p.Description,
+		Category:      p.Category,
+		Tags:          p.Tags,
+		CoverImageKey: p.CoverImageKey,
+		Status:        p.Status,
+		MembersOnly:   p.MembersOnly,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+		AuthorID:      p.AuthorID,
+		Score:         score,
+	}
+}
+
+// Results is one page of search results. Total counts every matching post.
+type Results struct {
+	Results []Result `json:"results"`
+	Total   int      `json:"total"`
+}
+
+// activeIndex is the index used by Search.
+var activeIndex *Index
+
+// SetIndex makes ix the index used by Search and registers it with the model
+// layer so post writes keep it current. Pass nil to disable search.
+func SetIndex(ix *Index) {
+	activeIndex = ix
+	if ix == nil {
+		models.SetPostIndex(nil)
+		return
+	}
+	models.SetPostIndex(ix)
+}
+
+// Init builds an index from every post in the active store and makes it the
+// active index.
+func Init(ctx context.Context) (*Index, error) {
+	ix := NewIndex()
+	if err := ix.Rebuild(ctx); err != nil {
+		return nil, err
+	}
+	SetIndex(ix)
+	return ix, nil
+}
+
+// Search runs q against the active index.
+func Search(q string, opts Options) (*Results, error) {
+	if activeIndex == nil {
+		return nil, ErrNotReady
+	}
+	return activeIndex.Search(q, opts)
+}
@@ -0,0 +1,176 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"example.com/blog_backend/models"
+)
+
+// setupIndex installs a fresh memory store with a "go" category and an empty
+// active index, and saves the given posts through the model layer.
+func setupIndex(t *testing.T, posts ...*models.Post) {
+	t.Helper()
+	models.SetStore(models.NewMemoryStore())
+	category := &models.Category{Name: "go"}
+	if err := category.Save(context.Background()); err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+	if _, err := Init(context.Background()); err != nil {
+		t.Fatalf("failed to build index: %v", err)
+	}
+	t.Cleanup(func() { SetIndex(nil) })
+
+	for _, p := range posts {
+		if err := p.Save(context.Background()); err != nil {
+			t.Fatalf("failed to save post: %v", err)
+		}
+	}
+}
+
+func resultIDs(t *testing.T, q string, opts Options) []int64 {
+	t.Helper()
+	results, err := Search(q, opts)
+	if err != nil {
+		t.Fatalf("search %q failed: %v", q, err)
+	}
+	ids := make([]int64, len(results.Results))
+	for i, r := range results.Results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchRanksAndMatchesQueries(t *testing.T) {
+	setupIndex(t,
+		&models.Post{Title: "Concurrency in Go", Content: "Goroutines and channels make concurrent code readable."},
+		&models.Post{Title: "Error handling", Content: "Go treats errors as values. Handling errors explicitly keeps control flow visible."},
+		&models.Post{Title: "Travel notes", Category: "go", Content: "We had to go home early because of the weather."},
+		&models.Post{Title: "Secret plans", Content: "Channels everywhere.", Status: "draft"},
+	)
+
+	cases := []struct {
+		query string
+		opts  Options
+		want  []int64
+	}{
+		// The title match outranks the category and body matches.
+		{"concurrency", Options{}, []int64{1}},
+		{"channels", Options{}, []int64{1}},
+		{"channels", Options{IncludeDrafts: true}, []int64{4, 1}},
+		{`"handling errors"`, Options{}, []int64{2}},
+		{`"errors handling"`, Options{}, nil},
+		{"concurr*", Options{}, []int64{1}},
+		{"go weather", Options{}, []int64{3}},
+	}
+	for _, tc := range cases {
+		got := resultIDs(t, tc.query, tc.opts)
+		if !equalIDs(got, tc.want) {
+			t.Errorf("%q (drafts=%v): expected %v, got %v", tc.query, tc.opts.IncludeDrafts, tc.want, got)
+		}
+	}
+
+	results, err := Search("go", Options{})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if results.Total != 3 || results.Results[0].ID != 1 {
+		t.Errorf("expected 3 matches led by the title match, got %+v", results)
+	}
+
+	page, err := Search("go", Options{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if page.Total != 3 || len(page.Results) != 1 || page.Results[0].ID != results.Results[1].ID {
+		t.Errorf("expected the second match as page two, got %+v", page)
+	}
+}
+
+func TestSearchFollowsPostWrites(t *testing.T) {
+	post := &models.Post{Title: "Draft title", Content: "Original body about databases."}
+	setupIndex(t, post)
+	ctx := context.Background()
+
+	if got := resultIDs(t, "databases", Options{}); !equalIDs(got, []int64{post.ID}) {
+		t.Fatalf("expected saved post to be found, got %v", got)
+	}
+
+	updated := models.Post{ID: post.ID, Title: "Final title", Content: "Rewritten body about caching."}
+	if err := updated.Update(ctx, 0); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+	if got := resultIDs(t, "databases", Options{}); len(got) != 0 {
+		t.Errorf("expected old content to be gone from the index, got %v", got)
+	}
+	if got := resultIDs(t, "caching", Options{}); !equalIDs(got, []int64{post.ID}) {
+		t.Errorf("expected updated content to be found, got %v", got)
+	}
+
+	if err := updated.Delete(ctx); err != nil {
+		t.Fatalf("failed to delete post: %v", err)
+	}
+	if got := resultIDs(t, "caching", Options{}); len(got) != 0 {
+		t.Errorf("expected deleted post to be gone from the index, got %v", got)
+	}
+}
+
+func TestSearchHighlightsSnippet(t *testing.T) {
+	content := strings.Repeat("Filler text that does not matter. ", 20) +
+		"The <b>borrow</b> checker\nis strict about borrow rules. " +
+		strings.Repeat("More filler. ", 20)
+	setupIndex(t, &models.Post{Title: "Rust", Content: content})
+
+	results, err := Search("borrow", Options{})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	s := results.Results[0].Snippet
+	if !strings.Contains(s, "&lt;b&gt;<mark>borrow</mark>&lt;/b&gt; checker is strict about <mark>borrow</mark> rules") {
+		t.Errorf("unexpected snippet %q", s)
+	}
+	if !strings.HasPrefix(s, "…") || !strings.HasSuffix(s, "…") {
+		t.Errorf("expected a snippet cut on both sides, got %q", s)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	clauses, err := parseQuery(`Go "error handling" conc* e-mail "unterminated phrase`)
+	if err != nil {
+		t.Fatalf("parseQuery failed: %v", err)
+	}
+	want := []clause{
+		{clauseTerm, []string{"go"}},
+		{clausePhrase, []string{"error", "handling"}},
+		{clausePrefix, []string{"conc"}},
+		{clausePhrase, []string{"e", "mail"}},
+		{clausePhrase, []string{"unterminated", "phrase"}},
+	}
+	if len(clauses) != len(want) {
+		t.Fatalf("expected %d clauses, got %+v", len(want), clauses)
+	}
+	for i := range want {
+		if clauses[i].kind != want[i].kind || strings.Join(clauses[i].terms, " ") != strings.Join(want[i].terms, " ") {
+			t.Errorf("clause %d: expected %+v, got %+v", i, want[i], clauses[i])
+		}
+	}
+
+	for _, q := range []string{"", "  ", `"" * !!`} {
+		if _, err := parseQuery(q); err != ErrEmptyQuery {
+			t.Errorf("%q: expected ErrEmptyQuery, got %v", q, err)
+		}
+	}
+}
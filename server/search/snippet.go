@@ -0,0 +1,115 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"example.com/blog_backend/models"
+)
+
+const (
+	// snippetLength is the approximate snippet size in bytes.
+	snippetLength = 160
+	// snippetLead is how much text is kept before the first highlighted word.
+	snippetLead = 40
+)
+
+// snippet returns a highlighted passage from the content of p, or from its
+// description when only that matched. Posts that matched on the title or
+// category alone get their excerpt without highlights.
+func snippet(p models.Post, highlight map[string]bool) string {
+	for _, text := range []string{p.Content, p.Description} {
+		if s, ok := highlightText(text, highlight); ok {
+			return s
+		}
+	}
+	return html.EscapeString(p.Excerpt)
+}
+
+// highlightText picks the window of text with the most highlighted words
+// and marks them. It reports false when no word of text is highlighted.
+func highlightText(text string, highlight map[string]bool) (string, bool) {
+	tokens := tokenize(text)
+
+	var hits []token
+	for _, t := range tokens {
+		if highlight[t.term] {
+			hits = append(hits, t)
+		}
+	}
+	if len(hits) == 0 {
+		return "", false
+	}
+
+	// Start the window at the hit that is followed by the most other hits.
+	best, bestCount := 0, 0
+	for i := range hits {
+		count := 0
+		for j := i; j < len(hits) && hits[j].end <= hits[i].start+snippetLength; j++ {
+			count++
+		}
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+
+	// Back up to a word start shortly before the first hit, then end at the
+	// last word that fits in the window.
+	start := hits[best].start
+	for _, t := range tokens {
+		if t.start >= hits[best].start-snippetLead {
+			start = t.start
+			break
+		}
+	}
+	end := hits[best].end
+	for _, t := range tokens {
+		if t.start >= start && t.end <= start+snippetLength {
+			end = t.end
+		}
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	pos := start
+	for _, h := range hits {
+		if h.start < start || h.end > end {
+			continue
+		}
+		sb.WriteString(html.EscapeString(collapseSpace(text[pos:h.start])))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(text[h.start:h.end]))
+		sb.WriteString("</mark>")
+		pos = h.end
+	}
+	sb.WriteString(html.EscapeString(collapseSpace(text[pos:end])))
+	if end < len(text) {
+		sb.WriteString("…")
+	}
+	return sb.String(), true
+}
+
+// collapseSpace replaces every run of whitespace, including line breaks, with
+// a single space.
+func collapseSpace(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+	if space {
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
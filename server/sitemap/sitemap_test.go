@@ -0,0 +1,42 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSet(t *testing.T) {
+	data, err := URLSet([]URL{
+		{Loc: "https://blog.example.com/blog.html?post=a&b", LastMod: time.Date(2024, 3, 1, 9, 0, 0, 0, time.FixedZone("CET", 3600))},
+		{Loc: "https://blog.example.com/blog.html"},
+	})
+	if err != nil {
+		t.Fatalf("URLSet failed: %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://blog.example.com/blog.html?post=a&amp;b</loc>
+    <lastmod>2024-03-01T08:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://blog.example.com/blog.html</loc>
+  </url>
+</urlset>
+`
+	if string(data) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, data)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	data, err := Index([]URL{{Loc: "https://api.example.com/sitemaps/1.xml"}})
+	if err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<sitemapindex xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n  <sitemap>\n    <loc>https://api.example.com/sitemaps/1.xml</loc>") {
+		t.Errorf("unexpected index:\n%s", data)
+	}
+}